@@ -3,8 +3,10 @@ package api
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	db "github.com/SaishNaik/simplebank/db/sqlc"
 	"github.com/SaishNaik/simplebank/token"
+	"github.com/SaishNaik/simplebank/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 	"net/http"
@@ -14,6 +16,8 @@ type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
 }
 
+// createAccount opens a personal account, only a banker can change its type, see updateAccountType
+
 func (s *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
@@ -24,9 +28,11 @@ func (s *Server) createAccount(ctx *gin.Context) {
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
 	args := db.CreateAccountParams{
-		Owner:    authPayload.Username,
-		Balance:  0,
-		Currency: req.Currency,
+		Owner:         authPayload.Username,
+		Balance:       0,
+		Currency:      req.Currency,
+		AccountNumber: utils.NewAccountNumber(),
+		Type:          utils.AccountTypePersonal,
 	}
 
 	account, err := s.store.CreateAccount(ctx, args)
@@ -45,7 +51,7 @@ func (s *Server) createAccount(ctx *gin.Context) {
 }
 
 type getAccountRequest struct {
-	ID int64 `uri:"id" binding:"required,min=1"`
+	ID accountRef `uri:"id" binding:"required,account_ref"`
 }
 
 func (s *Server) getAccount(ctx *gin.Context) {
@@ -57,7 +63,7 @@ func (s *Server) getAccount(ctx *gin.Context) {
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	account, err := s.store.GetAccount(ctx, req.ID)
+	account, err := s.getAccountByRef(ctx, req.ID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
@@ -101,3 +107,172 @@ func (s *Server) listAccount(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, accounts)
 }
+
+type updateAccountStatusRequest struct {
+	Status string `json:"status" binding:"required,account_status"`
+}
+
+// updateAccountStatus freezes or closes an account of the authenticated user. Unfreezing and reopening a closed
+// account are left to bankers so that a freeze holds even when the credentials of the owner are compromised.
+func (s *Server) updateAccountStatus(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateAccountStatusRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	account, err := s.getAccountByRef(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if req.Status == utils.AccountStatusActive {
+		user, err := s.store.GetUser(ctx, authPayload.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if user.Role != utils.BankerRole {
+			err = errors.New("only a banker can unfreeze or reopen an account")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+	} else if account.Owner != authPayload.Username {
+		err = errors.New("account doesnt belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := s.store.UpdateAccountStatusTx(ctx, db.UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    req.Status,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidStatusTransition) {
+			err = fmt.Errorf("%w: account [%d] cannot go from %s to %s", err, account.ID, account.Status, req.Status)
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrAccountNotEmpty) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Account)
+}
+
+type updateAccountAliasRequest struct {
+	// Alias is cleared when empty
+	Alias string `json:"alias" binding:"omitempty,min=3,max=32,lowercase,alphanum"`
+}
+
+// updateAccountAlias sets the alias senders can pay the account by, see resolveRecipient
+func (s *Server) updateAccountAlias(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateAccountAliasRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	account, err := s.getAccountByRef(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if account.Owner != authPayload.Username {
+		err = errors.New("account doesnt belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	account, err = s.store.UpdateAccountAlias(ctx, db.UpdateAccountAliasParams{
+		Alias: sql.NullString{String: req.Alias, Valid: req.Alias != ""},
+		ID:    account.ID,
+	})
+	if err != nil {
+		if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, account)
+}
+
+type updateAccountTypeRequest struct {
+	// Type decides which fees the account is charged
+	Type string `json:"type" binding:"required,account_type"`
+}
+
+// updateAccountType changes the type of an account on behalf of a banker
+func (s *Server) updateAccountType(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateAccountTypeRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := s.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if user.Role != utils.BankerRole {
+		err = errors.New("only a banker can change the type of an account")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	account, err := s.getAccountByRef(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	account, err = s.store.UpdateAccountType(ctx, db.UpdateAccountTypeParams{
+		Type: req.Type,
+		ID:   account.ID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, account)
+}
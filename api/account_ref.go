@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/utils"
+	"strconv"
+)
+
+// accountRef identifies an account by its id or by its account number, wherever the api accepts an account id.
+// In json it can be given as a number or a string.
+type accountRef string
+
+func (ref *accountRef) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*ref = accountRef(s)
+		return nil
+	}
+	var id json.Number
+	if err := json.Unmarshal(data, &id); err != nil {
+		return err
+	}
+	*ref = accountRef(id)
+	return nil
+}
+
+// accountID returns the id ref holds, it is false when ref is an account number or invalid
+func (ref accountRef) accountID() (int64, bool) {
+	id, err := strconv.ParseInt(string(ref), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// getAccountByRef loads the account ref identifies, refs are checked by the account_ref validator beforehand
+func (s *Server) getAccountByRef(ctx context.Context, ref accountRef) (db.Account, error) {
+	if utils.IsValidAccountNumber(string(ref)) {
+		return s.store.GetAccountByNumber(ctx, string(ref))
+	}
+	id, ok := ref.accountID()
+	if !ok {
+		return db.Account{}, fmt.Errorf("invalid account reference %q", ref)
+	}
+	return s.store.GetAccount(ctx, id)
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"time"
+)
+
+const transferModeAuthorize = "authorize"
+
+var (
+	errHoldsDisabled = errors.New("authorizing transfers is disabled")
+	// errHoldVoidBeforeExpiry is returned when the payer voids a hold the payee may still capture
+	errHoldVoidBeforeExpiry = errors.New("only the payee can void a hold before it expires")
+)
+
+// authorizeTransfer holds the amount of a transfer on the from account until it is captured, voided or expires
+func (s *Server) authorizeTransfer(ctx *gin.Context, authPayload *token.Payload, fromAccount db.Account, toAccountID int64, req transferRequest) {
+	if s.config.HoldDuration <= 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errHoldsDisabled))
+		return
+	}
+
+	result, err := s.store.AuthorizeTransferTx(ctx, db.AuthorizeTransferTxParams{
+		Owner:         authPayload.Username,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccountID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		ExpiresAt:     time.Now().Add(s.config.HoldDuration),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrAccountNotActive) || errors.Is(err, db.ErrInsufficientFunds) ||
+			errors.Is(err, db.ErrTransferLimitExceeded) || errors.Is(err, db.ErrApprovalRequired) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+type holdRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (s *Server) getHold(ctx *gin.Context) {
+	var req holdRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, _, ok := s.holdParty(ctx, req.ID)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, hold)
+}
+
+type captureHoldRequest struct {
+	// Amount is optional, without it the whole hold is captured
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// captureHold moves all or part of a held amount to the to account, the rest of the hold is released.
+// Both the payer and the payee can capture.
+func (s *Server) captureHold(ctx *gin.Context) {
+	var uri holdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req captureHoldRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, _, ok := s.holdParty(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	result, err := s.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{
+		HoldID: hold.ID,
+		Amount: req.Amount,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrHoldNotAuthorized),
+			errors.Is(err, db.ErrHoldExpired),
+			errors.Is(err, db.ErrCaptureExceedsHold):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrAccountNotActive), errors.Is(err, db.ErrTransferLimitExceeded), errors.Is(err, db.ErrApprovalRequired):
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// voidHold releases a hold without moving money. The payee can void it at any time,
+// the payer only once it has expired, so a payer cannot take back funds the payee is about to capture.
+func (s *Server) voidHold(ctx *gin.Context) {
+	var req holdRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, payee, ok := s.holdParty(ctx, req.ID)
+	if !ok {
+		return
+	}
+	if !payee && hold.ExpiresAt.After(time.Now()) {
+		ctx.JSON(http.StatusForbidden, errorResponse(errHoldVoidBeforeExpiry))
+		return
+	}
+
+	result, err := s.store.VoidHoldTx(ctx, db.VoidHoldTxParams{HoldID: hold.ID})
+	if err != nil {
+		if errors.Is(err, db.ErrHoldNotAuthorized) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// holdParty loads a hold authorized by the authenticated user or paying into one of their accounts,
+// otherwise it responds and returns false. payee is set when the authenticated user owns the to account.
+func (s *Server) holdParty(ctx *gin.Context, id int64) (hold db.Hold, payee bool, ok bool) {
+	hold, err := s.store.GetHold(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return hold, false, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false, false
+	}
+
+	toAccount, err := s.store.GetAccount(ctx, hold.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	payee = toAccount.Owner == authPayload.Username
+	if hold.Owner != authPayload.Username && !payee {
+		err = errors.New("hold doesnt belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return hold, false, false
+	}
+	return hold, payee, true
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	requestIDHeaderKey = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+// requestLogger assigns or propagates the request id and logs every request once it has been handled
+func requestLogger() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestID := ctx.GetHeader(requestIDHeaderKey)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx.Request = ctx.Request.WithContext(utils.ContextWithRequestID(ctx.Request.Context(), requestID))
+		ctx.Header(requestIDHeaderKey, requestID)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+		}
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			attrs = append(attrs, slog.String("username", payload.(*token.Payload).Username))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		slog.LogAttrs(ctx.Request.Context(), level, "received http request", attrs...)
+	}
+}
+
+// recoverer logs panics through slog instead of gin's plain text recovery writer
+func recoverer(ctx *gin.Context, err any) {
+	slog.ErrorContext(ctx.Request.Context(), "recovered from panic",
+		slog.Any("error", err),
+		slog.String("stack", string(debug.Stack())),
+	)
+	ctx.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// validRequestID rejects client supplied ids that are too long or could corrupt log lines
+func validRequestID(requestID string) bool {
+	if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
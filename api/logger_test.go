@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestLogger(t *testing.T) {
+	testCases := []struct {
+		name          string
+		setupRequest  func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, logLine map[string]any)
+	}{
+		{
+			name: "GeneratesRequestID",
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, logLine map[string]any) {
+				requestID := recorder.Header().Get(requestIDHeaderKey)
+				require.NotEmpty(t, requestID)
+				require.Equal(t, requestID, logLine["request_id"])
+				require.NotContains(t, logLine, "username")
+			},
+		},
+		{
+			name: "PropagatesRequestID",
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				request.Header.Set(requestIDHeaderKey, "abc-123")
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, logLine map[string]any) {
+				require.Equal(t, "abc-123", recorder.Header().Get(requestIDHeaderKey))
+				require.Equal(t, "abc-123", logLine["request_id"])
+			},
+		},
+		{
+			name: "ReplacesInvalidRequestID",
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				request.Header.Set(requestIDHeaderKey, strings.Repeat("a", maxRequestIDLength+1))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, logLine map[string]any) {
+				requestID := recorder.Header().Get(requestIDHeaderKey)
+				require.NotEmpty(t, requestID)
+				require.LessOrEqual(t, len(requestID), maxRequestIDLength)
+			},
+		},
+		{
+			name: "LogsUsername",
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, logLine map[string]any) {
+				require.Equal(t, "user", logLine["username"])
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := utils.NewLogger(utils.Config{LogFormat: "json"}, &buf)
+			require.NoError(t, err)
+
+			defaultLogger := slog.Default()
+			slog.SetDefault(logger)
+			defer slog.SetDefault(defaultLogger)
+
+			// the store is only used to check that the token has not been revoked
+			server := NewTestServer(t, mockdb.NewMockStore(gomock.NewController(t)))
+			path := "/logged"
+			server.router.GET(path, authMiddleware(server.tokenMaker, server.store), func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{})
+			})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			tc.setupRequest(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+
+			var logLine map[string]any
+			err = json.Unmarshal(buf.Bytes(), &logLine)
+			require.NoError(t, err)
+			require.Equal(t, http.MethodGet, logLine["method"])
+			require.Equal(t, path, logLine["route"])
+			require.EqualValues(t, recorder.Code, logLine["status"])
+			tc.checkResponse(t, recorder, logLine)
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package api
 
 import (
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
 	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/mail"
 	"github.com/SaishNaik/simplebank/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"os"
 	"testing"
@@ -17,8 +20,27 @@ func TestMain(m *testing.M) {
 
 func NewTestServer(t *testing.T, store db.Store) *Server {
 	config := utils.Config{
-		TokenSymmetricKey:   utils.RandomString(32),
-		AccessTokenDuration: time.Minute,
+		TokenSymmetricKey:         utils.RandomString(32),
+		AccessTokenDuration:       time.Minute,
+		MFAEncryptionKey:          utils.RandomString(32),
+		MFAIssuer:                 "SimpleBank",
+		MFAChallengeDuration:      time.Minute,
+		MailDriver:                mail.DriverMemory,
+		EmailVerificationURL:      "http://localhost:8080/users/verify_email",
+		EmailVerificationDuration: time.Hour,
+		PasswordResetURL:          "http://localhost:3000/reset-password",
+		PasswordResetDuration:     time.Hour,
+		HoldDuration:              time.Hour,
+		TransferApprovalDuration:  time.Hour,
+		PaymentRequestDuration:    time.Hour,
+		PayeeCoolingOffPeriod:     time.Hour,
+	}
+
+	// authenticated requests check when the password was changed, tests that care expect it themselves
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().
+			GetPasswordChangedAt(gomock.Any(), gomock.Any()).
+			AnyTimes()
 	}
 
 	server, err := NewServer(config, store)
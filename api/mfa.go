@@ -0,0 +1,267 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	recoveryCodeCount = 10
+	// recoveryCodeSize is the number of random bytes in a recovery code, encoded as 10 base32 characters
+	recoveryCodeSize = 6
+)
+
+var errInvalidVerificationCode = errors.New("invalid verification code")
+
+type enrollTOTPResponse struct {
+	Secret        string   `json:"secret"`
+	OTPAuthURI    string   `json:"otpauth_uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (s *Server) enrollTOTP(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	encryptedSecret, err := utils.EncryptSecret(s.config.MFAEncryptionKey, secret)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	recoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	recoveryCodeHashes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		recoveryCodeHashes[i] = hashRecoveryCode(code)
+	}
+
+	_, err = s.store.EnrollTOTPTx(ctx, db.EnrollTOTPTxParams{
+		Username:           authPayload.Username,
+		EncryptedSecret:    encryptedSecret,
+		RecoveryCodeHashes: recoveryCodeHashes,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = errors.New("totp is already enabled")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	resp := enrollTOTPResponse{
+		Secret:        secret,
+		OTPAuthURI:    utils.TOTPURI(s.config.MFAIssuer, authPayload.Username, secret),
+		RecoveryCodes: recoveryCodes,
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+type verifyTOTPRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type verifyTOTPResponse struct {
+	MFAEnabled bool `json:"mfa_enabled"`
+}
+
+func (s *Server) verifyTOTP(ctx *gin.Context) {
+	var req verifyTOTPRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	userTotp, err := s.store.GetUserTOTP(ctx, authPayload.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = errors.New("totp enrolment not found")
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if userTotp.ConfirmedAt.Valid {
+		err = errors.New("totp is already enabled")
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	secret, err := utils.DecryptSecret(s.config.MFAEncryptionKey, userTotp.EncryptedSecret)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	step, ok := utils.ValidateTOTP(secret, req.Code, time.Now())
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidVerificationCode))
+		return
+	}
+
+	_, err = s.store.ConfirmUserTOTP(ctx, db.ConfirmUserTOTPParams{
+		Username:     authPayload.Username,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = errors.New("totp is already enabled")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, verifyTOTPResponse{MFAEnabled: true})
+}
+
+type mfaChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+type loginUserMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	// Code is either the current totp code or one of the recovery codes
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// loginUserMFA exchanges the challenge returned by loginUser and a second factor for an access token
+func (s *Server) loginUserMFA(ctx *gin.Context) {
+	var req loginUserMFARequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := s.tokenMaker.VerifyToken(req.MFAToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if payload.TokenType != token.TokenTypeMFAChallenge {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(token.ErrInvalidToken))
+		return
+	}
+
+	if !s.checkLoginLockout(ctx, payload.Username) {
+		return
+	}
+
+	user, err := s.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(token.ErrInvalidToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ok, err := s.checkSecondFactor(ctx, user.Username, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !ok {
+		s.rejectLogin(ctx, user.Username, errInvalidVerificationCode)
+		return
+	}
+
+	s.loginSucceeded(ctx, user, []string{token.AMRPassword, token.AMROTP, token.AMRMFA})
+}
+
+// mfaEnabled reports whether the user has a confirmed second factor
+func (s *Server) mfaEnabled(ctx *gin.Context, username string) (bool, error) {
+	userTotp, err := s.store.GetUserTOTP(ctx, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return userTotp.ConfirmedAt.Valid, nil
+}
+
+// checkSecondFactor verifies a totp or recovery code, each of them can only be used once
+func (s *Server) checkSecondFactor(ctx *gin.Context, username string, code string) (bool, error) {
+	userTotp, err := s.store.GetUserTOTP(ctx, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !userTotp.ConfirmedAt.Valid {
+		return false, nil
+	}
+
+	if len(code) == 6 {
+		secret, err := utils.DecryptSecret(s.config.MFAEncryptionKey, userTotp.EncryptedSecret)
+		if err != nil {
+			return false, err
+		}
+		step, ok := utils.ValidateTOTP(secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		_, err = s.store.UseTOTPStep(ctx, db.UseTOTPStepParams{
+			Username: username,
+			Step:     step,
+		})
+		return usedOnce(err)
+	}
+
+	_, err = s.store.UseMFARecoveryCode(ctx, db.UseMFARecoveryCodeParams{
+		Username: username,
+		CodeHash: hashRecoveryCode(code),
+	})
+	return usedOnce(err)
+}
+
+// usedOnce maps the result of marking a code as used, sql.ErrNoRows means it was used before
+func usedOnce(err error) (bool, error) {
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b, err := utils.RandomBytes(recoveryCodeSize)
+		if err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return utils.HashToken(code)
+}
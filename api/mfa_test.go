@@ -0,0 +1,455 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnrollTOTPAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					EnrollTOTPTx(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, arg db.EnrollTOTPTxParams) (db.EnrollTOTPTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NotEmpty(t, arg.EncryptedSecret)
+						require.Len(t, arg.RecoveryCodeHashes, recoveryCodeCount)
+						return db.EnrollTOTPTxResult{}, nil
+					}).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var resp enrollTOTPResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				require.NotEmpty(t, resp.Secret)
+				require.True(t, strings.HasPrefix(resp.OTPAuthURI, "otpauth://totp/SimpleBank:"+user.Username))
+				require.Len(t, resp.RecoveryCodes, recoveryCodeCount)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					EnrollTOTPTx(gomock.Any(), gomock.Any()).
+					Return(db.EnrollTOTPTxResult{}, sql.ErrNoRows).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					EnrollTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/users/mfa/totp"
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestVerifyTOTPAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	userTotp, secret, mfaKey := randomUserTOTP(t, user.Username, false)
+
+	code, err := utils.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(userTotp, nil).Times(1)
+				arg := db.ConfirmUserTOTPParams{
+					Username:     user.Username,
+					LastUsedStep: utils.TOTPStep(time.Now()),
+				}
+				store.EXPECT().
+					ConfirmUserTOTP(gomock.Any(), gomock.Eq(arg)).
+					Return(userTotp, nil).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCode",
+			body: gin.H{"code": wrongTOTPCode(code)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(userTotp, nil).Times(1)
+				store.EXPECT().
+					ConfirmUserTOTP(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotEnrolled",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(db.UserTotp{}, sql.ErrNoRows).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				confirmed := userTotp
+				confirmed.ConfirmedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(confirmed, nil).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCodeFormat",
+			body: gin.H{"code": "12ab"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			server.config.MFAEncryptionKey = mfaKey
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/mfa/totp/verify"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginUserMFAAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	userTotp, secret, mfaKey := randomUserTOTP(t, user.Username, true)
+
+	code, err := utils.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	recoveryCode := "abcde-fghij"
+
+	mfaToken := func(t *testing.T, tokenMaker token.Maker) string {
+		payload, err := token.NewPayload(user.Username, time.Minute)
+		require.NoError(t, err)
+		payload.TokenType = token.TokenTypeMFAChallenge
+
+		mfaToken, err := tokenMaker.CreateTokenFromPayload(payload)
+		require.NoError(t, err)
+		return mfaToken
+	}
+
+	testCases := []struct {
+		name          string
+		body          func(t *testing.T, tokenMaker token.Maker) gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				return gin.H{"mfa_token": mfaToken(t, tokenMaker), "code": code}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(userTotp, nil).Times(1)
+				arg := db.UseTOTPStepParams{
+					Username: user.Username,
+					Step:     utils.TOTPStep(time.Now()),
+				}
+				store.EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Eq(arg)).
+					Return(userTotp, nil).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, true)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var resp loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				require.NotEmpty(t, resp.AccessToken)
+				require.Equal(t, user.Username, resp.User.Username)
+			},
+		},
+		{
+			name: "RecoveryCode",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				return gin.H{"mfa_token": mfaToken(t, tokenMaker), "code": strings.ToUpper(recoveryCode)}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(userTotp, nil).Times(1)
+				arg := db.UseMFARecoveryCodeParams{
+					Username: user.Username,
+					CodeHash: hashRecoveryCode(recoveryCode),
+				}
+				store.EXPECT().
+					UseMFARecoveryCode(gomock.Any(), gomock.Eq(arg)).
+					Return(db.MfaRecoveryCode{}, nil).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, true)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "ReplayedCode",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				return gin.H{"mfa_token": mfaToken(t, tokenMaker), "code": code}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(userTotp, nil).Times(1)
+				store.EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Any()).
+					Return(db.UserTotp{}, sql.ErrNoRows).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, false)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidVerificationCode)
+			},
+		},
+		{
+			name: "WrongCode",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				return gin.H{"mfa_token": mfaToken(t, tokenMaker), "code": wrongTOTPCode(code)}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(userTotp, nil).Times(1)
+				store.EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, false)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "AccessTokenInsteadOfChallenge",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				accessToken, err := tokenMaker.CreateToken(user.Username, time.Minute)
+				require.NoError(t, err)
+				return gin.H{"mfa_token": accessToken, "code": code}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "LockedOut",
+			body: func(t *testing.T, tokenMaker token.Maker) gin.H {
+				return gin.H{"mfa_token": mfaToken(t, tokenMaker), "code": code}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{FailedAttempts: 3, LastFailedAt: time.Now()}, nil).
+					Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			server.config.MFAEncryptionKey = mfaKey
+			server.config.LoginMaxFailedAttempts = 3
+			server.config.LoginLockoutDuration = time.Minute
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body(t, server.tokenMaker))
+			require.NoError(t, err)
+
+			url := "/users/login/mfa"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// randomUserTOTP returns an enrolment with its plain secret and the key it is encrypted with
+func randomUserTOTP(t *testing.T, username string, confirmed bool) (db.UserTotp, string, string) {
+	mfaKey := utils.RandomString(32)
+	secret, err := utils.GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	encryptedSecret, err := utils.EncryptSecret(mfaKey, secret)
+	require.NoError(t, err)
+
+	userTotp := db.UserTotp{
+		Username:        username,
+		EncryptedSecret: encryptedSecret,
+		ConfirmedAt:     sql.NullTime{Time: time.Now(), Valid: confirmed},
+		CreatedAt:       time.Now(),
+	}
+	return userTotp, secret, mfaKey
+}
+
+// wrongTOTPCode returns a six digit code different from code
+func wrongTOTPCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
 	"github.com/SaishNaik/simplebank/token"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -15,7 +17,9 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+var errRevokedToken = errors.New("token has been revoked")
+
+func authMiddleware(tokenMaker token.Maker, store db.Store) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
@@ -44,6 +48,26 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
 			return
 		}
+		if payload.TokenType != token.TokenTypeAccess {
+			err := fmt.Errorf("unsupported token type: %s", payload.TokenType)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		// changing the password revokes every token issued before
+		passwordChangedAt, err := store.GetPasswordChangedAt(ctx, payload.Username)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errRevokedToken))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if payload.IssuedAt.Before(passwordChangedAt) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errRevokedToken))
+			return
+		}
 		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	}
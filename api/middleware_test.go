@@ -1,9 +1,12 @@
 package api
 
 import (
+	"database/sql"
 	"fmt"
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
 	"github.com/SaishNaik/simplebank/token"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
@@ -63,6 +66,22 @@ func TestAuthMiddleware(t *testing.T) {
 
 			},
 		},
+		{
+			name: "MFA challenge token",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				payload, err := token.NewPayload("user", time.Minute)
+				require.NoError(t, err)
+				payload.TokenType = token.TokenTypeMFAChallenge
+
+				challenge, err := tokenMaker.CreateTokenFromPayload(payload)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, challenge))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+			},
+		},
 		{
 			name: "Access token expired",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
@@ -78,12 +97,12 @@ func TestAuthMiddleware(t *testing.T) {
 	for i := range testcases {
 		tc := testcases[i]
 		t.Run(tc.name, func(t *testing.T) {
-			// store is nil as we dont need to use it
-			server := NewTestServer(t, nil)
+			// the store is only used to check that the token has not been revoked
+			server := NewTestServer(t, mockdb.NewMockStore(gomock.NewController(t)))
 
 			// create a dummy handler to test middleware
 			authPath := "/auth"
-			server.router.GET(authPath, authMiddleware(server.tokenMaker), func(ctx *gin.Context) {
+			server.router.GET(authPath, authMiddleware(server.tokenMaker, server.store), func(ctx *gin.Context) {
 				ctx.JSON(http.StatusOK, gin.H{})
 			})
 
@@ -98,3 +117,85 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthMiddlewareRevokedToken(t *testing.T) {
+	username := "user"
+
+	testcases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "password changed before token was issued",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordChangedAt(gomock.Any(), gomock.Eq(username)).
+					Return(time.Now().Add(-time.Minute), nil).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "password changed after token was issued",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordChangedAt(gomock.Any(), gomock.Eq(username)).
+					Return(time.Now().Add(time.Second), nil).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errRevokedToken)
+			},
+		},
+		{
+			name: "user not found",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordChangedAt(gomock.Any(), gomock.Eq(username)).
+					Return(time.Time{}, sql.ErrNoRows).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errRevokedToken)
+			},
+		},
+		{
+			name: "internal error",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordChangedAt(gomock.Any(), gomock.Eq(username)).
+					Return(time.Time{}, sql.ErrConnDone).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testcases {
+		tc := testcases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			authPath := "/auth"
+			server.router.GET(authPath, authMiddleware(server.tokenMaker, server.store), func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{})
+			})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest("GET", authPath, nil)
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// checkPasswordPolicy responds with an error and returns false when the new password of the user breaks the policy
+func (s *Server) checkPasswordPolicy(ctx *gin.Context, password string, username string, email string) bool {
+	err := s.passwordPolicy.Validate(password, username, email)
+	if err != nil {
+		var policyErr *utils.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// checkPasswordReuse responds with an error and returns false when the password is the current one
+// or one of the previous ones kept by the policy
+func (s *Server) checkPasswordReuse(ctx *gin.Context, user db.User, password string) bool {
+	historySize := s.passwordPolicy.HistorySize
+	if historySize <= 0 {
+		return true
+	}
+
+	hashedPasswords := []string{user.HashedPassword}
+	if historySize > 1 {
+		history, err := s.store.ListPasswordHistory(ctx, db.ListPasswordHistoryParams{
+			Username: user.Username,
+			Limit:    int32(historySize - 1),
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+		for _, previous := range history {
+			hashedPasswords = append(hashedPasswords, previous.HashedPassword)
+		}
+	}
+
+	for _, hashedPassword := range hashedPasswords {
+		if utils.CheckPassword(password, hashedPassword) == nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(utils.ErrPasswordReused))
+			return false
+		}
+	}
+	return true
+}
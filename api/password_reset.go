@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/mail"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// passwordResetTokenSize is the number of random bytes in a password reset token
+const passwordResetTokenSize = 32
+
+var errInvalidPasswordResetToken = errors.New("invalid or expired password reset token")
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type forgotPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+// forgotPassword emails a password reset link to the owner of the email if it is verified, it responds
+// the same way whether or not the email belongs to a user
+func (s *Server) forgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := s.store.GetUserByEmail(ctx, req.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if err == nil && user.IsEmailVerified {
+		// the reset is sent in the background so the response takes as long whether or not the email exists,
+		// failures are only logged, an error response would tell that the email exists
+		sendCtx := context.WithoutCancel(ctx.Request.Context())
+		s.background.Add(1)
+		go func() {
+			defer s.background.Done()
+			if err := s.sendPasswordReset(sendCtx, user); err != nil {
+				slog.ErrorContext(sendCtx, "cannot send password reset", "username", user.Username, "error", err)
+			}
+		}()
+	}
+
+	ctx.JSON(http.StatusAccepted, forgotPasswordResponse{
+		Message: "if the email belongs to an account, a password reset link has been sent to it",
+	})
+}
+
+// sendPasswordReset stores a new reset token for the user and emails it to them
+func (s *Server) sendPasswordReset(ctx context.Context, user db.User) error {
+	resetToken, err := utils.GenerateSecureToken(passwordResetTokenSize)
+	if err != nil {
+		return err
+	}
+	passwordResetToken, err := s.store.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: utils.HashToken(resetToken),
+		ExpiredAt: time.Now().Add(s.config.PasswordResetDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("token", resetToken)
+	link := s.config.PasswordResetURL + "?" + query.Encode()
+
+	body := fmt.Sprintf("Hello %s,\n\n"+
+		"We received a request to reset the password of your account. Open the link below to choose a new password:\n\n"+
+		"%s\n\n"+
+		"The link expires at %s. If you did not ask for a new password, you can ignore this email.\n",
+		user.FullName, link, passwordResetToken.ExpiredAt.UTC().Format("2006-01-02 15:04 MST"))
+
+	err = s.mailer.SendEmail(ctx, mail.Email{
+		To:      []string{user.Email},
+		Subject: "Reset your SimpleBank password",
+		Body:    body,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot send password reset email: %w", err)
+	}
+	return nil
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required,max=64"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+type resetPasswordResponse struct {
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+// resetPassword replaces the password of the user the token was sent to and revokes their existing tokens
+func (s *Server) resetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	tokenHash := utils.HashToken(req.Token)
+	passwordResetToken, err := s.store.GetPasswordResetToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidPasswordResetToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	user, err := s.store.GetUser(ctx, passwordResetToken.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !s.checkPasswordPolicy(ctx, req.NewPassword, user.Username, user.Email) {
+		return
+	}
+	if !s.checkPasswordReuse(ctx, user, req.NewPassword) {
+		return
+	}
+
+	hashedPassword, err := s.passwordHasher.Hash(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the token is checked again when it is used, in case it was used by a concurrent request
+	result, err := s.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenHash:      tokenHash,
+		HashedPassword: hashedPassword,
+		ChangedAt:      time.Now(),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidPasswordResetToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	slog.InfoContext(ctx, "password reset", "username", result.User.Username)
+	ctx.JSON(http.StatusOK, resetPasswordResponse{PasswordChangedAt: result.User.PasswordChangedAt})
+}
@@ -0,0 +1,385 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/mail"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestForgotPasswordAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	user.IsEmailVerified = true
+	unverified, _ := RandomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, arg db.CreatePasswordResetTokenParams) (db.PasswordResetToken, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Len(t, arg.TokenHash, 64)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiredAt, time.Second)
+						return db.PasswordResetToken{Username: arg.Username, TokenHash: arg.TokenHash, ExpiredAt: arg.ExpiredAt}, nil
+					}).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				sent := mailer.Sent()
+				require.Len(t, sent, 1)
+				require.Equal(t, []string{user.Email}, sent[0].To)
+				require.Contains(t, sent[0].Body, "http://localhost:3000/reset-password?token=")
+			},
+		},
+		{
+			name: "UnknownEmail",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Return(db.User{}, sql.ErrNoRows).Times(1)
+				store.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Empty(t, mailer.Sent())
+			},
+		},
+		{
+			name: "UnverifiedEmail",
+			body: gin.H{"email": unverified.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(unverified.Email)).
+					Return(unverified, nil).Times(1)
+				store.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Empty(t, mailer.Sent())
+			},
+		},
+		{
+			name: "CannotCreateToken",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+					Return(db.PasswordResetToken{}, sql.ErrConnDone).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				// the response must not differ from the one for an unknown email
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Empty(t, mailer.Sent())
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Return(db.User{}, sql.ErrConnDone).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{"email": "invalid-email"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/password/forgot"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			server.background.Wait()
+			tc.checkResponse(t, recorder, server.mailer.(*mail.MemoryMailer))
+		})
+	}
+}
+
+func TestResetPasswordAPI(t *testing.T) {
+	user, password := RandomUser(t)
+	resetToken, err := utils.GenerateSecureToken(passwordResetTokenSize)
+	require.NoError(t, err)
+	newPassword := utils.RandomString(8)
+	previousPassword := utils.RandomString(8)
+	previousHashedPassword, err := utils.HashPassword(previousPassword)
+	require.NoError(t, err)
+
+	passwordResetToken := db.PasswordResetToken{
+		ID:        utils.RandomInt(1, 1000),
+		Username:  user.Username,
+		TokenHash: utils.HashToken(resetToken),
+		ExpiredAt: time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		policy        utils.PasswordPolicy
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"token": resetToken, "new_password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetToken(gomock.Any(), gomock.Eq(utils.HashToken(resetToken))).
+					Return(passwordResetToken, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, arg db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+						require.Equal(t, utils.HashToken(resetToken), arg.TokenHash)
+						require.NoError(t, utils.CheckPassword(newPassword, arg.HashedPassword))
+						require.WithinDuration(t, time.Now(), arg.ChangedAt, time.Second)
+
+						user := user
+						user.HashedPassword = arg.HashedPassword
+						user.PasswordChangedAt = arg.ChangedAt
+						return db.ResetPasswordTxResult{User: user}, nil
+					}).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var resp resetPasswordResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				require.WithinDuration(t, time.Now(), resp.PasswordChangedAt, time.Second)
+			},
+		},
+		{
+			name: "InvalidToken",
+			body: gin.H{"token": resetToken, "new_password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetToken(gomock.Any(), gomock.Any()).
+					Return(db.PasswordResetToken{}, sql.ErrNoRows).Times(1)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidPasswordResetToken)
+			},
+		},
+		{
+			name: "TokenUsedConcurrently",
+			body: gin.H{"token": resetToken, "new_password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetToken(gomock.Any(), gomock.Any()).
+					Return(passwordResetToken, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Return(db.ResetPasswordTxResult{}, sql.ErrNoRows).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidPasswordResetToken)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"token": resetToken, "new_password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetToken(gomock.Any(), gomock.Any()).
+					Return(passwordResetToken, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Return(db.ResetPasswordTxResult{}, sql.ErrConnDone).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:   "BreaksPolicy",
+			body:   gin.H{"token": resetToken, "new_password": user.Username + "1"},
+			policy: utils.PasswordPolicy{MinLength: 12, RejectPersonalInfo: true},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetToken(gomock.Any(), gomock.Any()).
+					Return(passwordResetToken, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, &utils.PasswordPolicyError{Violations: []string{
+					"password must be at least 12 characters long",
+					"password must not be similar to the username or email",
+				}})
+			},
+		},
+		{
+			name:   "CurrentPassword",
+			body:   gin.H{"token": resetToken, "new_password": password},
+			policy: utils.PasswordPolicy{HistorySize: 3},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetToken(gomock.Any(), gomock.Any()).
+					Return(passwordResetToken, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					ListPasswordHistory(gomock.Any(), gomock.Any()).
+					Return([]db.PasswordHistory{}, nil).Times(1)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, utils.ErrPasswordReused)
+			},
+		},
+		{
+			name:   "PreviousPassword",
+			body:   gin.H{"token": resetToken, "new_password": previousPassword},
+			policy: utils.PasswordPolicy{HistorySize: 3},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetToken(gomock.Any(), gomock.Any()).
+					Return(passwordResetToken, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				arg := db.ListPasswordHistoryParams{
+					Username: user.Username,
+					Limit:    2,
+				}
+				store.EXPECT().
+					ListPasswordHistory(gomock.Any(), gomock.Eq(arg)).
+					Return([]db.PasswordHistory{{Username: user.Username, HashedPassword: previousHashedPassword}}, nil).
+					Times(1)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, utils.ErrPasswordReused)
+			},
+		},
+		{
+			name: "PasswordTooShort",
+			body: gin.H{"token": resetToken, "new_password": "abc"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetToken(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			server.passwordPolicy = tc.policy
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/password/reset"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"net/http"
+	"time"
+)
+
+type createPayeeRequest struct {
+	Nickname  string     `json:"nickname" binding:"required,max=64"`
+	AccountID accountRef `json:"account_id" binding:"required,account_ref"`
+	Currency  string     `json:"currency" binding:"required,currency"`
+}
+
+// createPayee saves an account the authenticated user can then send transfers to by payee id.
+// Large transfers to it are refused until its cooling-off period is over.
+func (s *Server) createPayee(ctx *gin.Context) {
+	var req createPayeeRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := s.validAccount(ctx, req.AccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	payee, err := s.store.CreatePayee(ctx, db.CreatePayeeParams{
+		Owner:           authPayload.Username,
+		Nickname:        req.Nickname,
+		AccountID:       account.ID,
+		Currency:        account.Currency,
+		CoolingOffUntil: time.Now().Add(s.config.PayeeCoolingOffPeriod),
+	})
+	if err != nil {
+		if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, payee)
+}
+
+type listPayeesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listPayees lists the payees of the authenticated user by nickname
+func (s *Server) listPayees(ctx *gin.Context) {
+	var req listPayeesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	payees, err := s.store.ListPayees(ctx, db.ListPayeesParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, payees)
+}
+
+type payeeRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (s *Server) getPayee(ctx *gin.Context) {
+	var req payeeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payee, ok := s.ownedPayee(ctx, req.ID)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, payee)
+}
+
+type updatePayeeRequest struct {
+	Nickname string `json:"nickname" binding:"required,max=64"`
+}
+
+// updatePayee renames a payee. Its account cannot change, a new payee starts a new cooling-off period.
+func (s *Server) updatePayee(ctx *gin.Context) {
+	var uri payeeRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updatePayeeRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payee, ok := s.ownedPayee(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	payee, err := s.store.UpdatePayeeNickname(ctx, db.UpdatePayeeNicknameParams{
+		Nickname: req.Nickname,
+		ID:       payee.ID,
+	})
+	if err != nil {
+		if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, payee)
+}
+
+func (s *Server) deletePayee(ctx *gin.Context) {
+	var req payeeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payee, ok := s.ownedPayee(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	if err := s.store.DeletePayee(ctx, payee.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// payeeAccount returns the account of a payee of the authenticated user that can receive amount in currency,
+// otherwise it responds and returns false
+func (s *Server) payeeAccount(ctx *gin.Context, payeeID int64, currency string, amount int64) (db.Account, bool) {
+	payee, ok := s.ownedPayee(ctx, payeeID)
+	if !ok {
+		return db.Account{}, false
+	}
+	if payee.Currency != currency {
+		err := fmt.Errorf("payee [%d] curreny mismatch %s vs %s", payee.ID, payee.Currency, currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Account{}, false
+	}
+
+	if !s.checkPayeeCoolingOff(ctx, payee, amount) {
+		return db.Account{}, false
+	}
+
+	account, err := s.store.GetAccount(ctx, payee.AccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+	return account, checkAccount(ctx, account, currency)
+}
+
+// ownedPayee loads a payee of the authenticated user, otherwise it responds and returns false
+func (s *Server) ownedPayee(ctx *gin.Context, id int64) (db.Payee, bool) {
+	payee, err := s.store.GetPayee(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return payee, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return payee, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if payee.Owner != authPayload.Username {
+		err = errors.New("payee doesnt belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return payee, false
+	}
+	return payee, true
+}
+
+// checkPayeeCoolingOff refuses amounts at or above the threshold of the payee currency while the payee is cooling off,
+// it responds and returns false if the transfer is refused
+func (s *Server) checkPayeeCoolingOff(ctx *gin.Context, payee db.Payee, amount int64) bool {
+	threshold, ok := s.payeeCoolingOffThresholds[payee.Currency]
+	if ok && threshold > 0 && amount >= threshold && time.Now().Before(payee.CoolingOffUntil) {
+		err := fmt.Errorf("%w: payee [%d] until %s", db.ErrPayeeCoolingOff, payee.ID, payee.CoolingOffUntil.Format(time.RFC3339))
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// checkAccountCoolingOff applies the cooling-off period of a payee of owner to transfers into its account,
+// however the account is given, so a payee cannot be paid by its account number to skip the cooling-off
+func (s *Server) checkAccountCoolingOff(ctx *gin.Context, owner string, toAccountID int64, currency string, amount int64) bool {
+	threshold, ok := s.payeeCoolingOffThresholds[currency]
+	if !ok || threshold <= 0 || amount < threshold {
+		return true
+	}
+
+	payee, err := s.store.GetPayeeByAccount(ctx, db.GetPayeeByAccountParams{
+		Owner:     owner,
+		AccountID: toAccountID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return true
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	return s.checkPayeeCoolingOff(ctx, payee, amount)
+}
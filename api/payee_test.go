@@ -0,0 +1,633 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreatePayeeAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	other, _ := RandomUser(t)
+	account := randomAccount(other.Username)
+	payee := randomPayee(user.Username, account)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"nickname":   payee.Nickname,
+				"account_id": account.ID,
+				"currency":   account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreatePayee(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreatePayeeParams) (db.Payee, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.Equal(t, payee.Nickname, arg.Nickname)
+						require.Equal(t, account.ID, arg.AccountID)
+						require.Equal(t, account.Currency, arg.Currency)
+						// the test config has a cooling-off period of an hour
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.CoolingOffUntil, time.Minute)
+						return payee, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchPayee(t, recorder.Body, payee)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			body: gin.H{
+				"nickname":   payee.Nickname,
+				"account_id": account.ID,
+				"currency":   account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: gin.H{
+				"nickname":   payee.Nickname,
+				"account_id": account.ID,
+				"currency":   otherCurrency(account.Currency),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DuplicateNickname",
+			body: gin.H{
+				"nickname":   payee.Nickname,
+				"account_id": account.ID,
+				"currency":   account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreatePayee(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Payee{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "MissingNickname",
+			body: gin.H{
+				"account_id": account.ID,
+				"currency":   account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreatePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/payees", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListPayeesAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	other, _ := RandomUser(t)
+
+	n := 5
+	payees := make([]db.Payee, n)
+	for i := range payees {
+		payees[i] = randomPayee(user.Username, randomAccount(other.Username))
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	arg := db.ListPayeesParams{
+		Owner:  user.Username,
+		Limit:  int32(n),
+		Offset: 0,
+	}
+	store.EXPECT().ListPayees(gomock.Any(), gomock.Eq(arg)).Times(1).Return(payees, nil)
+
+	server := NewTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	url := fmt.Sprintf("/payees?page_id=%d&page_size=%d", 1, n)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got []db.Payee
+	err = json.Unmarshal(recorder.Body.Bytes(), &got)
+	require.NoError(t, err)
+	require.Len(t, got, n)
+}
+
+func TestPayeeAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	other, _ := RandomUser(t)
+	payee := randomPayee(user.Username, randomAccount(other.Username))
+	renamed := payee
+	renamed.Nickname = utils.RandomOwner()
+
+	testCases := []struct {
+		name          string
+		method        string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Get",
+			method:   http.MethodGet,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchPayee(t, recorder.Body, payee)
+			},
+		},
+		{
+			name:     "GetNotFound",
+			method:   http.MethodGet,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(db.Payee{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "GetNotOwner",
+			method:   http.MethodGet,
+			username: other.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "Rename",
+			method:   http.MethodPatch,
+			username: user.Username,
+			body:     gin.H{"nickname": renamed.Nickname},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+				arg := db.UpdatePayeeNicknameParams{
+					Nickname: renamed.Nickname,
+					ID:       payee.ID,
+				}
+				store.EXPECT().UpdatePayeeNickname(gomock.Any(), gomock.Eq(arg)).Times(1).Return(renamed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchPayee(t, recorder.Body, renamed)
+			},
+		},
+		{
+			name:     "RenameNotOwner",
+			method:   http.MethodPatch,
+			username: other.Username,
+			body:     gin.H{"nickname": renamed.Nickname},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+				store.EXPECT().UpdatePayeeNickname(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "Delete",
+			method:   http.MethodDelete,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+				store.EXPECT().DeletePayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:     "DeleteNotOwner",
+			method:   http.MethodDelete,
+			username: other.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+				store.EXPECT().DeletePayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body io.Reader
+			if tc.body != nil {
+				data, err := json.Marshal(tc.body)
+				require.NoError(t, err)
+				body = bytes.NewReader(data)
+			}
+			url := fmt.Sprintf("/payees/%d", payee.ID)
+			request, err := http.NewRequest(tc.method, url, body)
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestTransferToPayeeAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	other, _ := RandomUser(t)
+	fromAccount := randomAccount(user.Username)
+	toAccount := randomAccount(other.Username)
+	toAccount.Currency = fromAccount.Currency
+	payee := randomPayee(user.Username, toAccount)
+	// payee was saved a day ago so it is past its cooling-off period
+	payee.CoolingOffUntil = time.Now().Add(-24 * time.Hour)
+	coolingOff := payee
+	coolingOff.CoolingOffUntil = time.Now().Add(time.Hour)
+	otherPayee := randomPayee(other.Username, fromAccount)
+
+	const threshold = int64(500)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"payee_id":        payee.ID,
+				"amount":          threshold,
+				"currency":        fromAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(payee.ID)).Times(1).Return(payee, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				arg := db.TransferTxParams{
+					FromAccountId: fromAccount.ID,
+					ToAccountId:   toAccount.ID,
+					Amount:        threshold,
+				}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "CoolingOffBelowThreshold",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"payee_id":        coolingOff.ID,
+				"amount":          threshold - 1,
+				"currency":        fromAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(coolingOff.ID)).Times(1).Return(coolingOff, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "CoolingOff",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"payee_id":        coolingOff.ID,
+				"amount":          threshold,
+				"currency":        fromAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(coolingOff.ID)).Times(1).Return(coolingOff, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "CoolingOffByToAccount",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          threshold,
+				"currency":        fromAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.GetPayeeByAccountParams{
+					Owner:     user.Username,
+					AccountID: toAccount.ID,
+				}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetPayeeByAccount(gomock.Any(), gomock.Eq(arg)).Times(1).Return(coolingOff, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "CoolingOffByRecipient",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"recipient":       toAccount.AccountNumber,
+				"amount":          threshold,
+				"currency":        fromAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(toAccount.AccountNumber)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetPayeeByAccount(gomock.Any(), gomock.Any()).Times(1).Return(coolingOff, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "ToAccountNotPayee",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          threshold,
+				"currency":        fromAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetPayeeByAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Payee{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "PayeeNotOwned",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"payee_id":        otherPayee.ID,
+				"amount":          threshold,
+				"currency":        fromAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Eq(otherPayee.ID)).Times(1).Return(otherPayee, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "PayeeAndToAccount",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"payee_id":        payee.ID,
+				"amount":          threshold,
+				"currency":        fromAccount.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetPayee(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			server.payeeCoolingOffThresholds = map[string]int64{fromAccount.Currency: threshold}
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestScheduledPaymentToPayeeCoolingOffAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	other, _ := RandomUser(t)
+	fromAccount := randomAccount(user.Username)
+	toAccount := randomAccount(other.Username)
+	toAccount.Currency = fromAccount.Currency
+	coolingOff := randomPayee(user.Username, toAccount)
+	coolingOff.CoolingOffUntil = time.Now().Add(time.Hour)
+
+	const threshold = int64(500)
+	paymentRequest := randomPaymentRequest(other.Username, user.Username, toAccount)
+	paymentRequest.Amount = threshold
+	startAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	payeeArg := db.GetPayeeByAccountParams{
+		Owner:     user.Username,
+		AccountID: toAccount.ID,
+	}
+
+	testCases := []struct {
+		name       string
+		url        string
+		body       gin.H
+		buildStubs func(store *mockdb.MockStore)
+	}{
+		{
+			name: "ScheduledTransfer",
+			url:  "/scheduled-transfers",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          threshold,
+				"currency":        fromAccount.Currency,
+				"execute_at":      startAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetPayeeByAccount(gomock.Any(), gomock.Eq(payeeArg)).Times(1).Return(coolingOff, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "StandingOrder",
+			url:  "/standing-orders",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          threshold,
+				"currency":        fromAccount.Currency,
+				"frequency":       utils.FrequencyMonthly,
+				"day_of_month":    startAt.Day(),
+				"start_at":        startAt,
+				"count":           12,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetPayeeByAccount(gomock.Any(), gomock.Eq(payeeArg)).Times(1).Return(coolingOff, nil)
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "AcceptPaymentRequest",
+			url:  fmt.Sprintf("/payment-requests/%d/accept", paymentRequest.ID),
+			body: gin.H{"from_account_id": fromAccount.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(paymentRequest.ID)).Times(1).Return(paymentRequest, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetPayeeByAccount(gomock.Any(), gomock.Eq(payeeArg)).Times(1).Return(coolingOff, nil)
+				store.EXPECT().AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			server.payeeCoolingOffThresholds = map[string]int64{fromAccount.Currency: threshold}
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, tc.url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusForbidden, recorder.Code)
+		})
+	}
+}
+
+func randomPayee(owner string, account db.Account) db.Payee {
+	return db.Payee{
+		ID:        utils.RandomInt(1, 1000),
+		Owner:     owner,
+		Nickname:  utils.RandomOwner(),
+		AccountID: account.ID,
+		Currency:  account.Currency,
+	}
+}
+
+func requireBodyMatchPayee(t *testing.T, body *bytes.Buffer, payee db.Payee) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var got db.Payee
+	err = json.Unmarshal(data, &got)
+	require.NoError(t, err)
+	require.Equal(t, payee, got)
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+var errPaymentNeedsApproval = errors.New("payment needs approval, send it as a transfer instead")
+
+type createPaymentRequestRequest struct {
+	Payer       string     `json:"payer" binding:"required,alphanum"`
+	ToAccountID accountRef `json:"to_account_id" binding:"required,account_ref"`
+	Amount      int64      `json:"amount" binding:"required,gt=0"`
+	Currency    string     `json:"currency" binding:"required,currency"`
+	Description string     `json:"description" binding:"max=280"`
+}
+
+// createPaymentRequest asks another user to pay an amount into an account of the authenticated user
+func (s *Server) createPaymentRequest(ctx *gin.Context) {
+	var req createPaymentRequestRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.Payer == authPayload.Username {
+		err := errors.New("cannot request a payment from yourself")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	toAccount, valid := s.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+	if toAccount.Owner != authPayload.Username {
+		err := errors.New("to account does not belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	_, err := s.store.GetUser(ctx, req.Payer)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var expiresAt sql.NullTime
+	if s.config.PaymentRequestDuration > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().Add(s.config.PaymentRequestDuration), Valid: true}
+	}
+
+	result, err := s.store.CreatePaymentRequestTx(ctx, db.CreatePaymentRequestTxParams{
+		CreatePaymentRequestParams: db.CreatePaymentRequestParams{
+			Requester:   authPayload.Username,
+			Payer:       req.Payer,
+			ToAccountID: toAccount.ID,
+			Amount:      req.Amount,
+			Currency:    req.Currency,
+			Description: req.Description,
+			ExpiresAt:   expiresAt,
+		},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.PaymentRequest)
+}
+
+type listPaymentRequestsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listPaymentRequests lists the payment requests the authenticated user made or has to pay, newest first
+func (s *Server) listPaymentRequests(ctx *gin.Context) {
+	var req listPaymentRequestsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	requests, err := s.store.ListPaymentRequests(ctx, db.ListPaymentRequestsParams{
+		Username: authPayload.Username,
+		Limit:    req.PageSize,
+		Offset:   (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, requests)
+}
+
+type paymentRequestRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type paymentRequestResponse struct {
+	PaymentRequest db.PaymentRequest        `json:"payment_request"`
+	Events         []db.PaymentRequestEvent `json:"events"`
+}
+
+// getPaymentRequest returns a payment request with the history of its status
+func (s *Server) getPaymentRequest(ctx *gin.Context) {
+	var req paymentRequestRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	request, ok := s.paymentRequestParty(ctx, authPayload, req.ID)
+	if !ok {
+		return
+	}
+
+	events, err := s.store.ListPaymentRequestEvents(ctx, request.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, paymentRequestResponse{
+		PaymentRequest: request,
+		Events:         events,
+	})
+}
+
+type acceptPaymentRequestRequest struct {
+	FromAccountID accountRef `json:"from_account_id" binding:"required,account_ref"`
+}
+
+// acceptPaymentRequest pays a payment request from an account of the payer
+func (s *Server) acceptPaymentRequest(ctx *gin.Context) {
+	var uri paymentRequestRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req acceptPaymentRequestRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	request, ok := s.paymentRequestParty(ctx, authPayload, uri.ID)
+	if !ok {
+		return
+	}
+	if request.Payer != authPayload.Username {
+		err := errors.New("only the payer can accept a payment request")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if !s.checkStepUp(ctx, authPayload, request.Currency, request.Amount) {
+		return
+	}
+
+	fromAccount, valid := s.validAccount(ctx, req.FromAccountID, request.Currency)
+	if !valid {
+		return
+	}
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account does not belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if !s.checkAccountCoolingOff(ctx, authPayload.Username, request.ToAccountID, request.Currency, request.Amount) {
+		return
+	}
+	if needsApproval(fromAccount, request.Amount) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errPaymentNeedsApproval))
+		return
+	}
+
+	result, err := s.store.AcceptPaymentRequestTx(ctx, db.AcceptPaymentRequestTxParams{
+		PaymentRequestID: request.ID,
+		Payer:            authPayload.Username,
+		FromAccountID:    fromAccount.ID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrAccountNotActive),
+			errors.Is(err, db.ErrInsufficientFunds),
+			errors.Is(err, db.ErrTransferLimitExceeded),
+			errors.Is(err, db.ErrApprovalRequired):
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		default:
+			paymentRequestError(ctx, err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// declinePaymentRequest refuses a payment request on behalf of its payer
+func (s *Server) declinePaymentRequest(ctx *gin.Context) {
+	s.closePaymentRequest(ctx, utils.PaymentRequestStatusDeclined)
+}
+
+// cancelPaymentRequest withdraws a payment request on behalf of its requester
+func (s *Server) cancelPaymentRequest(ctx *gin.Context) {
+	s.closePaymentRequest(ctx, utils.PaymentRequestStatusCancelled)
+}
+
+// closePaymentRequest closes a pending payment request with status. Only the payer can decline a request
+// and only the requester can cancel it.
+func (s *Server) closePaymentRequest(ctx *gin.Context, status string) {
+	var req paymentRequestRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	request, ok := s.paymentRequestParty(ctx, authPayload, req.ID)
+	if !ok {
+		return
+	}
+
+	party, err := request.Payer, errors.New("only the payer can decline a payment request")
+	if status == utils.PaymentRequestStatusCancelled {
+		party, err = request.Requester, errors.New("only the requester can cancel a payment request")
+	}
+	if party != authPayload.Username {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := s.store.UpdatePaymentRequestStatusTx(ctx, db.UpdatePaymentRequestStatusTxParams{
+		PaymentRequestID: request.ID,
+		Status:           status,
+		Actor:            authPayload.Username,
+	})
+	if err != nil {
+		paymentRequestError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// paymentRequestParty loads a payment request the authenticated user made or has to pay,
+// otherwise it responds and returns false
+func (s *Server) paymentRequestParty(ctx *gin.Context, authPayload *token.Payload, id int64) (db.PaymentRequest, bool) {
+	request, err := s.store.GetPaymentRequest(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return request, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return request, false
+	}
+
+	if request.Requester != authPayload.Username && request.Payer != authPayload.Username {
+		err = errors.New("payment request doesnt belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return request, false
+	}
+	return request, true
+}
+
+// paymentRequestError responds with the status of an error from accepting, declining or cancelling a payment request
+func paymentRequestError(ctx *gin.Context, err error) {
+	if errors.Is(err, db.ErrPaymentRequestNotPending) || errors.Is(err, db.ErrPaymentRequestExpired) {
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+}
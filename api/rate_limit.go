@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"github.com/SaishNaik/simplebank/ratelimit"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// rateLimitKeyFunc returns the key identifying who the request is counted against
+type rateLimitKeyFunc func(ctx *gin.Context) string
+
+func clientIPKey(ctx *gin.Context) string {
+	return "ip:" + ctx.ClientIP()
+}
+
+// usernameKey must only be used behind authMiddleware
+func usernameKey(ctx *gin.Context) string {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	return "user:" + authPayload.Username
+}
+
+func rateLimitMiddleware(limiter ratelimit.Limiter, policy ratelimit.Policy, keyFunc rateLimitKeyFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !policy.Enabled() {
+			ctx.Next()
+			return
+		}
+
+		result, err := limiter.Allow(ctx, keyFunc(ctx), policy)
+		if err != nil {
+			// fail open, an unavailable limiter should not take the whole api down
+			slog.ErrorContext(ctx, "cannot check rate limit", "policy", policy.Name, "error", err)
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("X-RateLimit-Limit", strconv.Itoa(policy.Burst))
+		ctx.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+			err := errors.New("too many requests, please retry later")
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, errorResponse(err))
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
+	"github.com/SaishNaik/simplebank/ratelimit"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		policy        ratelimit.Policy
+		setupRequest  func(t *testing.T, request *http.Request, tokenMaker token.Maker, i int)
+		checkResponse func(t *testing.T, recorders []*httptest.ResponseRecorder)
+	}{
+		{
+			name:   "LimitedByUsername",
+			policy: ratelimit.PerMinute("test", 2),
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker, i int) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorders []*httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorders[0].Code)
+				require.Equal(t, http.StatusOK, recorders[1].Code)
+				require.Equal(t, http.StatusTooManyRequests, recorders[2].Code)
+				require.Equal(t, "30", recorders[2].Header().Get("Retry-After"))
+			},
+		},
+		{
+			name:   "SeparateUsers",
+			policy: ratelimit.PerMinute("test", 2),
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker, i int) {
+				username := "user"
+				if i == 2 {
+					username = "other"
+				}
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorders []*httptest.ResponseRecorder) {
+				for _, recorder := range recorders {
+					require.Equal(t, http.StatusOK, recorder.Code)
+				}
+			},
+		},
+		{
+			name:   "Disabled",
+			policy: ratelimit.PerMinute("test", 0),
+			setupRequest: func(t *testing.T, request *http.Request, tokenMaker token.Maker, i int) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorders []*httptest.ResponseRecorder) {
+				for _, recorder := range recorders {
+					require.Equal(t, http.StatusOK, recorder.Code)
+					require.Empty(t, recorder.Header().Get("X-RateLimit-Limit"))
+				}
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			// the store is only used to check that the token has not been revoked
+			server := NewTestServer(t, mockdb.NewMockStore(gomock.NewController(t)))
+
+			path := "/limited"
+			server.router.GET(path,
+				authMiddleware(server.tokenMaker, server.store),
+				rateLimitMiddleware(ratelimit.NewMemoryLimiter(), tc.policy, usernameKey),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorders := make([]*httptest.ResponseRecorder, 3)
+			for j := range recorders {
+				recorders[j] = httptest.NewRecorder()
+				request, err := http.NewRequest(http.MethodGet, path, nil)
+				require.NoError(t, err)
+
+				tc.setupRequest(t, request, server.tokenMaker, j)
+				server.router.ServeHTTP(recorders[j], request)
+			}
+			tc.checkResponse(t, recorders)
+		})
+	}
+}
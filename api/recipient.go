@@ -0,0 +1,89 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// aliasPrefix marks a recipient as an account alias, such as "$rentpot"
+const aliasPrefix = "$"
+
+var errRecipientNotFound = errors.New("recipient not found")
+
+type lookupRecipientRequest struct {
+	Recipient string `form:"recipient" binding:"required,max=254"`
+	Currency  string `form:"currency" binding:"required,currency"`
+}
+
+type recipientResponse struct {
+	MaskedName string `json:"masked_name"`
+	Currency   string `json:"currency"`
+}
+
+// lookupRecipient shows the masked name of the owner of the account a recipient resolves to,
+// so the sender can confirm who they are paying before sending money
+func (s *Server) lookupRecipient(ctx *gin.Context) {
+	var req lookupRecipientRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := s.resolveRecipient(ctx, req.Recipient, req.Currency)
+	if !valid {
+		return
+	}
+
+	user, err := s.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, recipientResponse{
+		MaskedName: utils.MaskName(user.FullName),
+		Currency:   account.Currency,
+	})
+}
+
+// resolveRecipient finds the account a recipient is paid into. A recipient is an account number, an account alias
+// prefixed with $, or the email or username of a user whose account in currency is chosen.
+// The account must be able to receive currency, otherwise it responds and returns false.
+func (s *Server) resolveRecipient(ctx *gin.Context, recipient string, currency string) (db.Account, bool) {
+	var account db.Account
+	var err error
+	switch {
+	case utils.IsValidAccountNumber(recipient):
+		account, err = s.store.GetAccountByNumber(ctx, recipient)
+	case strings.HasPrefix(recipient, aliasPrefix):
+		alias := strings.TrimPrefix(recipient, aliasPrefix)
+		account, err = s.store.GetAccountByAlias(ctx, sql.NullString{String: alias, Valid: true})
+	case strings.Contains(recipient, "@"):
+		var user db.User
+		user, err = s.store.GetUserByEmail(ctx, recipient)
+		if err == nil {
+			account, err = s.store.GetAccountByOwnerAndCurrency(ctx, db.GetAccountByOwnerAndCurrencyParams{
+				Owner:    user.Username,
+				Currency: currency,
+			})
+		}
+	default:
+		account, err = s.store.GetAccountByOwnerAndCurrency(ctx, db.GetAccountByOwnerAndCurrencyParams{
+			Owner:    recipient,
+			Currency: currency,
+		})
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errRecipientNotFound))
+			return account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+	return account, checkAccount(ctx, account, currency)
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestLookupRecipientAPI(t *testing.T) {
+	sender, _ := RandomUser(t)
+	recipient, _ := RandomUser(t)
+	recipient.FullName = "John Smith"
+	account := randomAccount(recipient.Username)
+	account.Alias = sql.NullString{String: "rentpot", Valid: true}
+	byOwner := db.GetAccountByOwnerAndCurrencyParams{Owner: recipient.Username, Currency: account.Currency}
+
+	testCases := []struct {
+		name          string
+		recipient     string
+		currency      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "Username",
+			recipient: recipient.Username,
+			currency:  account.Currency,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(byOwner)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(recipient.Username)).Times(1).Return(recipient, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got recipientResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, recipientResponse{MaskedName: "J*** S***", Currency: account.Currency}, got)
+			},
+		},
+		{
+			name:      "Email",
+			recipient: recipient.Email,
+			currency:  account.Currency,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(recipient.Email)).Times(1).Return(recipient, nil)
+				store.EXPECT().GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(byOwner)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(recipient.Username)).Times(1).Return(recipient, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "Alias",
+			recipient: "$rentpot",
+			currency:  account.Currency,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByAlias(gomock.Any(), gomock.Eq(account.Alias)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(recipient.Username)).Times(1).Return(recipient, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "AliasCurrencyMismatch",
+			recipient: "$rentpot",
+			currency:  otherCurrency(account.Currency),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByAlias(gomock.Any(), gomock.Eq(account.Alias)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "NotFound",
+			recipient: recipient.Email,
+			currency:  account.Currency,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(recipient.Email)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errRecipientNotFound)
+			},
+		},
+		{
+			name:      "InvalidCurrency",
+			recipient: recipient.Username,
+			currency:  "XYZ",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			query := url.Values{"recipient": {tc.recipient}, "currency": {tc.currency}}
+			request, err := http.NewRequest(http.MethodGet, "/recipients/lookup?"+query.Encode(), nil)
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, sender.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// otherCurrency returns a supported currency different from currency
+func otherCurrency(currency string) string {
+	if currency == utils.USD {
+		return utils.EUR
+	}
+	return utils.USD
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type createScheduledTransferRequest struct {
+	FromAccountID accountRef `json:"from_account_id" binding:"required,account_ref"`
+	ToAccountID   accountRef `json:"to_account_id" binding:"required,account_ref"`
+	Amount        int64      `json:"amount" binding:"required,gt=0"`
+	Currency      string     `json:"currency" binding:"required,currency"`
+	ExecuteAt     time.Time  `json:"execute_at" binding:"required"`
+}
+
+func (s *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !req.ExecuteAt.After(time.Now()) {
+		err := errors.New("execute_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !s.checkStepUp(ctx, authPayload, req.Currency, req.Amount) {
+		return
+	}
+
+	fromAccount, toAccount, ok := s.checkTransferAccounts(ctx, authPayload, req.FromAccountID, req.ToAccountID, req.Currency)
+	if !ok {
+		return
+	}
+	if !s.checkAccountCoolingOff(ctx, authPayload.Username, toAccount.ID, toAccount.Currency, req.Amount) {
+		return
+	}
+	if needsApproval(fromAccount, req.Amount) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errApprovalNotScheduled))
+		return
+	}
+
+	scheduledTransfer, err := s.store.CreateScheduledTransfer(ctx, db.CreateScheduledTransferParams{
+		Owner:         authPayload.Username,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		ExecuteAt:     req.ExecuteAt,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, scheduledTransfer)
+}
+
+type listScheduledTransfersRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (s *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	scheduledTransfers, err := s.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, scheduledTransfers)
+}
+
+type scheduledTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type scheduledTransferResponse struct {
+	ScheduledTransfer db.ScheduledTransfer          `json:"scheduled_transfer"`
+	Attempts          []db.ScheduledTransferAttempt `json:"attempts"`
+}
+
+// getScheduledTransfer returns a scheduled transfer with the outcome of each execution attempt
+func (s *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req scheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduledTransfer, ok := s.ownedScheduledTransfer(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	attempts, err := s.store.ListScheduledTransferAttempts(ctx, scheduledTransfer.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, scheduledTransferResponse{
+		ScheduledTransfer: scheduledTransfer,
+		Attempts:          attempts,
+	})
+}
+
+func (s *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	var req scheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	scheduledTransfer, ok := s.ownedScheduledTransfer(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	// waits for an executor holding the row, only transfers still pending afterwards are cancelled
+	scheduledTransfer, err := s.store.CancelScheduledTransfer(ctx, scheduledTransfer.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = errors.New("scheduled transfer is no longer pending")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, scheduledTransfer)
+}
+
+// ownedScheduledTransfer loads a scheduled transfer of the authenticated user, otherwise it responds and returns false
+func (s *Server) ownedScheduledTransfer(ctx *gin.Context, id int64) (db.ScheduledTransfer, bool) {
+	scheduledTransfer, err := s.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return scheduledTransfer, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return scheduledTransfer, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if scheduledTransfer.Owner != authPayload.Username {
+		err = errors.New("scheduled transfer doesnt belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return scheduledTransfer, false
+	}
+	return scheduledTransfer, true
+}
@@ -1,20 +1,42 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/mail"
+	"github.com/SaishNaik/simplebank/ratelimit"
 	"github.com/SaishNaik/simplebank/token"
 	"github.com/SaishNaik/simplebank/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/chacha20poly1305"
+	"io"
+	"net/http"
+	"sync"
 )
 
 type Server struct {
-	config     utils.Config
-	store      db.Store
-	router     *gin.Engine
-	tokenMaker token.Maker
+	config      utils.Config
+	store       db.Store
+	router      *gin.Engine
+	httpServer  *http.Server
+	tokenMaker  token.Maker
+	rateLimiter ratelimit.Limiter
+	mailer      mail.Mailer
+	// passwordHasher hashes new passwords, hashes it did not make are replaced on login
+	passwordHasher utils.PasswordHasher
+	passwordPolicy utils.PasswordPolicy
+	// dummyHashedPassword is checked against when the username does not exist
+	dummyHashedPassword func() (string, error)
+	// stepUpThresholds holds the transfer amount per currency from which a recent login is required
+	stepUpThresholds map[string]int64
+	// payeeCoolingOffThresholds holds the transfer amount per currency refused to payees in their cooling-off period
+	payeeCoolingOffThresholds map[string]int64
+	// background tracks the work handlers leave running after they respond
+	background sync.WaitGroup
 }
 
 func NewServer(config utils.Config, store db.Store) (*Server, error) {
@@ -22,38 +44,169 @@ func NewServer(config utils.Config, store db.Store) (*Server, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
+	if len(config.MFAEncryptionKey) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("invalid mfa encryption key size: must be exactly %d characters", chacha20poly1305.KeySize)
+	}
+	stepUpThresholds, err := utils.ParseCurrencyAmounts(config.StepUpThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid step up thresholds: %w", err)
+	}
+	payeeCoolingOffThresholds, err := utils.ParseCurrencyAmounts(config.PayeeCoolingOffThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payee cooling-off thresholds: %w", err)
+	}
+	rateLimiter, err := ratelimit.New(config.RateLimitBackend, store)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create rate limiter: %w", err)
+	}
+	mailer, err := mail.New(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create mailer: %w", err)
+	}
+	passwordHasher, err := utils.NewPasswordHasher(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create password hasher: %w", err)
+	}
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:      config,
+		store:       store,
+		tokenMaker:  tokenMaker,
+		rateLimiter: rateLimiter,
+		mailer:      mailer,
+
+		stepUpThresholds:          stepUpThresholds,
+		payeeCoolingOffThresholds: payeeCoolingOffThresholds,
+		passwordHasher:            passwordHasher,
+		passwordPolicy:            utils.NewPasswordPolicy(config),
+		dummyHashedPassword: sync.OnceValues(func() (string, error) {
+			return passwordHasher.Hash(utils.RandomString(16))
+		}),
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("account_status", validAccountStatus)
+		v.RegisterValidation("account_type", validAccountType)
+		v.RegisterValidation("json_object", validJSONObject)
+		v.RegisterValidation("account_ref", validAccountRef)
 	}
 
-	server.setupRouter()
+	err = server.setupRouter()
+	if err != nil {
+		return nil, err
+	}
+	server.httpServer = &http.Server{Handler: server.router}
 	return server, nil
 }
 
-func (server *Server) setupRouter() {
+func (server *Server) setupRouter() error {
+
+	router := gin.New()
+	// lets the store read request scoped values, such as the request id, through the gin context
+	router.ContextWithFallback = true
+	// client ips are only taken from forwarding headers set by the configured proxies
+	if err := router.SetTrustedProxies(server.config.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	router.Use(requestLogger(), gin.CustomRecoveryWithWriter(io.Discard, recoverer))
 
-	router := gin.Default()
+	publicLimit := server.rateLimit("public", server.config.PublicRateLimit, clientIPKey)
+	loginLimit := server.rateLimit("login", server.config.LoginRateLimit, clientIPKey)
+	router.POST("/users", publicLimit, server.createUser)
+	router.POST("/users/login", loginLimit, server.loginUser)
+	router.POST("/users/login/mfa", loginLimit, server.loginUserMFA)
+	router.GET("/users/verify_email", publicLimit, server.verifyEmail)
+	router.POST("/users/password/forgot", loginLimit, server.forgotPassword)
+	router.POST("/users/password/reset", loginLimit, server.resetPassword)
 
-	router.POST("/users", server.createUser)
-	router.POST("/users/login", server.loginUser)
+	authRoutes := router.Group("/").Use(
+		authMiddleware(server.tokenMaker, server.store),
+		server.rateLimit("authenticated", server.config.AuthenticatedRateLimit, usernameKey),
+	)
+	authRoutes.GET("/users/me", server.getCurrentUser)
+	authRoutes.PATCH("/users/me", server.updateCurrentUser)
+	authRoutes.POST("/users/reauthenticate", loginLimit, server.reauthenticate)
+	authRoutes.POST("/users/mfa/totp", server.enrollTOTP)
+	authRoutes.POST("/users/mfa/totp/verify", server.verifyTOTP)
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
 	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts/:id", server.getAccount)
 	authRoutes.GET("/accounts", server.listAccount)
+	authRoutes.PUT("/accounts/:id/status", server.updateAccountStatus)
+	authRoutes.PUT("/accounts/:id/alias", server.updateAccountAlias)
+	authRoutes.PUT("/accounts/:id/type", server.updateAccountType)
+	authRoutes.PUT("/accounts/:id/approval-policy", server.updateApprovalPolicy)
 
-	authRoutes.POST("/transfers", server.createTransfer)
+	transferLimit := server.rateLimit("transfers", server.config.TransferRateLimit, usernameKey)
+	authRoutes.POST("/transfers", transferLimit, server.createTransfer)
+	authRoutes.GET("/transfers", server.searchTransfers)
+	authRoutes.GET("/recipients/lookup", transferLimit, server.lookupRecipient)
+	authRoutes.POST("/payees", server.createPayee)
+	authRoutes.GET("/payees", server.listPayees)
+	authRoutes.GET("/payees/:id", server.getPayee)
+	authRoutes.PATCH("/payees/:id", server.updatePayee)
+	authRoutes.DELETE("/payees/:id", server.deletePayee)
+	authRoutes.POST("/transfers/:id/reversal", transferLimit, server.reverseTransfer)
+	authRoutes.POST("/transfers/:id/approve", transferLimit, server.approveTransfer)
+	authRoutes.POST("/transfers/:id/reject", server.rejectTransfer)
+	authRoutes.GET("/limits", server.listLimits)
+	authRoutes.PUT("/limits", server.setLimit)
+	authRoutes.POST("/payment-requests", server.createPaymentRequest)
+	authRoutes.GET("/payment-requests", server.listPaymentRequests)
+	authRoutes.GET("/payment-requests/:id", server.getPaymentRequest)
+	authRoutes.POST("/payment-requests/:id/accept", transferLimit, server.acceptPaymentRequest)
+	authRoutes.POST("/payment-requests/:id/decline", server.declinePaymentRequest)
+	authRoutes.POST("/payment-requests/:id/cancel", server.cancelPaymentRequest)
+	authRoutes.POST("/transfer-batches", transferLimit, server.createTransferBatch)
+	authRoutes.GET("/transfer-batches/:id", server.getTransferBatch)
+	authRoutes.GET("/holds/:id", server.getHold)
+	authRoutes.POST("/holds/:id/capture", transferLimit, server.captureHold)
+	authRoutes.POST("/holds/:id/void", server.voidHold)
+	authRoutes.POST("/scheduled-transfers", transferLimit, server.createScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers", server.listScheduledTransfers)
+	authRoutes.GET("/scheduled-transfers/:id", server.getScheduledTransfer)
+	authRoutes.POST("/scheduled-transfers/:id/cancel", server.cancelScheduledTransfer)
+	authRoutes.POST("/standing-orders", transferLimit, server.createStandingOrder)
+	authRoutes.GET("/standing-orders", server.listStandingOrders)
+	authRoutes.GET("/standing-orders/:id", server.getStandingOrder)
+	authRoutes.POST("/standing-orders/:id/cancel", server.cancelStandingOrder)
 	server.router = router
+	return nil
+}
+
+// rateLimit creates a middleware allowing requestsPerMinute requests per key
+func (server *Server) rateLimit(name string, requestsPerMinute int, keyFunc rateLimitKeyFunc) gin.HandlerFunc {
+	return rateLimitMiddleware(server.rateLimiter, ratelimit.PerMinute(name, requestsPerMinute), keyFunc)
 }
 
+// Start serves requests on addr until Shutdown is called
 func (s *Server) Start(addr string) error {
-	return s.router.Run(addr)
+	s.httpServer.Addr = addr
+	err := s.httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting requests, then waits for the requests in flight and for the work handlers left running
+// in the background, such as sending emails, until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func errorResponse(err error) gin.H {
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServerShutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	server := NewTestServer(t, mockdb.NewMockStore(ctrl))
+
+	var sent atomic.Bool
+	server.background.Add(1)
+	go func() {
+		defer server.background.Done()
+		time.Sleep(50 * time.Millisecond)
+		sent.Store(true)
+	}()
+
+	require.NoError(t, server.Shutdown(context.Background()))
+	require.True(t, sent.Load())
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	server := NewTestServer(t, mockdb.NewMockStore(ctrl))
+
+	release := make(chan struct{})
+	defer close(release)
+	server.background.Add(1)
+	go func() {
+		defer server.background.Done()
+		<-release
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, server.Shutdown(ctx), context.DeadlineExceeded)
+}
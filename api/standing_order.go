@@ -0,0 +1,194 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type createStandingOrderRequest struct {
+	FromAccountID accountRef `json:"from_account_id" binding:"required,account_ref"`
+	ToAccountID   accountRef `json:"to_account_id" binding:"required,account_ref"`
+	Amount        int64      `json:"amount" binding:"required,gt=0"`
+	Currency      string     `json:"currency" binding:"required,currency"`
+	Frequency     string     `json:"frequency" binding:"required,oneof=daily weekly monthly"`
+	DayOfMonth    int32      `json:"day_of_month" binding:"omitempty,min=1,max=31"`
+	StartAt       time.Time  `json:"start_at" binding:"required"`
+	// the order ends after EndAt or Count occurrences, whichever comes first, or when cancelled
+	EndAt *time.Time `json:"end_at"`
+	Count int32      `json:"count" binding:"omitempty,min=1"`
+}
+
+func (s *Server) createStandingOrder(ctx *gin.Context) {
+	var req createStandingOrderRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !req.StartAt.After(time.Now()) {
+		err := errors.New("start_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	recurrence := utils.Recurrence{
+		Frequency:  req.Frequency,
+		DayOfMonth: int(req.DayOfMonth),
+		StartAt:    req.StartAt,
+		Count:      int(req.Count),
+	}
+	endAt := sql.NullTime{}
+	if req.EndAt != nil {
+		recurrence.EndAt = *req.EndAt
+		endAt = sql.NullTime{Time: *req.EndAt, Valid: true}
+	}
+	if err := recurrence.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	firstOccurrence, _ := recurrence.Occurrence(0)
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !s.checkStepUp(ctx, authPayload, req.Currency, req.Amount) {
+		return
+	}
+	fromAccount, toAccount, ok := s.checkTransferAccounts(ctx, authPayload, req.FromAccountID, req.ToAccountID, req.Currency)
+	if !ok {
+		return
+	}
+	if !s.checkAccountCoolingOff(ctx, authPayload.Username, toAccount.ID, toAccount.Currency, req.Amount) {
+		return
+	}
+	if needsApproval(fromAccount, req.Amount) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errApprovalNotScheduled))
+		return
+	}
+
+	order, err := s.store.CreateStandingOrder(ctx, db.CreateStandingOrderParams{
+		Owner:            authPayload.Username,
+		FromAccountID:    fromAccount.ID,
+		ToAccountID:      toAccount.ID,
+		Amount:           req.Amount,
+		Currency:         req.Currency,
+		Frequency:        req.Frequency,
+		DayOfMonth:       req.DayOfMonth,
+		StartAt:          req.StartAt,
+		EndAt:            endAt,
+		MaxOccurrences:   sql.NullInt32{Int32: req.Count, Valid: req.Count > 0},
+		NextOccurrenceAt: firstOccurrence,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, order)
+}
+
+type listStandingOrdersRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (s *Server) listStandingOrders(ctx *gin.Context) {
+	var req listStandingOrdersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	orders, err := s.store.ListStandingOrders(ctx, db.ListStandingOrdersParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, orders)
+}
+
+type standingOrderRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type standingOrderResponse struct {
+	StandingOrder db.StandingOrder `json:"standing_order"`
+	// Executions lists every payment attempt, failed ones with their reason
+	Executions []db.StandingOrderExecution `json:"executions"`
+}
+
+func (s *Server) getStandingOrder(ctx *gin.Context) {
+	var req standingOrderRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	order, ok := s.ownedStandingOrder(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	executions, err := s.store.ListStandingOrderExecutions(ctx, order.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, standingOrderResponse{
+		StandingOrder: order,
+		Executions:    executions,
+	})
+}
+
+func (s *Server) cancelStandingOrder(ctx *gin.Context) {
+	var req standingOrderRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	order, ok := s.ownedStandingOrder(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	order, err := s.store.CancelStandingOrder(ctx, order.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = errors.New("standing order is no longer active")
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, order)
+}
+
+// ownedStandingOrder loads a standing order of the authenticated user, otherwise it responds and returns false
+func (s *Server) ownedStandingOrder(ctx *gin.Context, id int64) (db.StandingOrder, bool) {
+	order, err := s.store.GetStandingOrder(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return order, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return order, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if order.Owner != authPayload.Username {
+		err = errors.New("standing order doesnt belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return order, false
+	}
+	return order, true
+}
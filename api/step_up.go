@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+var errStepUpRequired = errors.New("recent authentication required for this transfer")
+
+type reauthenticateRequest struct {
+	Password string `json:"password" binding:"required_without=Code,omitempty,min=6"`
+	// Code is either the current totp code or one of the recovery codes
+	Code string `json:"code" binding:"required_without=Password,omitempty,max=32"`
+}
+
+type reauthenticateResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+// reauthenticate confirms the identity of a logged in user again and responds with a short lived token
+// whose auth time is recent enough for step up protected operations
+func (s *Server) reauthenticate(ctx *gin.Context) {
+	var req reauthenticateRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !s.checkLoginLockout(ctx, authPayload.Username) {
+		return
+	}
+
+	user, err := s.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var amr []string
+	if req.Password != "" {
+		if err = utils.CheckPassword(req.Password, user.HashedPassword); err != nil {
+			s.rejectLogin(ctx, user.Username, errInvalidCredentials)
+			return
+		}
+		amr = []string{token.AMRPassword}
+	} else {
+		ok, err := s.checkSecondFactor(ctx, user.Username, req.Code)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if !ok {
+			s.rejectLogin(ctx, user.Username, errInvalidVerificationCode)
+			return
+		}
+		amr = []string{token.AMROTP}
+	}
+
+	if !s.recordLoginAttempt(ctx, user.Username, true) {
+		return
+	}
+	accessToken, payload, err := s.createAccessToken(user.Username, s.config.StepUpTokenDuration, amr)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, reauthenticateResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: payload.ExpiredAt,
+	})
+}
+
+// checkStepUp responds with an error and returns false when the amount needs a more recent authentication
+// than the one the access token was issued for
+func (s *Server) checkStepUp(ctx *gin.Context, payload *token.Payload, currency string, amount int64) bool {
+	threshold, ok := s.stepUpThresholds[currency]
+	if !ok || threshold <= 0 || amount < threshold {
+		return true
+	}
+	if time.Since(payload.AuthTime) <= s.config.StepUpMaxAge {
+		return true
+	}
+
+	maxAge := int(s.config.StepUpMaxAge / time.Second)
+	ctx.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_user_authentication", max_age=%d`, maxAge))
+	resp := errorResponse(errStepUpRequired)
+	resp["step_up_required"] = true
+	ctx.JSON(http.StatusUnauthorized, resp)
+	return false
+}
@@ -0,0 +1,338 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReauthenticateAPI(t *testing.T) {
+	user, password := RandomUser(t)
+	userTotp, secret, mfaKey := randomUserTOTP(t, user.Username, true)
+
+	code, err := utils.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker)
+	}{
+		{
+			name: "Password",
+			body: gin.H{"password": password},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, true)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				payload := requireBodyMatchStepUpToken(t, recorder.Body, tokenMaker)
+				require.Equal(t, user.Username, payload.Username)
+				require.Equal(t, []string{token.AMRPassword}, payload.AMR)
+			},
+		},
+		{
+			name: "TOTPCode",
+			body: gin.H{"code": code},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(userTotp, nil).Times(1)
+				store.EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Any()).
+					Return(userTotp, nil).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, true)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				payload := requireBodyMatchStepUpToken(t, recorder.Body, tokenMaker)
+				require.Equal(t, []string{token.AMROTP}, payload.AMR)
+			},
+		},
+		{
+			name: "WrongPassword",
+			body: gin.H{"password": "wrong-password"},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, false)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidCredentials)
+			},
+		},
+		{
+			name: "WrongCode",
+			body: gin.H{"code": wrongTOTPCode(code)},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(userTotp, nil).Times(1)
+				store.EXPECT().
+					UseTOTPStep(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, false)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidVerificationCode)
+			},
+		},
+		{
+			name: "NoCredentials",
+			body: gin.H{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "LockedOut",
+			body: gin.H{"password": password},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{FailedAttempts: 3, LastFailedAt: time.Now()}, nil).
+					Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: gin.H{"password": password},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			server.config.MFAEncryptionKey = mfaKey
+			server.config.LoginMaxFailedAttempts = 3
+			server.config.LoginLockoutDuration = time.Minute
+			server.config.StepUpTokenDuration = time.Minute
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/reauthenticate"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server.tokenMaker)
+		})
+	}
+}
+
+func TestTransferStepUpAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	account1 := randomAccount(user.Username)
+	account2 := randomAccount(user.Username)
+	account1.Currency = utils.USD
+	account2.Currency = utils.USD
+
+	threshold := int64(1000)
+
+	// addAuthorization signs a token for a user who last logged in authAge ago
+	addAuthorization := func(t *testing.T, request *http.Request, tokenMaker token.Maker, authAge time.Duration) {
+		payload, err := token.NewPayload(user.Username, time.Minute)
+		require.NoError(t, err)
+		payload.AuthTime = time.Now().Add(-authAge)
+
+		accessToken, err := tokenMaker.CreateTokenFromPayload(payload)
+		require.NoError(t, err)
+		request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+	}
+
+	testCases := []struct {
+		name     string
+		amount   int64
+		currency string
+		authAge  time.Duration
+		// stepUpRequired is set when the request must be rejected before any account is read
+		stepUpRequired bool
+		checkResponse  func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "RecentAuthentication",
+			amount:   threshold,
+			currency: utils.USD,
+			authAge:  time.Second,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "BelowThreshold",
+			amount:   threshold - 1,
+			currency: utils.USD,
+			authAge:  time.Hour,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:           "StaleAuthentication",
+			amount:         threshold,
+			currency:       utils.USD,
+			authAge:        time.Hour,
+			stepUpRequired: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, `Bearer error="insufficient_user_authentication", max_age=300`, recorder.Header().Get("WWW-Authenticate"))
+
+				var resp gin.H
+				err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				require.Equal(t, errStepUpRequired.Error(), resp["error"])
+				require.Equal(t, true, resp["step_up_required"])
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			expectedCalls := 1
+			if tc.stepUpRequired {
+				expectedCalls = 0
+			}
+			store.EXPECT().
+				GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+				Return(account1, nil).Times(expectedCalls)
+			store.EXPECT().
+				GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+				Return(account2, nil).Times(expectedCalls)
+			store.EXPECT().
+				TransferTx(gomock.Any(), gomock.Any()).
+				Times(expectedCalls)
+
+			server := NewTestServer(t, store)
+			server.stepUpThresholds = map[string]int64{utils.USD: threshold}
+			server.config.StepUpMaxAge = 5 * time.Minute
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          tc.amount,
+				"currency":        tc.currency,
+			})
+			require.NoError(t, err)
+
+			url := "/transfers"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, tc.authAge)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// requireBodyMatchStepUpToken checks that the response holds a valid token issued just now
+func requireBodyMatchStepUpToken(t *testing.T, body *bytes.Buffer, tokenMaker token.Maker) *token.Payload {
+	var resp reauthenticateResponse
+	err := json.Unmarshal(body.Bytes(), &resp)
+	require.NoError(t, err)
+
+	payload, err := tokenMaker.VerifyToken(resp.AccessToken)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), payload.AuthTime, time.Second)
+	require.WithinDuration(t, payload.ExpiredAt, resp.AccessTokenExpiresAt, time.Second)
+	return payload
+}
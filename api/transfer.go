@@ -2,19 +2,30 @@ package api
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	db "github.com/SaishNaik/simplebank/db/sqlc"
 	"github.com/SaishNaik/simplebank/token"
+	"github.com/SaishNaik/simplebank/utils"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
 type transferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID accountRef `json:"from_account_id" binding:"required,account_ref"`
+	ToAccountID   accountRef `json:"to_account_id" binding:"omitempty,account_ref"`
+	// Recipient can be given instead of ToAccountID, see resolveRecipient
+	Recipient string `json:"recipient" binding:"required_without_all=ToAccountID PayeeID,excluded_with=ToAccountID,max=254"`
+	// PayeeID sends the transfer to a saved payee instead of ToAccountID or Recipient
+	PayeeID           int64           `json:"payee_id" binding:"excluded_with=ToAccountID Recipient,omitempty,min=1"`
+	Amount            int64           `json:"amount" binding:"required,gt=0"`
+	Currency          string          `json:"currency" binding:"required,currency"`
+	Description       string          `json:"description" binding:"max=280"`
+	ExternalReference string          `json:"external_reference" binding:"max=64"`
+	Metadata          json.RawMessage `json:"metadata" binding:"omitempty,max=4096,json_object"`
+	// Mode authorize only holds the amount on the from account, see authorizeTransfer
+	Mode string `json:"mode" binding:"omitempty,oneof=transfer authorize"`
 }
 
 func (s *Server) createTransfer(ctx *gin.Context) {
@@ -24,39 +35,145 @@ func (s *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !s.checkStepUp(ctx, authPayload, req.Currency, req.Amount) {
+		return
+	}
+
 	fromAccount, valid := s.validAccount(ctx, req.FromAccountID, req.Currency)
 	if !valid {
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	if fromAccount.Owner != authPayload.Username {
 		err := errors.New("from account does not belong to authenticated user")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	_, valid = s.validAccount(ctx, req.ToAccountID, req.Currency)
+	var toAccount db.Account
+	switch {
+	case req.PayeeID != 0:
+		toAccount, valid = s.payeeAccount(ctx, req.PayeeID, req.Currency, req.Amount)
+	case req.Recipient != "":
+		toAccount, valid = s.resolveRecipient(ctx, req.Recipient, req.Currency)
+		valid = valid && s.checkAccountCoolingOff(ctx, authPayload.Username, toAccount.ID, toAccount.Currency, req.Amount)
+	default:
+		toAccount, valid = s.validAccount(ctx, req.ToAccountID, req.Currency)
+		valid = valid && s.checkAccountCoolingOff(ctx, authPayload.Username, toAccount.ID, toAccount.Currency, req.Amount)
+	}
 	if !valid {
 		return
 	}
+	toAccountID := toAccount.ID
+
+	if needsApproval(fromAccount, req.Amount) {
+		s.requestTransferApproval(ctx, authPayload, fromAccount, toAccountID, req)
+		return
+	}
+
+	if req.Mode == transferModeAuthorize {
+		s.authorizeTransfer(ctx, authPayload, fromAccount, toAccountID, req)
+		return
+	}
 
 	args := db.TransferTxParams{
-		FromAccountId: req.FromAccountID,
-		ToAccountId:   req.ToAccountID,
-		Amount:        req.Amount,
+		FromAccountId:     fromAccount.ID,
+		ToAccountId:       toAccountID,
+		Amount:            req.Amount,
+		Description:       req.Description,
+		ExternalReference: req.ExternalReference,
+		Metadata:          req.Metadata,
 	}
 
 	result, err := s.store.TransferTx(ctx, args)
 	if err != nil {
+		if errors.Is(err, db.ErrAccountNotActive) || errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrTransferLimitExceeded) ||
+			errors.Is(err, db.ErrApprovalRequired) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 	ctx.JSON(http.StatusOK, result)
 }
 
-func (s *Server) validAccount(ctx *gin.Context, accountId int64, currency string) (db.Account, bool) {
-	account, err := s.store.GetAccount(ctx, accountId)
+type searchTransfersRequest struct {
+	AccountID accountRef `form:"account_id" binding:"required,account_ref"`
+	// Query matches anywhere in the description, ignoring case
+	Query             string `form:"q" binding:"max=280"`
+	ExternalReference string `form:"external_reference" binding:"max=64"`
+	// Metadata is a json object the metadata of matching transfers must contain
+	Metadata string `form:"metadata" binding:"omitempty,max=4096,json_object"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// searchTransfers lists the transfers of an account of the authenticated user, newest first
+func (s *Server) searchTransfers(ctx *gin.Context) {
+	var req searchTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := s.getAccountByRef(ctx, req.AccountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err = errors.New("account doesnt belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	metadata := json.RawMessage("{}")
+	if req.Metadata != "" {
+		metadata = json.RawMessage(req.Metadata)
+	}
+	transfers, err := s.store.SearchTransfers(ctx, db.SearchTransfersParams{
+		AccountID:         account.ID,
+		Description:       req.Query,
+		ExternalReference: req.ExternalReference,
+		Metadata:          metadata,
+		Limit:             req.PageSize,
+		Offset:            (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, transfers)
+}
+
+// checkTransferAccounts checks that the from account belongs to the authenticated user and that both
+// accounts can move money in currency, otherwise it responds and returns false
+func (s *Server) checkTransferAccounts(ctx *gin.Context, authPayload *token.Payload, fromAccountRef, toAccountRef accountRef, currency string) (fromAccount, toAccount db.Account, ok bool) {
+	fromAccount, valid := s.validAccount(ctx, fromAccountRef, currency)
+	if !valid {
+		return fromAccount, toAccount, false
+	}
+
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account does not belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return fromAccount, toAccount, false
+	}
+
+	toAccount, valid = s.validAccount(ctx, toAccountRef, currency)
+	return fromAccount, toAccount, valid
+}
+
+func (s *Server) validAccount(ctx *gin.Context, ref accountRef, currency string) (db.Account, bool) {
+	account, err := s.getAccountByRef(ctx, ref)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
@@ -65,11 +182,26 @@ func (s *Server) validAccount(ctx *gin.Context, accountId int64, currency string
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return account, false
 	}
+	return account, checkAccount(ctx, account, currency)
+}
 
-	if account.Currency != currency {
-		err = fmt.Errorf("account [%d] curreny mismatch %s vs %s", account.ID, account.Currency, currency)
+// checkAccount checks that an account can move money in currency, otherwise it responds and returns false
+func checkAccount(ctx *gin.Context, account db.Account, currency string) bool {
+	if err := accountError(account, currency); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return account, false
+		return false
+	}
+	return true
+}
+
+// accountError returns why an account cannot move money in currency, or nil if it can
+func accountError(account db.Account, currency string) error {
+	if account.Currency != currency {
+		return fmt.Errorf("account [%d] curreny mismatch %s vs %s", account.ID, account.Currency, currency)
+	}
+
+	if account.Status != utils.AccountStatusActive {
+		return fmt.Errorf("%w: account [%d] is %s", db.ErrAccountNotActive, account.ID, account.Status)
 	}
-	return account, true
+	return nil
 }
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"net/http"
+	"time"
+)
+
+var (
+	errApprovalNotAuthorized = errors.New("transfers needing approval cannot be authorized")
+	errApprovalNotScheduled  = errors.New("transfers needing approval cannot be scheduled")
+	errApprovalNotBatched    = errors.New("transfers needing approval cannot be part of a batch")
+)
+
+// needsApproval reports whether a transfer of amount from account has to be approved before it is posted
+func needsApproval(account db.Account, amount int64) bool {
+	return account.ApprovalThreshold > 0 && amount >= account.ApprovalThreshold
+}
+
+// requestTransferApproval records a pending transfer, no money moves until its designated approvers approve it
+func (s *Server) requestTransferApproval(ctx *gin.Context, authPayload *token.Payload, fromAccount db.Account, toAccountID int64, req transferRequest) {
+	if req.Mode == transferModeAuthorize {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errApprovalNotAuthorized))
+		return
+	}
+
+	metadata := req.Metadata
+	if len(metadata) == 0 {
+		metadata = json.RawMessage("{}")
+	}
+	var expiresAt sql.NullTime
+	if s.config.TransferApprovalDuration > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().Add(s.config.TransferApprovalDuration), Valid: true}
+	}
+
+	transfer, err := s.store.CreatePendingTransfer(ctx, db.CreatePendingTransferParams{
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       toAccountID,
+		Amount:            req.Amount,
+		Description:       req.Description,
+		ExternalReference: req.ExternalReference,
+		Metadata:          metadata,
+		RequestedBy:       authPayload.Username,
+		RequiredApprovals: fromAccount.RequiredApprovals,
+		ExpiresAt:         expiresAt,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusAccepted, transfer)
+}
+
+type transferApprovalRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// approveTransfer records the approval of a pending transfer by a designated approver of its from account.
+// The transfer is posted once it has collected its required approvals.
+func (s *Server) approveTransfer(ctx *gin.Context) {
+	var req transferApprovalRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	transfer, ok := s.approverTransfer(ctx, authPayload, req.ID)
+	if !ok {
+		return
+	}
+
+	result, err := s.store.ApproveTransferTx(ctx, db.ApproveTransferTxParams{
+		TransferID: transfer.ID,
+		Approver:   authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrAccountNotActive) || errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrTransferLimitExceeded) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		transferDecisionError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// rejectTransfer rejects a pending transfer on behalf of a designated approver of its from account
+func (s *Server) rejectTransfer(ctx *gin.Context) {
+	var req transferApprovalRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	transfer, ok := s.approverTransfer(ctx, authPayload, req.ID)
+	if !ok {
+		return
+	}
+
+	result, err := s.store.RejectTransferTx(ctx, db.RejectTransferTxParams{
+		TransferID: transfer.ID,
+		Approver:   authPayload.Username,
+	})
+	if err != nil {
+		transferDecisionError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// approverTransfer gets a transfer, checking the authenticated user is a designated approver of its from account,
+// otherwise it responds and returns false
+func (s *Server) approverTransfer(ctx *gin.Context, authPayload *token.Payload, transferID int64) (db.Transfer, bool) {
+	transfer, err := s.store.GetTransfer(ctx, transferID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return transfer, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return transfer, false
+	}
+
+	_, err = s.store.GetAccountApprover(ctx, db.GetAccountApproverParams{
+		AccountID: transfer.FromAccountID,
+		Username:  authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = errors.New("authenticated user is not an approver of the account")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return transfer, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return transfer, false
+	}
+	return transfer, true
+}
+
+// transferDecisionError responds with the status of an error from approving or rejecting a transfer
+func transferDecisionError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, db.ErrSelfApproval):
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+	case errors.Is(err, db.ErrTransferNotPending),
+		errors.Is(err, db.ErrTransferApprovalExpired),
+		errors.Is(err, db.ErrAlreadyDecided):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+type updateApprovalPolicyRequest struct {
+	// Threshold is the amount from which transfers need approval, zero turns approvals off
+	Threshold         int64    `json:"threshold" binding:"min=0"`
+	RequiredApprovals int32    `json:"required_approvals" binding:"required,min=1,max=10"`
+	Approvers         []string `json:"approvers" binding:"max=20,unique,dive,required,alphanum"`
+}
+
+// updateApprovalPolicy sets which transfers of an account need approval and by whom.
+// The owner sets up the policy and can tighten it, loosening a policy in force is left to a banker.
+func (s *Server) updateApprovalPolicy(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req updateApprovalPolicyRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	account, err := s.getAccountByRef(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// once a policy is in force the owner can only tighten it, so credentials of the owner alone
+	// cannot switch approvals off or hand them to someone else
+	if account.Owner == authPayload.Username && account.ApprovalThreshold > 0 {
+		approvers, err := s.store.ListAccountApprovers(ctx, account.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if loosensApprovalPolicy(account, approvers, req) {
+			err = errors.New("only a banker can loosen the approval policy of an account")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+	} else if account.Owner != authPayload.Username {
+		user, err := s.store.GetUser(ctx, authPayload.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if user.Role != utils.BankerRole {
+			err = errors.New("account doesnt belong to authenticated user")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+	}
+
+	for _, approver := range req.Approvers {
+		if approver == account.Owner {
+			err = errors.New("the account owner cannot be an approver")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+	if req.Threshold > 0 && len(req.Approvers) < int(req.RequiredApprovals) {
+		err = fmt.Errorf("%d approvals required but only %d approvers", req.RequiredApprovals, len(req.Approvers))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := s.store.UpdateApprovalPolicyTx(ctx, db.UpdateApprovalPolicyTxParams{
+		AccountID:         account.ID,
+		ApprovalThreshold: req.Threshold,
+		RequiredApprovals: req.RequiredApprovals,
+		Approvers:         req.Approvers,
+	})
+	if err != nil {
+		if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "foreign_key_violation" {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// loosensApprovalPolicy reports whether req lets a transfer through that the policy in force on account holds
+// for approval, or lets anyone but the current approvers approve
+func loosensApprovalPolicy(account db.Account, approvers []db.AccountApprover, req updateApprovalPolicyRequest) bool {
+	if req.Threshold == 0 || req.Threshold > account.ApprovalThreshold || req.RequiredApprovals < account.RequiredApprovals {
+		return true
+	}
+	current := make(map[string]bool, len(approvers))
+	for _, approver := range approvers {
+		current[approver.Username] = true
+	}
+	for _, approver := range req.Approvers {
+		if !current[approver] {
+			return true
+		}
+	}
+	return false
+}
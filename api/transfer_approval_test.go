@@ -0,0 +1,565 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateTransferNeedsApprovalAPI(t *testing.T) {
+	maker, _ := RandomUser(t)
+	recipient, _ := RandomUser(t)
+	fromAccount := randomAccount(maker.Username)
+	fromAccount.Currency = utils.USD
+	fromAccount.ApprovalThreshold = 1000
+	fromAccount.RequiredApprovals = 2
+	toAccount := randomAccount(recipient.Username)
+	toAccount.Currency = utils.USD
+
+	pending := db.Transfer{
+		ID:                utils.RandomInt(1, 1000),
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       toAccount.ID,
+		Amount:            fromAccount.ApprovalThreshold,
+		Metadata:          json.RawMessage(`{}`),
+		Status:            utils.TransferStatusPending,
+		RequestedBy:       maker.Username,
+		RequiredApprovals: fromAccount.RequiredApprovals,
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Pending",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          fromAccount.ApprovalThreshold,
+				"currency":        utils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					CreatePendingTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreatePendingTransferParams) (db.Transfer, error) {
+						require.Equal(t, maker.Username, arg.RequestedBy)
+						require.Equal(t, fromAccount.RequiredApprovals, arg.RequiredApprovals)
+						require.True(t, arg.ExpiresAt.Valid)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt.Time, time.Minute)
+						return pending, nil
+					})
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var got db.Transfer
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, pending, got)
+			},
+		},
+		{
+			name: "BelowThreshold",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          fromAccount.ApprovalThreshold - 1,
+				"currency":        utils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "AuthorizeRefused",
+			body: gin.H{
+				"from_account_id": fromAccount.ID,
+				"to_account_id":   toAccount.ID,
+				"amount":          fromAccount.ApprovalThreshold,
+				"currency":        utils.USD,
+				"mode":            transferModeAuthorize,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, maker.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestApproveTransferAPI(t *testing.T) {
+	maker, _ := RandomUser(t)
+	checker, _ := RandomUser(t)
+	outsider, _ := RandomUser(t)
+	fromAccount := randomAccount(maker.Username)
+	fromAccount.ApprovalThreshold = 1000
+	fromAccount.RequiredApprovals = 1
+
+	pending := db.Transfer{
+		ID:                utils.RandomInt(1, 1000),
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       fromAccount.ID + 1,
+		Amount:            fromAccount.ApprovalThreshold,
+		Metadata:          json.RawMessage(`{}`),
+		Status:            utils.TransferStatusPending,
+		RequestedBy:       maker.Username,
+		RequiredApprovals: fromAccount.RequiredApprovals,
+	}
+	completed := pending
+	completed.Status = utils.TransferStatusCompleted
+	result := db.ApproveTransferTxResult{
+		Transfer: completed,
+		Approval: db.TransferApproval{TransferID: pending.ID, Approver: checker.Username, Approved: true},
+	}
+	approverParams := func(username string) db.GetAccountApproverParams {
+		return db.GetAccountApproverParams{AccountID: fromAccount.ID, Username: username}
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: checker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().
+					GetAccountApprover(gomock.Any(), gomock.Eq(approverParams(checker.Username))).
+					Times(1).
+					Return(db.AccountApprover{AccountID: fromAccount.ID, Username: checker.Username}, nil)
+				store.EXPECT().
+					ApproveTransferTx(gomock.Any(), gomock.Eq(db.ApproveTransferTxParams{TransferID: pending.ID, Approver: checker.Username})).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.ApproveTransferTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, result, got)
+			},
+		},
+		{
+			name:     "NotApprover",
+			username: outsider.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().
+					GetAccountApprover(gomock.Any(), gomock.Eq(approverParams(outsider.Username))).
+					Times(1).
+					Return(db.AccountApprover{}, sql.ErrNoRows)
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "SelfApproval",
+			username: maker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().
+					GetAccountApprover(gomock.Any(), gomock.Eq(approverParams(maker.Username))).
+					Times(1).
+					Return(db.AccountApprover{AccountID: fromAccount.ID, Username: maker.Username}, nil)
+				store.EXPECT().
+					ApproveTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApproveTransferTxResult{}, db.ErrSelfApproval)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "Expired",
+			username: checker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().GetAccountApprover(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountApprover{}, nil)
+				store.EXPECT().
+					ApproveTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApproveTransferTxResult{}, fmt.Errorf("%w: transfer [%d]", db.ErrTransferApprovalExpired, pending.ID))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			username: checker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().GetAccountApprover(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountApprover{}, nil)
+				store.EXPECT().
+					ApproveTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApproveTransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: checker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/approve", pending.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRejectTransferAPI(t *testing.T) {
+	maker, _ := RandomUser(t)
+	checker, _ := RandomUser(t)
+	fromAccount := randomAccount(maker.Username)
+
+	pending := db.Transfer{
+		ID:            utils.RandomInt(1, 1000),
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   fromAccount.ID + 1,
+		Amount:        utils.RandomMoney(),
+		Metadata:      json.RawMessage(`{}`),
+		Status:        utils.TransferStatusPending,
+		RequestedBy:   maker.Username,
+	}
+	rejected := pending
+	rejected.Status = utils.TransferStatusRejected
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().GetAccountApprover(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountApprover{}, nil)
+				store.EXPECT().
+					RejectTransferTx(gomock.Any(), gomock.Eq(db.RejectTransferTxParams{TransferID: pending.ID, Approver: checker.Username})).
+					Times(1).
+					Return(db.RejectTransferTxResult{Transfer: rejected}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got db.RejectTransferTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, utils.TransferStatusRejected, got.Transfer.Status)
+			},
+		},
+		{
+			name: "NotPending",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(rejected, nil)
+				store.EXPECT().GetAccountApprover(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountApprover{}, nil)
+				store.EXPECT().
+					RejectTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RejectTransferTxResult{}, db.ErrTransferNotPending)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/reject", pending.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, checker.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateApprovalPolicyAPI(t *testing.T) {
+	owner, _ := RandomUser(t)
+	approver1, _ := RandomUser(t)
+	approver2, _ := RandomUser(t)
+	banker, _ := RandomUser(t)
+	banker.Role = utils.BankerRole
+	account := randomAccount(owner.Username)
+	// guarded is an account with a policy in force
+	guarded := account
+	guarded.ApprovalThreshold = 1000
+	guarded.RequiredApprovals = 1
+	approvers := []db.AccountApprover{
+		{AccountID: account.ID, Username: approver1.Username},
+		{AccountID: account.ID, Username: approver2.Username},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner.Username,
+			body: gin.H{
+				"threshold":          1000,
+				"required_approvals": 2,
+				"approvers":          []string{approver1.Username, approver2.Username},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.UpdateApprovalPolicyTxParams{
+					AccountID:         account.ID,
+					ApprovalThreshold: 1000,
+					RequiredApprovals: 2,
+					Approvers:         []string{approver1.Username, approver2.Username},
+				}
+				store.EXPECT().
+					UpdateApprovalPolicyTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.UpdateApprovalPolicyTxResult{Account: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "TooFewApprovers",
+			username: owner.Username,
+			body: gin.H{
+				"threshold":          1000,
+				"required_approvals": 2,
+				"approvers":          []string{approver1.Username},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateApprovalPolicyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "OwnerAsApprover",
+			username: owner.Username,
+			body: gin.H{
+				"threshold":          1000,
+				"required_approvals": 1,
+				"approvers":          []string{owner.Username},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateApprovalPolicyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "TightenedByOwner",
+			username: owner.Username,
+			body: gin.H{
+				"threshold":          500,
+				"required_approvals": 2,
+				"approvers":          []string{approver1.Username, approver2.Username},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(guarded, nil)
+				store.EXPECT().ListAccountApprovers(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(approvers, nil)
+				store.EXPECT().UpdateApprovalPolicyTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "TurnedOffByOwner",
+			username: owner.Username,
+			body: gin.H{
+				"threshold":          0,
+				"required_approvals": 1,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(guarded, nil)
+				store.EXPECT().ListAccountApprovers(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(approvers, nil)
+				store.EXPECT().UpdateApprovalPolicyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "ApproversReplacedByOwner",
+			username: owner.Username,
+			body: gin.H{
+				"threshold":          1000,
+				"required_approvals": 1,
+				"approvers":          []string{banker.Username},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(guarded, nil)
+				store.EXPECT().ListAccountApprovers(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(approvers, nil)
+				store.EXPECT().UpdateApprovalPolicyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "TurnedOffByBanker",
+			username: banker.Username,
+			body: gin.H{
+				"threshold":          0,
+				"required_approvals": 1,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(guarded, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(banker.Username)).Times(1).Return(banker, nil)
+				arg := db.UpdateApprovalPolicyTxParams{
+					AccountID:         account.ID,
+					RequiredApprovals: 1,
+				}
+				store.EXPECT().UpdateApprovalPolicyTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Unauthorized",
+			username: approver1.Username,
+			body: gin.H{
+				"threshold":          0,
+				"required_approvals": 1,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(approver1.Username)).Times(1).Return(approver1, nil)
+				store.EXPECT().UpdateApprovalPolicyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			url := fmt.Sprintf("/accounts/%d/approval-policy", account.ID)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"math"
+	"net/http"
+)
+
+type transferBatchItemRequest struct {
+	ToAccountID       accountRef `json:"to_account_id" binding:"required,account_ref"`
+	Amount            int64      `json:"amount" binding:"required,gt=0"`
+	Description       string     `json:"description" binding:"max=280"`
+	ExternalReference string     `json:"external_reference" binding:"max=64"`
+}
+
+type createTransferBatchRequest struct {
+	FromAccountID accountRef `json:"from_account_id" binding:"required,account_ref"`
+	Currency      string     `json:"currency" binding:"required,currency"`
+	// Atomic transfers all items or none, otherwise each item succeeds or fails on its own
+	Atomic bool                       `json:"atomic"`
+	Items  []transferBatchItemRequest `json:"items" binding:"required,min=1,max=500,dive"`
+	// IdempotencyKey makes retries safe, a batch already created with the same key is returned instead of executed again
+	IdempotencyKey string `json:"idempotency_key" binding:"max=64"`
+}
+
+// createTransferBatch transfers many items from one account of the authenticated user.
+// Every item is validated before anything is transferred, the batch is refused as a whole if one is invalid
+// or if the from account cannot cover the total and its fees.
+func (s *Server) createTransferBatch(ctx *gin.Context) {
+	var req createTransferBatchRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var total int64
+	amounts := make([]int64, len(req.Items))
+	for i, item := range req.Items {
+		if item.Amount > math.MaxInt64-total {
+			err := fmt.Errorf("items[%d]: batch total overflows", i)
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		total += item.Amount
+		amounts[i] = item.Amount
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !s.checkStepUp(ctx, authPayload, req.Currency, total) {
+		return
+	}
+
+	fromAccount, valid := s.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account does not belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if req.IdempotencyKey != "" && s.existingTransferBatch(ctx, authPayload.Username, req.IdempotencyKey) {
+		return
+	}
+
+	// each item is charged its own fee on top of its amount
+	fees, err := s.store.TransferFees(ctx, fromAccount, amounts...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if fees > math.MaxInt64-total || fromAccount.AvailableBalance < total+fees {
+		err = fmt.Errorf("%w: account [%d] cannot cover the batch total of %d and fees of %d", db.ErrInsufficientFunds, fromAccount.ID, total, fees)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	items := make([]db.TransferBatchItemParams, len(req.Items))
+	for i, item := range req.Items {
+		if needsApproval(fromAccount, item.Amount) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("items[%d]: %w", i, errApprovalNotBatched)))
+			return
+		}
+		toAccount, err := s.getAccountByRef(ctx, item.ToAccountID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = fmt.Errorf("items[%d]: account %s not found", i, item.ToAccountID)
+				ctx.JSON(http.StatusNotFound, errorResponse(err))
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if err = accountError(toAccount, req.Currency); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("items[%d]: %w", i, err)))
+			return
+		}
+		if !s.checkAccountCoolingOff(ctx, authPayload.Username, toAccount.ID, toAccount.Currency, item.Amount) {
+			return
+		}
+		items[i] = db.TransferBatchItemParams{
+			ToAccountID:       toAccount.ID,
+			Amount:            item.Amount,
+			Description:       item.Description,
+			ExternalReference: item.ExternalReference,
+		}
+	}
+
+	result, err := s.store.CreateTransferBatchTx(ctx, db.CreateTransferBatchTxParams{
+		Owner:          authPayload.Username,
+		FromAccountID:  fromAccount.ID,
+		Currency:       req.Currency,
+		Atomic:         req.Atomic,
+		Items:          items,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		// a concurrent retry recorded the batch first
+		var pqError *pq.Error
+		if errors.As(err, &pqError) && pqError.Code.Name() == "unique_violation" && req.IdempotencyKey != "" &&
+			s.existingTransferBatch(ctx, authPayload.Username, req.IdempotencyKey) {
+			return
+		}
+		// only an atomic batch fails as a whole, when an account changed since it was validated
+		if errors.Is(err, db.ErrAccountNotActive) || errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrTransferLimitExceeded) ||
+			errors.Is(err, db.ErrApprovalRequired) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, transferBatchResponse{
+		Batch: result.Batch,
+		Items: result.Items,
+	})
+}
+
+// existingTransferBatch responds with the batch of owner created with key, it returns false if there is none
+func (s *Server) existingTransferBatch(ctx *gin.Context, owner, key string) bool {
+	batch, err := s.store.GetTransferBatchByIdempotencyKey(ctx, db.GetTransferBatchByIdempotencyKeyParams{
+		Owner:          owner,
+		IdempotencyKey: sql.NullString{String: key, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return true
+	}
+
+	items, err := s.store.ListTransferBatchItems(ctx, batch.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return true
+	}
+	ctx.JSON(http.StatusOK, transferBatchResponse{
+		Batch: batch,
+		Items: items,
+	})
+	return true
+}
+
+type transferBatchRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type transferBatchResponse struct {
+	Batch db.TransferBatch       `json:"batch"`
+	Items []db.TransferBatchItem `json:"items"`
+}
+
+// getTransferBatch returns a batch of the authenticated user with the status of each item
+func (s *Server) getTransferBatch(ctx *gin.Context) {
+	var req transferBatchRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	batch, err := s.store.GetTransferBatch(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if batch.Owner != authPayload.Username {
+		err = errors.New("transfer batch doesnt belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	items, err := s.store.ListTransferBatchItems(ctx, batch.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, transferBatchResponse{
+		Batch: batch,
+		Items: items,
+	})
+}
@@ -0,0 +1,397 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateTransferBatchAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+	user3, _ := RandomUser(t)
+	fromAccount := randomAccount(user1.Username)
+	fromAccount.AvailableBalance = 100
+	toAccount1 := randomAccount(user2.Username)
+	toAccount1.ID = fromAccount.ID + 1
+	toAccount1.Currency = fromAccount.Currency
+	toAccount2 := randomAccount(user3.Username)
+	toAccount2.ID = fromAccount.ID + 2
+	toAccount2.Currency = fromAccount.Currency
+
+	items := []gin.H{
+		{"to_account_id": toAccount1.ID, "amount": 60, "description": "Salary"},
+		{"to_account_id": toAccount2.AccountNumber, "amount": 40},
+	}
+	itemParams := []db.TransferBatchItemParams{
+		{ToAccountID: toAccount1.ID, Amount: 60, Description: "Salary"},
+		{ToAccountID: toAccount2.ID, Amount: 40},
+	}
+	batch := db.TransferBatch{
+		ID:            utils.RandomInt(1, 1000),
+		Owner:         user1.Username,
+		FromAccountID: fromAccount.ID,
+		Currency:      fromAccount.Currency,
+		TotalAmount:   100,
+		Status:        utils.TransferBatchStatusPartiallySucceeded,
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"from_account_id": fromAccount.ID, "currency": fromAccount.Currency, "items": items},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferFees(gomock.Any(), gomock.Eq(fromAccount), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount1.ID)).Times(1).Return(toAccount1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(toAccount2.AccountNumber)).Times(1).Return(toAccount2, nil)
+				store.EXPECT().
+					CreateTransferBatchTx(gomock.Any(), gomock.Eq(db.CreateTransferBatchTxParams{
+						Owner:         user1.Username,
+						FromAccountID: fromAccount.ID,
+						Currency:      fromAccount.Currency,
+						Items:         itemParams,
+					})).
+					Times(1).
+					Return(db.CreateTransferBatchTxResult{Batch: batch}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got transferBatchResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, batch, got.Batch)
+			},
+		},
+		{
+			name: "Atomic",
+			body: gin.H{"from_account_id": fromAccount.ID, "currency": fromAccount.Currency, "atomic": true, "items": items[:1]},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferFees(gomock.Any(), gomock.Eq(fromAccount), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount1.ID)).Times(1).Return(toAccount1, nil)
+				store.EXPECT().
+					CreateTransferBatchTx(gomock.Any(), gomock.Eq(db.CreateTransferBatchTxParams{
+						Owner:         user1.Username,
+						FromAccountID: fromAccount.ID,
+						Currency:      fromAccount.Currency,
+						Atomic:        true,
+						Items:         itemParams[:1],
+					})).
+					Times(1).
+					Return(db.CreateTransferBatchTxResult{Batch: batch}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "IdempotencyKey",
+			body: gin.H{"from_account_id": fromAccount.ID, "currency": fromAccount.Currency, "items": items[:1], "idempotency_key": "payroll-1"},
+			buildStubs: func(store *mockdb.MockStore) {
+				key := db.GetTransferBatchByIdempotencyKeyParams{
+					Owner:          user1.Username,
+					IdempotencyKey: sql.NullString{String: "payroll-1", Valid: true},
+				}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferFees(gomock.Any(), gomock.Eq(fromAccount), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().GetTransferBatchByIdempotencyKey(gomock.Any(), gomock.Eq(key)).Times(1).Return(db.TransferBatch{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount1.ID)).Times(1).Return(toAccount1, nil)
+				store.EXPECT().
+					CreateTransferBatchTx(gomock.Any(), gomock.Eq(db.CreateTransferBatchTxParams{
+						Owner:          user1.Username,
+						FromAccountID:  fromAccount.ID,
+						Currency:       fromAccount.Currency,
+						Items:          itemParams[:1],
+						IdempotencyKey: "payroll-1",
+					})).
+					Times(1).
+					Return(db.CreateTransferBatchTxResult{Batch: batch}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "IdempotentRetry",
+			body: gin.H{"from_account_id": fromAccount.ID, "currency": fromAccount.Currency, "items": items, "idempotency_key": "payroll-1"},
+			buildStubs: func(store *mockdb.MockStore) {
+				existing := batch
+				existing.IdempotencyKey = sql.NullString{String: "payroll-1", Valid: true}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetTransferBatchByIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(existing, nil)
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return([]db.TransferBatchItem{}, nil)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got transferBatchResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, batch.ID, got.Batch.ID)
+			},
+		},
+		{
+			name: "IdempotentRetryRace",
+			body: gin.H{"from_account_id": fromAccount.ID, "currency": fromAccount.Currency, "items": items[:1], "idempotency_key": "payroll-1"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferFees(gomock.Any(), gomock.Eq(fromAccount), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount1.ID)).Times(1).Return(toAccount1, nil)
+				gomock.InOrder(
+					store.EXPECT().GetTransferBatchByIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferBatch{}, sql.ErrNoRows),
+					store.EXPECT().
+						CreateTransferBatchTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.CreateTransferBatchTxResult{}, &pq.Error{Code: "23505"}),
+					store.EXPECT().GetTransferBatchByIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(batch, nil),
+				)
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return([]db.TransferBatchItem{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{"from_account_id": fromAccount.ID, "currency": fromAccount.Currency, "items": append(items, gin.H{"to_account_id": toAccount1.ID, "amount": 1})},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferFees(gomock.Any(), gomock.Eq(fromAccount), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount1.ID)).Times(0)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFundsForFees",
+			body: gin.H{"from_account_id": fromAccount.ID, "currency": fromAccount.Currency, "items": items},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				// the balance covers the amounts but not the fee of each item
+				store.EXPECT().
+					TransferFees(gomock.Any(), gomock.Eq(fromAccount), gomock.Any()).
+					Times(1).
+					Return(int64(len(items)), nil)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "fees of 2")
+			},
+		},
+		{
+			name: "ItemNeedsApproval",
+			body: gin.H{"from_account_id": fromAccount.ID, "currency": fromAccount.Currency, "items": items},
+			buildStubs: func(store *mockdb.MockStore) {
+				account := fromAccount
+				account.ApprovalThreshold = 50
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(account, nil)
+				store.EXPECT().TransferFees(gomock.Any(), gomock.Eq(account), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "items[0]")
+			},
+		},
+		{
+			name: "ItemCurrencyMismatch",
+			body: gin.H{"from_account_id": fromAccount.ID, "currency": fromAccount.Currency, "items": items},
+			buildStubs: func(store *mockdb.MockStore) {
+				account := toAccount2
+				account.Currency = otherCurrency(fromAccount.Currency)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferFees(gomock.Any(), gomock.Eq(fromAccount), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount1.ID)).Times(1).Return(toAccount1, nil)
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(toAccount2.AccountNumber)).Times(1).Return(account, nil)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "items[1]")
+			},
+		},
+		{
+			name: "ItemNotFound",
+			body: gin.H{"from_account_id": fromAccount.ID, "currency": fromAccount.Currency, "items": items},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferFees(gomock.Any(), gomock.Eq(fromAccount), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount1.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "items[0]")
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{"from_account_id": toAccount1.ID, "currency": fromAccount.Currency, "items": items[1:]},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount1.ID)).Times(1).Return(toAccount1, nil)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NoItems",
+			body: gin.H{"from_account_id": fromAccount.ID, "currency": fromAccount.Currency, "items": []gin.H{}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidItemAmount",
+			body: gin.H{"from_account_id": fromAccount.ID, "currency": fromAccount.Currency, "items": []gin.H{{"to_account_id": toAccount1.ID, "amount": -1}}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateTransferBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfer-batches", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTransferBatchAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+	batch := db.TransferBatch{
+		ID:            utils.RandomInt(1, 1000),
+		Owner:         user1.Username,
+		FromAccountID: utils.RandomInt(1, 1000),
+		Currency:      utils.RandomCurrency(),
+		TotalAmount:   utils.RandomInt(1, 1000),
+		Status:        utils.TransferBatchStatusSucceeded,
+	}
+	items := []db.TransferBatchItem{
+		{
+			ID:          utils.RandomInt(1, 1000),
+			BatchID:     batch.ID,
+			ToAccountID: utils.RandomInt(1, 1000),
+			Amount:      batch.TotalAmount,
+			Status:      utils.TransferBatchItemStatusSucceeded,
+			TransferID:  sql.NullInt64{Int64: utils.RandomInt(1, 1000), Valid: true},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(items, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got transferBatchResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, batch, got.Batch)
+				require.Equal(t, items, got.Items)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().ListTransferBatchItems(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(db.TransferBatch{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfer-batches/%d", batch.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/token"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"net/http"
+	"time"
+)
+
+type limitUsage struct {
+	// limits are zero when there is no limit
+	PerTransaction int64 `json:"per_transaction"`
+	Daily          int64 `json:"daily"`
+	Monthly        int64 `json:"monthly"`
+	SentToday      int64 `json:"sent_today"`
+	SentThisMonth  int64 `json:"sent_this_month"`
+	// remaining allowances are left out when there is no limit
+	RemainingToday     *int64 `json:"remaining_today,omitempty"`
+	RemainingThisMonth *int64 `json:"remaining_this_month,omitempty"`
+}
+
+type userLimitResponse struct {
+	Currency string `json:"currency"`
+	limitUsage
+}
+
+type accountLimitResponse struct {
+	AccountID int64 `json:"account_id"`
+	limitUsage
+}
+
+type listLimitsResponse struct {
+	User     []userLimitResponse    `json:"user"`
+	Accounts []accountLimitResponse `json:"accounts"`
+}
+
+// listLimits shows the transfer limits of the authenticated user and their accounts, with what is left of them
+func (s *Server) listLimits(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	now := time.Now()
+
+	userLimits, err := s.store.ListUserTransferLimits(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	accountLimits, err := s.store.ListAccountTransferLimits(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listLimitsResponse{
+		User:     make([]userLimitResponse, 0, len(userLimits)),
+		Accounts: make([]accountLimitResponse, 0, len(accountLimits)),
+	}
+	for _, limit := range userLimits {
+		usage, err := newLimitUsage(now, limit.PerTransaction, limit.Daily, limit.Monthly, func(since time.Time) (int64, error) {
+			return s.store.GetOwnerOutgoingAmount(ctx, db.GetOwnerOutgoingAmountParams{
+				Owner:    limit.Username,
+				Currency: limit.Currency,
+				Since:    since,
+			})
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		rsp.User = append(rsp.User, userLimitResponse{Currency: limit.Currency, limitUsage: usage})
+	}
+	for _, limit := range accountLimits {
+		usage, err := newLimitUsage(now, limit.PerTransaction, limit.Daily, limit.Monthly, func(since time.Time) (int64, error) {
+			return s.store.GetAccountOutgoingAmount(ctx, db.GetAccountOutgoingAmountParams{
+				FromAccountID: limit.AccountID,
+				Since:         since,
+			})
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		rsp.Accounts = append(rsp.Accounts, accountLimitResponse{AccountID: limit.AccountID, limitUsage: usage})
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// newLimitUsage adds up what was sent today and this month, with outgoing returning the amount sent since a time
+func newLimitUsage(now time.Time, perTransaction, daily, monthly int64, outgoing func(since time.Time) (int64, error)) (limitUsage, error) {
+	usage := limitUsage{
+		PerTransaction: perTransaction,
+		Daily:          daily,
+		Monthly:        monthly,
+	}
+
+	var err error
+	usage.SentToday, err = outgoing(utils.DayStart(now))
+	if err != nil {
+		return usage, err
+	}
+	usage.SentThisMonth, err = outgoing(utils.MonthStart(now))
+	if err != nil {
+		return usage, err
+	}
+
+	if remaining, limited := utils.RemainingAllowance(daily, usage.SentToday); limited {
+		usage.RemainingToday = &remaining
+	}
+	if remaining, limited := utils.RemainingAllowance(monthly, usage.SentThisMonth); limited {
+		usage.RemainingThisMonth = &remaining
+	}
+	return usage, nil
+}
+
+type setLimitRequest struct {
+	// AccountID sets the limit of an account, Username and Currency the limit of all accounts of a user in a currency
+	AccountID      accountRef `json:"account_id" binding:"omitempty,account_ref"`
+	Username       string     `json:"username" binding:"required_without=AccountID,excluded_with=AccountID,omitempty,alphanum"`
+	Currency       string     `json:"currency" binding:"required_with=Username,excluded_with=AccountID,omitempty,currency"`
+	PerTransaction int64      `json:"per_transaction" binding:"min=0"`
+	Daily          int64      `json:"daily" binding:"min=0"`
+	Monthly        int64      `json:"monthly" binding:"min=0"`
+}
+
+// setLimit sets the transfer limit of a user or an account. Only bankers can set limits,
+// so a stolen token cannot lift them.
+func (s *Server) setLimit(ctx *gin.Context) {
+	var req setLimitRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := s.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if user.Role != utils.BankerRole {
+		err = errors.New("only bankers can set transfer limits")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if req.AccountID == "" {
+		limit, err := s.store.UpsertUserTransferLimit(ctx, db.UpsertUserTransferLimitParams{
+			Username:       req.Username,
+			Currency:       req.Currency,
+			PerTransaction: req.PerTransaction,
+			Daily:          req.Daily,
+			Monthly:        req.Monthly,
+		})
+		if err != nil {
+			if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "foreign_key_violation" {
+				ctx.JSON(http.StatusNotFound, errorResponse(err))
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusOK, limit)
+		return
+	}
+
+	account, err := s.getAccountByRef(ctx, req.AccountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	limit, err := s.store.UpsertAccountTransferLimit(ctx, db.UpsertAccountTransferLimitParams{
+		AccountID:      account.ID,
+		PerTransaction: req.PerTransaction,
+		Daily:          req.Daily,
+		Monthly:        req.Monthly,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, limit)
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListLimitsAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	account := randomAccount(user.Username)
+
+	userLimit := db.UserTransferLimit{
+		Username: user.Username,
+		Currency: utils.USD,
+		Daily:    1000,
+		Monthly:  5000,
+	}
+	accountLimit := db.AccountTransferLimit{
+		AccountID:      account.ID,
+		PerTransaction: 100,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().ListUserTransferLimits(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.UserTransferLimit{userLimit}, nil)
+	store.EXPECT().ListAccountTransferLimits(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.AccountTransferLimit{accountLimit}, nil)
+	// what was sent today is added up before what was sent this month
+	ownerParams := func(since time.Time) db.GetOwnerOutgoingAmountParams {
+		return db.GetOwnerOutgoingAmountParams{Owner: user.Username, Currency: utils.USD, Since: since}
+	}
+	gomock.InOrder(
+		store.EXPECT().GetOwnerOutgoingAmount(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ any, arg db.GetOwnerOutgoingAmountParams) (int64, error) {
+				require.Equal(t, ownerParams(utils.DayStart(arg.Since)), arg)
+				return 400, nil
+			}),
+		store.EXPECT().GetOwnerOutgoingAmount(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ any, arg db.GetOwnerOutgoingAmountParams) (int64, error) {
+				require.Equal(t, ownerParams(utils.MonthStart(arg.Since)), arg)
+				return 6000, nil
+			}),
+	)
+	store.EXPECT().
+		GetAccountOutgoingAmount(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(int64(50), nil)
+
+	server := NewTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/limits", nil)
+	require.NoError(t, err)
+
+	AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got listLimitsResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &got)
+	require.NoError(t, err)
+
+	require.Len(t, got.User, 1)
+	require.Equal(t, utils.USD, got.User[0].Currency)
+	require.Equal(t, int64(400), got.User[0].SentToday)
+	require.Equal(t, int64(600), *got.User[0].RemainingToday)
+	// the monthly limit was lowered below what was already sent
+	require.Equal(t, int64(0), *got.User[0].RemainingThisMonth)
+
+	require.Len(t, got.Accounts, 1)
+	require.Equal(t, account.ID, got.Accounts[0].AccountID)
+	require.Equal(t, int64(100), got.Accounts[0].PerTransaction)
+	require.Equal(t, int64(50), got.Accounts[0].SentToday)
+	require.Nil(t, got.Accounts[0].RemainingToday)
+	require.Nil(t, got.Accounts[0].RemainingThisMonth)
+}
+
+func TestSetLimitAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	banker, _ := RandomUser(t)
+	banker.Role = utils.BankerRole
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "UserLimit",
+			username: banker.Username,
+			body: gin.H{
+				"username": user.Username,
+				"currency": utils.USD,
+				"daily":    1000,
+				"monthly":  5000,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(banker.Username)).Times(1).Return(banker, nil)
+				arg := db.UpsertUserTransferLimitParams{
+					Username: user.Username,
+					Currency: utils.USD,
+					Daily:    1000,
+					Monthly:  5000,
+				}
+				store.EXPECT().
+					UpsertUserTransferLimit(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.UserTransferLimit{Username: user.Username, Currency: utils.USD, Daily: 1000, Monthly: 5000}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "AccountLimit",
+			username: banker.Username,
+			body: gin.H{
+				"account_id":      account.ID,
+				"per_transaction": 100,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(banker.Username)).Times(1).Return(banker, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.UpsertAccountTransferLimitParams{
+					AccountID:      account.ID,
+					PerTransaction: 100,
+				}
+				store.EXPECT().
+					UpsertAccountTransferLimit(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.AccountTransferLimit{AccountID: account.ID, PerTransaction: 100}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "AccountNotFound",
+			username: banker.Username,
+			body: gin.H{
+				"account_id": account.ID,
+				"daily":      100,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(banker.Username)).Times(1).Return(banker, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().UpsertAccountTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "NotBanker",
+			username: user.Username,
+			body: gin.H{
+				"username": user.Username,
+				"currency": utils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpsertUserTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "UserAndAccount",
+			username: banker.Username,
+			body: gin.H{
+				"account_id": account.ID,
+				"username":   user.Username,
+				"currency":   utils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "MissingCurrency",
+			username: banker.Username,
+			body: gin.H{
+				"username": user.Username,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "NeitherUserNorAccount",
+			username: banker.Username,
+			body: gin.H{
+				"daily": 100,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "NegativeLimit",
+			username: banker.Username,
+			body: gin.H{
+				"account_id": account.ID,
+				"daily":      -1,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPut, "/limits", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	mockdb "github.com/SaishNaik/simplebank/db/mock"
 	db "github.com/SaishNaik/simplebank/db/sqlc"
 	"github.com/SaishNaik/simplebank/token"
@@ -13,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 )
@@ -34,6 +36,10 @@ func TestTransferAPI(t *testing.T) {
 	account2.Currency = utils.USD
 	account3.Currency = utils.EUR
 
+	frozenAccount := randomAccount(user2.Username)
+	frozenAccount.Currency = utils.USD
+	frozenAccount.Status = utils.AccountStatusFrozen
+
 	testcases := []struct {
 		name          string
 		body          gin.H
@@ -75,6 +81,174 @@ func TestTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusOK, response.Code)
 			},
 		},
+		{
+			name: "WithDetails",
+			body: gin.H{
+				"from_account_id":    account1.ID,
+				"to_account_id":      account2.ID,
+				"amount":             amount,
+				"currency":           utils.USD,
+				"description":        "rent for march",
+				"external_reference": "INV-2041",
+				"metadata":           gin.H{"invoice": "2041"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, account1.Owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Return(account1, nil).Times(1)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+					Return(account2, nil).Times(1)
+
+				args := db.TransferTxParams{
+					FromAccountId:     account1.ID,
+					ToAccountId:       account2.ID,
+					Amount:            amount,
+					Description:       "rent for march",
+					ExternalReference: "INV-2041",
+					Metadata:          json.RawMessage(`{"invoice":"2041"}`),
+				}
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(args)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, response.Code)
+			},
+		},
+		{
+			name: "MetadataNotObject",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"metadata":        []string{"invoice"},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, account1.Owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, response.Code)
+			},
+		},
+		{
+			name: "DescriptionTooLong",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"description":     utils.RandomString(281),
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, account1.Owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, response.Code)
+			},
+		},
+		{
+			name: "ByRecipient",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"recipient":       user2.Username,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, account1.Owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Return(account1, nil).Times(1)
+
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    user2.Username,
+						Currency: utils.USD,
+					})).
+					Return(account2, nil).Times(1)
+
+				args := db.TransferTxParams{
+					FromAccountId: account1.ID,
+					ToAccountId:   account2.ID,
+					Amount:        amount,
+				}
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(args)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, response.Code)
+			},
+		},
+		{
+			name: "RecipientAndToAccountID",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"recipient":       user2.Username,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, account1.Owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, response.Code)
+			},
+		},
+		{
+			name: "ByAccountNumber",
+			body: gin.H{
+				"from_account_id": account1.AccountNumber,
+				"to_account_id":   account2.AccountNumber,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, account1.Owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByNumber(gomock.Any(), gomock.Eq(account1.AccountNumber)).
+					Return(account1, nil).Times(1)
+
+				store.EXPECT().
+					GetAccountByNumber(gomock.Any(), gomock.Eq(account2.AccountNumber)).
+					Return(account2, nil).Times(1)
+
+				args := db.TransferTxParams{
+					FromAccountId: account1.ID,
+					ToAccountId:   account2.ID,
+					Amount:        amount,
+				}
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(args)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, response.Code)
+			},
+		},
 		{
 			name: "FromAccountNotFound",
 			body: gin.H{
@@ -276,6 +450,86 @@ func TestTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, response.Code)
 			},
 		},
+		{
+			name: "ToAccountFrozen",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   frozenAccount.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, account1.Owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Return(account1, nil).Times(1)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(frozenAccount.ID)).
+					Return(frozenAccount, nil).Times(1)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, response.Code)
+			},
+		},
+		{
+			name: "AccountFrozenConcurrently",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, account1.Owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Return(account1, nil).Times(1)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+					Return(account2, nil).Times(1)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Return(db.TransferTxResult{}, db.ErrAccountNotActive).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, response.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, account1.Owner, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Return(account1, nil).Times(1)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+					Return(account2, nil).Times(1)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Return(db.TransferTxResult{}, db.ErrInsufficientFunds).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, response *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, response.Code)
+			},
+		},
 	}
 
 	for i := range testcases {
@@ -305,3 +559,136 @@ func TestTransferAPI(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchTransfersAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	otherUser, _ := RandomUser(t)
+	account := randomAccount(user.Username)
+	otherAccount := randomAccount(otherUser.Username)
+
+	transfers := []db.Transfer{{
+		ID:                utils.RandomInt(1, 1000),
+		FromAccountID:     account.ID,
+		ToAccountID:       otherAccount.ID,
+		Amount:            utils.RandomInt(1, 1000),
+		Description:       "rent for march",
+		ExternalReference: "INV-2041",
+		Metadata:          json.RawMessage(`{"invoice":"2041"}`),
+		CreatedAt:         time.Now().UTC().Truncate(time.Second),
+	}}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			query: url.Values{
+				"account_id":         {fmt.Sprint(account.ID)},
+				"q":                  {"rent"},
+				"external_reference": {"INV-2041"},
+				"metadata":           {`{"invoice":"2041"}`},
+				"page_id":            {"1"},
+				"page_size":          {"5"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					SearchTransfers(gomock.Any(), gomock.Eq(db.SearchTransfersParams{
+						AccountID:         account.ID,
+						Description:       "rent",
+						ExternalReference: "INV-2041",
+						Metadata:          json.RawMessage(`{"invoice":"2041"}`),
+						Limit:             5,
+						Offset:            0,
+					})).
+					Times(1).
+					Return(transfers, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []db.Transfer
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, transfers, got)
+			},
+		},
+		{
+			name: "NoFilters",
+			query: url.Values{
+				"account_id": {fmt.Sprint(account.ID)},
+				"page_id":    {"2"},
+				"page_size":  {"5"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					SearchTransfers(gomock.Any(), gomock.Eq(db.SearchTransfersParams{
+						AccountID: account.ID,
+						Metadata:  json.RawMessage(`{}`),
+						Limit:     5,
+						Offset:    5,
+					})).
+					Times(1).
+					Return([]db.Transfer{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "AccountOfOtherUser",
+			query: url.Values{
+				"account_id": {fmt.Sprint(otherAccount.ID)},
+				"page_id":    {"1"},
+				"page_size":  {"5"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(otherAccount.ID)).Times(1).Return(otherAccount, nil)
+				store.EXPECT().SearchTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidMetadata",
+			query: url.Values{
+				"account_id": {fmt.Sprint(account.ID)},
+				"metadata":   {`"invoice"`},
+				"page_id":    {"1"},
+				"page_size":  {"5"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().SearchTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/transfers?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
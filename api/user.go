@@ -4,10 +4,14 @@ import (
 	"database/sql"
 	"errors"
 	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/token"
 	"github.com/SaishNaik/simplebank/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -22,8 +26,11 @@ type UserResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	// PendingEmail replaces Email once it is verified
+	PendingEmail string `json:"pending_email,omitempty"`
 }
 
 func newUserResponse(user db.User) UserResponse {
@@ -31,8 +38,10 @@ func newUserResponse(user db.User) UserResponse {
 		Username:          user.Username,
 		FullName:          user.FullName,
 		Email:             user.Email,
+		IsEmailVerified:   user.IsEmailVerified,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
+		PendingEmail:      user.PendingEmail.String,
 	}
 }
 
@@ -42,19 +51,31 @@ func (s *Server) createUser(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
-	hashedPassword, err := utils.HashPassword(req.Password)
+	if !s.checkPasswordPolicy(ctx, req.Password, req.Username, req.Email) {
+		return
+	}
+	hashedPassword, err := s.passwordHasher.Hash(req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	arg := db.CreateUserParams{
-		Username:       req.Username,
-		HashedPassword: hashedPassword,
-		FullName:       req.FullName,
-		Email:          req.Email,
+	secretCode, err := utils.GenerateSecureToken(verifyEmailCodeSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.Username,
+			HashedPassword: hashedPassword,
+			FullName:       req.FullName,
+			Email:          req.Email,
+		},
+		VerifyEmailCodeHash:  utils.HashToken(secretCode),
+		VerifyEmailExpiredAt: time.Now().Add(s.config.EmailVerificationDuration),
 	}
 
-	user, err := s.store.CreateUser(ctx, arg)
+	result, err := s.store.CreateUserTx(ctx, arg)
 	if err != nil {
 		if pgError, ok := err.(*pq.Error); ok {
 			switch pgError.Code.Name() {
@@ -66,10 +87,119 @@ func (s *Server) createUser(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	resp := newUserResponse(user)
+	// the email is sent once the user is committed, a failure leaves the user created without a verified email
+	if err := s.sendVerifyEmail(ctx, result.User, result.VerifyEmail, secretCode); err != nil {
+		slog.ErrorContext(ctx, "cannot send verification email", "username", result.User.Username, "error", err)
+	}
+	resp := newUserResponse(result.User)
 	ctx.JSON(http.StatusOK, resp)
 }
 
+func (s *Server) getCurrentUser(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := s.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// updateUserRequest holds the fields to change, the ones left out keep their value
+type updateUserRequest struct {
+	FullName *string `json:"full_name" binding:"omitempty,min=1"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	// CurrentPassword is required to change the email, the new one is used for password resets once verified
+	CurrentPassword string `json:"current_password"`
+}
+
+func (s *Server) updateCurrentUser(ctx *gin.Context) {
+	var req updateUserRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.FullName == nil && req.Email == nil {
+		err := errors.New("at least one of full_name or email must be given")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := s.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{
+			Username: user.Username,
+		},
+	}
+	if req.FullName != nil {
+		arg.FullName = sql.NullString{String: *req.FullName, Valid: true}
+	}
+	var secretCode string
+	// setting the current email again keeps it verified
+	if req.Email != nil && *req.Email != user.Email {
+		if err := utils.CheckPassword(req.CurrentPassword, user.HashedPassword); err != nil {
+			err = errors.New("invalid current password")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		if !s.checkEmailAvailable(ctx, *req.Email) {
+			return
+		}
+		secretCode, err = utils.GenerateSecureToken(verifyEmailCodeSize)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		arg.PendingEmail = sql.NullString{String: *req.Email, Valid: true}
+		arg.VerifyEmailCodeHash = utils.HashToken(secretCode)
+		arg.VerifyEmailExpiredAt = time.Now().Add(s.config.EmailVerificationDuration)
+	}
+
+	result, err := s.store.UpdateUserTx(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	// the email is sent once the pending email is committed, asking for the change again sends a new code
+	if arg.PendingEmail.Valid {
+		if err := s.sendVerifyEmail(ctx, result.User, result.VerifyEmail, secretCode); err != nil {
+			slog.ErrorContext(ctx, "cannot send verification email", "username", result.User.Username, "error", err)
+		}
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(result.User))
+}
+
+var errEmailInUse = errors.New("email is already in use")
+
+// checkEmailAvailable responds and returns false if the email belongs to a user.
+// It is checked again when the email is verified, in case another user took it in between.
+func (s *Server) checkEmailAvailable(ctx *gin.Context, email string) bool {
+	_, err := s.store.GetUserByEmail(ctx, email)
+	if err == nil {
+		ctx.JSON(http.StatusConflict, errorResponse(errEmailInUse))
+		return false
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	return true
+}
+
 type loginUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
 	Password string `json:"password" binding:"required,min=6"`
@@ -86,21 +216,68 @@ func (s *Server) loginUser(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+
+	if !s.checkLoginLockout(ctx, req.Username) {
+		return
+	}
+
 	user, err := s.store.GetUser(ctx, req.Username)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		if !errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		// spend the same time as for a wrong password so that usernames cannot be enumerated
+		if hashedPassword, err := s.dummyHashedPassword(); err == nil {
+			_ = utils.CheckPassword(req.Password, hashedPassword)
+		}
+		s.rejectLogin(ctx, req.Username, errInvalidCredentials)
+		return
 	}
 	err = utils.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		s.rejectLogin(ctx, req.Username, errInvalidCredentials)
 		return
 	}
+	if s.passwordHasher.NeedsRehash(user.HashedPassword) {
+		user = s.rehashPassword(ctx, user, req.Password)
+	}
 
-	accessToken, err := s.tokenMaker.CreateToken(user.Username, s.config.AccessTokenDuration)
+	mfaEnabled, err := s.mfaEnabled(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if mfaEnabled {
+		payload, err := token.NewPayload(user.Username, s.config.MFAChallengeDuration)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		payload.TokenType = token.TokenTypeMFAChallenge
+
+		mfaToken, err := s.tokenMaker.CreateTokenFromPayload(payload)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusOK, mfaChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		})
+		return
+	}
+
+	s.loginSucceeded(ctx, user, []string{token.AMRPassword})
+}
+
+// loginSucceeded records the successful login and responds with a new access token carrying the methods used
+func (s *Server) loginSucceeded(ctx *gin.Context, user db.User, amr []string) {
+	if !s.recordLoginAttempt(ctx, user.Username, true) {
+		return
+	}
+
+	accessToken, _, err := s.createAccessToken(user.Username, s.config.AccessTokenDuration, amr)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -111,3 +288,120 @@ func (s *Server) loginUser(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, resp)
 }
+
+// createAccessToken issues an access token for a user who has just authenticated with the given methods
+func (s *Server) createAccessToken(username string, duration time.Duration, amr []string) (string, *token.Payload, error) {
+	payload, err := token.NewPayload(username, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	payload.AMR = amr
+
+	accessToken, err := s.tokenMaker.CreateTokenFromPayload(payload)
+	if err != nil {
+		return "", nil, err
+	}
+	return accessToken, payload, nil
+}
+
+// errInvalidCredentials is returned for both unknown usernames and wrong passwords
+var errInvalidCredentials = errors.New("invalid username or password")
+
+// rehashPassword replaces a hash made with an outdated algorithm or parameters, the password itself does not
+// change so password_changed_at is kept and existing tokens stay valid. Failures only keep the old hash.
+func (s *Server) rehashPassword(ctx *gin.Context, user db.User, password string) db.User {
+	hashedPassword, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		slog.WarnContext(ctx, "cannot rehash password", "username", user.Username, "error", err)
+		return user
+	}
+	updatedUser, err := s.store.UpdateUser(ctx, db.UpdateUserParams{
+		HashedPassword: sql.NullString{String: hashedPassword, Valid: true},
+		Username:       user.Username,
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "cannot rehash password", "username", user.Username, "error", err)
+		return user
+	}
+	return updatedUser
+}
+
+func (s *Server) rejectLogin(ctx *gin.Context, username string, err error) {
+	if s.recordLoginAttempt(ctx, username, false) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	}
+}
+
+// recordLoginAttempt adds the attempt to the audit table, it responds with an error and returns false if it cannot
+func (s *Server) recordLoginAttempt(ctx *gin.Context, username string, succeeded bool) bool {
+	_, err := s.store.CreateLoginAttempt(ctx, db.CreateLoginAttemptParams{
+		Username:  username,
+		ClientIp:  ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Succeeded: succeeded,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// checkLoginLockout responds with an error and returns false if logins for the username are locked out
+func (s *Server) checkLoginLockout(ctx *gin.Context, username string) bool {
+	retryAfter, err := s.loginLockout(ctx, username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if retryAfter > 0 {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		err = errors.New("too many failed login attempts, please retry later")
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// loginLockout returns how long logins for the username are still refused because of previous failures
+func (s *Server) loginLockout(ctx *gin.Context, username string) (time.Duration, error) {
+	if s.config.LoginMaxFailedAttempts <= 0 {
+		return 0, nil
+	}
+
+	var since time.Time
+	if s.config.LoginFailureWindow > 0 {
+		since = time.Now().Add(-s.config.LoginFailureWindow)
+	}
+	failures, err := s.store.GetLoginFailures(ctx, db.GetLoginFailuresParams{
+		Username: username,
+		Since:    since,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	lockout := lockoutDuration(failures.FailedAttempts, s.config.LoginMaxFailedAttempts, s.config.LoginLockoutDuration, s.config.LoginMaxLockoutDuration)
+	return time.Until(failures.LastFailedAt.Add(lockout)), nil
+}
+
+// defaultMaxLockout caps the lockout when LoginMaxLockoutDuration is not set,
+// so doubling it never overflows into a negative duration
+const defaultMaxLockout = 24 * time.Hour
+
+// lockoutDuration doubles the lockout for every failure past maxFailures, up to maxLockout
+func lockoutDuration(failures int64, maxFailures int, lockout, maxLockout time.Duration) time.Duration {
+	if failures < int64(maxFailures) {
+		return 0
+	}
+	if maxLockout <= 0 {
+		maxLockout = defaultMaxLockout
+	}
+	for i := int64(maxFailures); i < failures && lockout < maxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > maxLockout {
+		return maxLockout
+	}
+	return lockout
+}
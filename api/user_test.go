@@ -2,19 +2,26 @@ package api
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	mockdb "github.com/SaishNaik/simplebank/db/mock"
 	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/mail"
+	"github.com/SaishNaik/simplebank/token"
 	"github.com/SaishNaik/simplebank/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 type eqMatcher struct {
@@ -23,34 +30,43 @@ type eqMatcher struct {
 }
 
 func (e eqMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(db.CreateUserParams)
+	txArg, ok := x.(db.CreateUserTxParams)
 	if !ok {
 		return false
 	}
+	arg := txArg.CreateUserParams
 
 	err := utils.CheckPassword(e.password, arg.HashedPassword)
 	if err != nil {
 		return false
 	}
 	e.arg.HashedPassword = arg.HashedPassword
-	return reflect.DeepEqual(e.arg, arg)
+	return reflect.DeepEqual(e.arg, arg) && txArg.VerifyEmailCodeHash != ""
 }
 
 func (e eqMatcher) String() string {
 	return fmt.Sprintf("matches arg %v and password %s", e.arg, e.password)
 }
 
-func EqCreateUserParams(arg db.CreateUserParams, password string) gomock.Matcher {
+func EqCreateUserTxParams(arg db.CreateUserParams, password string) gomock.Matcher {
 	return eqMatcher{arg, password}
 }
 
 func TestCreateUserApi(t *testing.T) {
 	user, password := RandomUser(t)
+	verifyEmail := db.VerifyEmail{
+		ID:        utils.RandomInt(1, 1000),
+		Username:  user.Username,
+		Email:     user.Email,
+		ExpiredAt: time.Now().Add(time.Hour),
+	}
+
 	testCases := []struct {
 		name          string
 		body          gin.H
+		policy        utils.PasswordPolicy
 		buildStubs    func(store *mockdb.MockStore)
-		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer)
 	}{
 		{
 			name: "OK",
@@ -67,12 +83,99 @@ func TestCreateUserApi(t *testing.T) {
 					Email:    user.Email,
 				}
 				store.EXPECT().
-					CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
-					Return(user, nil).Times(1)
+					CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password)).
+					DoAndReturn(func(_ any, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						verifyEmail := verifyEmail
+						verifyEmail.SecretCodeHash = arg.VerifyEmailCodeHash
+						return db.CreateUserTxResult{User: user, VerifyEmail: verifyEmail}, nil
+					}).
+					Times(1)
 			},
-			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 				requireBodyMatchUser(t, recorder.Body, user)
+
+				sent := mailer.Sent()
+				require.Len(t, sent, 1)
+				require.Equal(t, []string{user.Email}, sent[0].To)
+				require.Contains(t, sent[0].Body, fmt.Sprintf("/users/verify_email?email_id=%d&secret_code=", verifyEmail.ID))
+			},
+		},
+		{
+			name: "DuplicateUsername",
+			body: gin.H{
+				"username":  user.Username,
+				"email":     user.Email,
+				"full_name": user.FullName,
+				"password":  password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Return(db.CreateUserTxResult{}, &pq.Error{Code: "23505"}).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Empty(t, mailer.Sent())
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"username":  user.Username,
+				"email":     user.Email,
+				"full_name": user.FullName,
+				"password":  password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Return(db.CreateUserTxResult{}, sql.ErrConnDone).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				// nothing is sent for a user that was not created
+				require.Empty(t, mailer.Sent())
+			},
+		},
+		{
+			name: "WeakPassword",
+			body: gin.H{
+				"username":  user.Username,
+				"email":     user.Email,
+				"full_name": user.FullName,
+				"password":  "abcdefgh",
+			},
+			policy: utils.PasswordPolicy{MinLength: 8, MinCharClasses: 2},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, &utils.PasswordPolicyError{Violations: []string{
+					"password must contain at least 2 of lowercase letters, uppercase letters, digits and symbols",
+				}})
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{
+				"username":  user.Username,
+				"email":     "invalid-email",
+				"full_name": user.FullName,
+				"password":  password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 	}
@@ -87,6 +190,7 @@ func TestCreateUserApi(t *testing.T) {
 			tc.buildStubs(store)
 
 			server := NewTestServer(t, store)
+			server.passwordPolicy = tc.policy
 			recorder := httptest.NewRecorder()
 
 			data, err := json.Marshal(tc.body)
@@ -96,10 +200,650 @@ func TestCreateUserApi(t *testing.T) {
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 			server.router.ServeHTTP(recorder, request)
 
+			tc.checkResponse(t, recorder, server.mailer.(*mail.MemoryMailer))
+		})
+	}
+
+}
+
+func TestGetCurrentUserAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				AddAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(db.User{}, sql.ErrNoRows).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/users/me"
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
 	}
+}
+
+func TestUpdateCurrentUserAPI(t *testing.T) {
+	user, password := RandomUser(t)
+	user.IsEmailVerified = true
+	newFullName := utils.RandomOwner()
+	newEmail := utils.RandomEmail()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer)
+	}{
+		{
+			name: "FullName",
+			body: gin.H{"full_name": newFullName},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, db.UpdateUserParams{
+							FullName: sql.NullString{String: newFullName, Valid: true},
+							Username: user.Username,
+						}, arg.UpdateUserParams)
+						require.False(t, arg.PendingEmail.Valid)
+
+						updated := user
+						updated.FullName = newFullName
+						return db.UpdateUserTxResult{User: updated}, nil
+					}).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var resp UserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				require.Equal(t, newFullName, resp.FullName)
+				require.True(t, resp.IsEmailVerified)
+				require.Empty(t, mailer.Sent())
+			},
+		},
+		{
+			name: "Email",
+			body: gin.H{"email": newEmail, "current_password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(newEmail)).
+					Return(db.User{}, sql.ErrNoRows).Times(1)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, db.UpdateUserParams{Username: user.Username}, arg.UpdateUserParams)
+						require.Equal(t, sql.NullString{String: newEmail, Valid: true}, arg.PendingEmail)
+						require.NotEmpty(t, arg.VerifyEmailCodeHash)
+
+						updated := user
+						updated.PendingEmail = arg.PendingEmail
+						verifyEmail := db.VerifyEmail{ID: 1, Username: user.Username, Email: newEmail}
+						return db.UpdateUserTxResult{User: updated, VerifyEmail: verifyEmail}, nil
+					}).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				// the current email stays in use until the new one is verified
+				var resp UserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				require.Equal(t, user.Email, resp.Email)
+				require.True(t, resp.IsEmailVerified)
+				require.Equal(t, newEmail, resp.PendingEmail)
+
+				sent := mailer.Sent()
+				require.Len(t, sent, 1)
+				require.Equal(t, []string{newEmail}, sent[0].To)
+			},
+		},
+		{
+			name: "EmailWrongPassword",
+			body: gin.H{"email": newEmail, "current_password": "wrong-password"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Empty(t, mailer.Sent())
+			},
+		},
+		{
+			name: "EmailWithoutPassword",
+			body: gin.H{"email": newEmail},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "SameEmail",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.False(t, arg.PendingEmail.Valid)
+						return db.UpdateUserTxResult{User: user}, nil
+					}).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, mailer.Sent())
+			},
+		},
+		{
+			name: "EmailInUse",
+			body: gin.H{"email": newEmail, "current_password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(newEmail)).
+					Return(db.User{Email: newEmail}, nil).Times(1)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"full_name": newFullName},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Return(db.UpdateUserTxResult{}, sql.ErrConnDone).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "NoFields",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{"email": "invalid-email"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "EmptyFullName",
+			body: gin.H{"full_name": ""},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
 
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/me"
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			AddAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server.mailer.(*mail.MemoryMailer))
+		})
+	}
+}
+
+func TestLoginUserAPI(t *testing.T) {
+	user, password := RandomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(db.UserTotp{}, sql.ErrNoRows).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, true)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "RehashOutdatedHash",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				bcryptHash, err := utils.BcryptHasher{Cost: bcrypt.MinCost}.Hash(password)
+				require.NoError(t, err)
+				bcryptUser := user
+				bcryptUser.HashedPassword = bcryptHash
+
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(bcryptUser, nil).Times(1)
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, arg db.UpdateUserParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.True(t, strings.HasPrefix(arg.HashedPassword.String, "$argon2id$"))
+						require.NoError(t, utils.CheckPassword(password, arg.HashedPassword.String))
+						// the password did not change, so existing tokens must stay valid
+						require.False(t, arg.PasswordChangedAt.Valid)
+
+						updatedUser := bcryptUser
+						updatedUser.HashedPassword = arg.HashedPassword.String
+						return updatedUser, nil
+					}).
+					Times(1)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(db.UserTotp{}, sql.ErrNoRows).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, true)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "RehashFails",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				bcryptHash, err := utils.BcryptHasher{Cost: bcrypt.MinCost}.Hash(password)
+				require.NoError(t, err)
+				bcryptUser := user
+				bcryptUser.HashedPassword = bcryptHash
+
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(bcryptUser, nil).Times(1)
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Return(db.User{}, sql.ErrConnDone).Times(1)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(db.UserTotp{}, sql.ErrNoRows).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, true)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "MFARequired",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(db.UserTotp{
+						Username:    user.Username,
+						ConfirmedAt: sql.NullTime{Time: time.Now(), Valid: true},
+					}, nil).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var resp mfaChallengeResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				require.True(t, resp.MFARequired)
+				require.NotEmpty(t, resp.MFAToken)
+				require.NotContains(t, recorder.Body.String(), "access_token")
+			},
+		},
+		{
+			name: "UserNotFound",
+			body: gin.H{
+				"username": "notfound",
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq("notfound")).
+					Return(db.User{}, sql.ErrNoRows).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt("notfound", false)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidCredentials)
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, false)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidCredentials)
+			},
+		},
+		{
+			name: "LockedOut",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{FailedAttempts: 3, LastFailedAt: time.Now()}, nil).
+					Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name: "LockoutExpired",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{FailedAttempts: 3, LastFailedAt: time.Now().Add(-2 * time.Minute)}, nil).
+					Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Return(user, nil).Times(1)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Return(db.UserTotp{}, sql.ErrNoRows).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), EqLoginAttempt(user.Username, true)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Return(db.GetLoginFailuresRow{}, nil).Times(1)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Return(db.User{}, sql.ErrConnDone).Times(1)
+				store.EXPECT().
+					CreateLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidUsername",
+			body: gin.H{
+				"username": "invalid-user#1",
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			server.config.LoginMaxFailedAttempts = 3
+			server.config.LoginLockoutDuration = time.Minute
+			server.config.LoginMaxLockoutDuration = time.Hour
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/login"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	require.Zero(t, lockoutDuration(2, 3, time.Minute, time.Hour))
+	require.Equal(t, time.Minute, lockoutDuration(3, 3, time.Minute, time.Hour))
+	require.Equal(t, 2*time.Minute, lockoutDuration(4, 3, time.Minute, time.Hour))
+	require.Equal(t, 32*time.Minute, lockoutDuration(8, 3, time.Minute, time.Hour))
+	require.Equal(t, time.Hour, lockoutDuration(9, 3, time.Minute, time.Hour))
+	require.Equal(t, time.Hour, lockoutDuration(1000, 3, time.Minute, time.Hour))
+	// without a maximum the lockout is capped instead of overflowing
+	require.Equal(t, defaultMaxLockout, lockoutDuration(40, 3, time.Minute, 0))
+	require.Equal(t, defaultMaxLockout, lockoutDuration(1000, 3, time.Minute, 0))
+}
+
+type loginAttemptMatcher struct {
+	username  string
+	succeeded bool
+}
+
+func (e loginAttemptMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateLoginAttemptParams)
+	if !ok {
+		return false
+	}
+	return arg.Username == e.username && arg.Succeeded == e.succeeded
+}
+
+func (e loginAttemptMatcher) String() string {
+	return fmt.Sprintf("matches login attempt of %s succeeded %t", e.username, e.succeeded)
+}
+
+func EqLoginAttempt(username string, succeeded bool) gomock.Matcher {
+	return loginAttemptMatcher{username, succeeded}
 }
 
 func RandomUser(t *testing.T) (db.User, string) {
@@ -127,3 +871,13 @@ func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	require.Empty(t, gotUser.HashedPassword)
 
 }
+
+func requireBodyMatchError(t *testing.T, body *bytes.Buffer, expected error) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var got gin.H
+	err = json.Unmarshal(data, &got)
+	require.NoError(t, err)
+	require.Equal(t, expected.Error(), got["error"])
+}
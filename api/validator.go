@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"github.com/SaishNaik/simplebank/utils"
 	"github.com/go-playground/validator/v10"
 )
@@ -11,3 +12,41 @@ var validCurrency validator.Func = func(fl validator.FieldLevel) bool {
 	}
 	return false
 }
+
+var validAccountStatus validator.Func = func(fl validator.FieldLevel) bool {
+	if status, ok := fl.Field().Interface().(string); ok {
+		return utils.IsSupportedAccountStatus(status)
+	}
+	return false
+}
+
+var validAccountType validator.Func = func(fl validator.FieldLevel) bool {
+	if accountType, ok := fl.Field().Interface().(string); ok {
+		return utils.IsSupportedAccountType(accountType)
+	}
+	return false
+}
+
+// validJSONObject accepts a json object given as raw json or as a string, such as a query parameter
+var validJSONObject validator.Func = func(fl validator.FieldLevel) bool {
+	var data []byte
+	switch value := fl.Field().Interface().(type) {
+	case json.RawMessage:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		return false
+	}
+	var object map[string]any
+	return json.Unmarshal(data, &object) == nil && object != nil
+}
+
+// validAccountRef accepts an account id or an account number with valid check digits
+var validAccountRef validator.Func = func(fl validator.FieldLevel) bool {
+	if ref, ok := fl.Field().Interface().(accountRef); ok {
+		_, isID := ref.accountID()
+		return isID || utils.IsValidAccountNumber(string(ref))
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/mail"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// verifyEmailCodeSize is the number of random bytes in the secret code of a verification link
+const verifyEmailCodeSize = 32
+
+var errInvalidVerifyEmailCode = errors.New("invalid or expired verification link")
+
+// sendVerifyEmail emails the user a link to the verify email endpoint
+func (s *Server) sendVerifyEmail(ctx *gin.Context, user db.User, verifyEmail db.VerifyEmail, secretCode string) error {
+	query := url.Values{}
+	query.Set("email_id", strconv.FormatInt(verifyEmail.ID, 10))
+	query.Set("secret_code", secretCode)
+	link := s.config.EmailVerificationURL + "?" + query.Encode()
+
+	body := fmt.Sprintf("Hello %s,\n\n"+
+		"Please open the link below to verify your email address:\n\n"+
+		"%s\n\n"+
+		"The link expires at %s.\n",
+		user.FullName, link, verifyEmail.ExpiredAt.UTC().Format("2006-01-02 15:04 MST"))
+
+	err := s.mailer.SendEmail(ctx, mail.Email{
+		To:      []string{verifyEmail.Email},
+		Subject: "Please verify your SimpleBank email",
+		Body:    body,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot send verification email: %w", err)
+	}
+	return nil
+}
+
+type verifyEmailRequest struct {
+	EmailID    int64  `form:"email_id" binding:"required,min=1"`
+	SecretCode string `form:"secret_code" binding:"required,max=64"`
+}
+
+type verifyEmailResponse struct {
+	IsVerified bool `json:"is_verified"`
+}
+
+func (s *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := s.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		EmailID:        req.EmailID,
+		SecretCodeHash: utils.HashToken(req.SecretCode),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, db.ErrEmailChanged) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidVerifyEmailCode))
+			return
+		}
+		if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusConflict, errorResponse(errEmailInUse))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, verifyEmailResponse{IsVerified: result.User.IsEmailVerified})
+}
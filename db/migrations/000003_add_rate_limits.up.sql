@@ -0,0 +1,10 @@
+CREATE TABLE "rate_limit_buckets" (
+                                      "key" varchar PRIMARY KEY,
+                                      "tokens" double precision NOT NULL,
+                                      "allowed" boolean NOT NULL,
+                                      "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "rate_limit_buckets" ("updated_at");
+
+COMMENT ON COLUMN "rate_limit_buckets"."allowed" IS 'whether the last request taken from the bucket was allowed';
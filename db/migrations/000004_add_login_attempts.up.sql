@@ -0,0 +1,12 @@
+CREATE TABLE "login_attempts" (
+                                  "id" bigserial PRIMARY KEY,
+                                  "username" varchar NOT NULL,
+                                  "client_ip" varchar NOT NULL,
+                                  "user_agent" varchar NOT NULL,
+                                  "succeeded" boolean NOT NULL,
+                                  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "login_attempts" ("username", "created_at");
+
+COMMENT ON COLUMN "login_attempts"."username" IS 'not a foreign key, attempts for unknown usernames are recorded too';
@@ -0,0 +1,25 @@
+CREATE TABLE "user_totp" (
+                             "username" varchar PRIMARY KEY,
+                             "encrypted_secret" varchar NOT NULL,
+                             "confirmed_at" timestamptz,
+                             "last_used_step" bigint NOT NULL DEFAULT 0,
+                             "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "mfa_recovery_codes" (
+                                      "id" bigserial PRIMARY KEY,
+                                      "username" varchar NOT NULL,
+                                      "code_hash" varchar NOT NULL,
+                                      "used_at" timestamptz,
+                                      "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "mfa_recovery_codes" ("username", "code_hash");
+
+COMMENT ON COLUMN "user_totp"."confirmed_at" IS 'null until the first code has been verified';
+
+COMMENT ON COLUMN "user_totp"."last_used_step" IS 'time step of the last accepted code, older codes are refused';
+
+ALTER TABLE "user_totp" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "mfa_recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
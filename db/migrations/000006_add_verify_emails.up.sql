@@ -0,0 +1,19 @@
+ALTER TABLE "users" ADD COLUMN "is_email_verified" bool NOT NULL DEFAULT false;
+
+CREATE TABLE "verify_emails" (
+                                 "id" bigserial PRIMARY KEY,
+                                 "username" varchar NOT NULL,
+                                 "email" varchar NOT NULL,
+                                 "secret_code_hash" varchar NOT NULL,
+                                 "is_used" bool NOT NULL DEFAULT false,
+                                 "created_at" timestamptz NOT NULL DEFAULT (now()),
+                                 "expired_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "verify_emails" ("username");
+
+COMMENT ON COLUMN "verify_emails"."email" IS 'address the code was sent to, it only verifies the user while it is still their email';
+
+COMMENT ON COLUMN "verify_emails"."secret_code_hash" IS 'sha256 of the secret code sent in the verification link';
+
+ALTER TABLE "verify_emails" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
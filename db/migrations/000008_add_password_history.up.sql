@@ -0,0 +1,12 @@
+CREATE TABLE "password_history" (
+                                    "id" bigserial PRIMARY KEY,
+                                    "username" varchar NOT NULL,
+                                    "hashed_password" varchar NOT NULL,
+                                    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "password_history" ("username", "created_at");
+
+COMMENT ON TABLE "password_history" IS 'previous password hashes of each user, used to refuse reusing them';
+
+ALTER TABLE "password_history" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
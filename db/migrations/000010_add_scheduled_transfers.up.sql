@@ -0,0 +1,45 @@
+CREATE TABLE "scheduled_transfers" (
+                                       "id" bigserial PRIMARY KEY,
+                                       "owner" varchar NOT NULL,
+                                       "from_account_id" bigint NOT NULL,
+                                       "to_account_id" bigint NOT NULL,
+                                       "amount" bigint NOT NULL,
+                                       "currency" varchar NOT NULL,
+                                       "execute_at" timestamptz NOT NULL,
+                                       "status" varchar NOT NULL DEFAULT 'pending',
+                                       "transfer_id" bigint,
+                                       "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "scheduled_transfer_attempts" (
+                                               "id" bigserial PRIMARY KEY,
+                                               "scheduled_transfer_id" bigint NOT NULL,
+                                               "succeeded" bool NOT NULL,
+                                               "failure_reason" varchar NOT NULL DEFAULT '',
+                                               "transfer_id" bigint,
+                                               "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "scheduled_transfers" ("owner");
+
+CREATE INDEX ON "scheduled_transfers" ("status", "execute_at");
+
+CREATE INDEX ON "scheduled_transfer_attempts" ("scheduled_transfer_id");
+
+COMMENT ON COLUMN "scheduled_transfers"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "scheduled_transfers"."status" IS 'pending, succeeded, failed or cancelled';
+
+COMMENT ON COLUMN "scheduled_transfers"."transfer_id" IS 'set once the transfer has been executed';
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "scheduled_transfer_attempts" ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id");
+
+ALTER TABLE "scheduled_transfer_attempts" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,63 @@
+CREATE TABLE "standing_orders" (
+                                   "id" bigserial PRIMARY KEY,
+                                   "owner" varchar NOT NULL,
+                                   "from_account_id" bigint NOT NULL,
+                                   "to_account_id" bigint NOT NULL,
+                                   "amount" bigint NOT NULL,
+                                   "currency" varchar NOT NULL,
+                                   "frequency" varchar NOT NULL,
+                                   "day_of_month" int NOT NULL DEFAULT 0,
+                                   "start_at" timestamptz NOT NULL,
+                                   "end_at" timestamptz,
+                                   "max_occurrences" int,
+                                   "occurrences" int NOT NULL DEFAULT 0,
+                                   "next_occurrence_at" timestamptz NOT NULL,
+                                   "next_run_at" timestamptz NOT NULL,
+                                   "retries" int NOT NULL DEFAULT 0,
+                                   "status" varchar NOT NULL DEFAULT 'active',
+                                   "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "standing_order_executions" (
+                                             "id" bigserial PRIMARY KEY,
+                                             "standing_order_id" bigint NOT NULL,
+                                             "occurrence_at" timestamptz NOT NULL,
+                                             "succeeded" bool NOT NULL,
+                                             "failure_reason" varchar NOT NULL DEFAULT '',
+                                             "transfer_id" bigint,
+                                             "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "standing_orders" ("owner");
+
+CREATE INDEX ON "standing_orders" ("status", "next_run_at");
+
+CREATE INDEX ON "standing_order_executions" ("standing_order_id");
+
+COMMENT ON COLUMN "standing_orders"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "standing_orders"."frequency" IS 'daily, weekly or monthly';
+
+COMMENT ON COLUMN "standing_orders"."day_of_month" IS 'day monthly orders are paid on, zero for other frequencies';
+
+COMMENT ON COLUMN "standing_orders"."max_occurrences" IS 'null when the order only ends at end_at or when cancelled';
+
+COMMENT ON COLUMN "standing_orders"."occurrences" IS 'number of past occurrences, paid or failed';
+
+COMMENT ON COLUMN "standing_orders"."next_run_at" IS 'next execution attempt, later than next_occurrence_at while retrying';
+
+COMMENT ON COLUMN "standing_orders"."retries" IS 'failed attempts of the next occurrence';
+
+COMMENT ON COLUMN "standing_orders"."status" IS 'active, completed or cancelled';
+
+COMMENT ON COLUMN "standing_order_executions"."occurrence_at" IS 'the occurrence the execution attempt was for';
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "standing_order_executions" ADD FOREIGN KEY ("standing_order_id") REFERENCES "standing_orders" ("id");
+
+ALTER TABLE "standing_order_executions" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
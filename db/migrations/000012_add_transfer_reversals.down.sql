@@ -0,0 +1,3 @@
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "reverses_transfer_id";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "role";
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
+
+ALTER TABLE "transfers" ADD COLUMN "reverses_transfer_id" bigint;
+
+CREATE INDEX ON "transfers" ("reverses_transfer_id");
+
+COMMENT ON COLUMN "users"."role" IS 'depositor or banker';
+
+COMMENT ON COLUMN "transfers"."reverses_transfer_id" IS 'set on reversals, the transfer they fully or partially refund';
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reverses_transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,5 @@
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "metadata";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "external_reference";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "description";
\ No newline at end of file
@@ -0,0 +1,16 @@
+ALTER TABLE "transfers" ADD COLUMN "description" varchar(280) NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "external_reference" varchar(64) NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}'
+    CHECK (jsonb_typeof("metadata") = 'object' AND octet_length("metadata"::text) <= 4096);
+
+CREATE INDEX ON "transfers" ("external_reference");
+
+CREATE INDEX ON "transfers" USING GIN ("metadata" jsonb_path_ops);
+
+COMMENT ON COLUMN "transfers"."description" IS 'free text memo of what the transfer was for';
+
+COMMENT ON COLUMN "transfers"."external_reference" IS 'reference of the payment in an external system, used for reconciliation';
+
+COMMENT ON COLUMN "transfers"."metadata" IS 'json object of client defined key values, searchable by containment';
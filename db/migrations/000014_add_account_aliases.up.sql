@@ -0,0 +1,5 @@
+ALTER TABLE "accounts" ADD COLUMN "alias" varchar(32);
+
+CREATE UNIQUE INDEX ON "accounts" ("alias");
+
+COMMENT ON COLUMN "accounts"."alias" IS 'optional name senders can pay the account by instead of its id';
@@ -0,0 +1,16 @@
+ALTER TABLE "accounts" ADD COLUMN "account_number" varchar(16);
+
+-- existing accounts get a random 12 digit basic account number behind SB and mod-97 check digits, S and B being 28 and 11
+UPDATE "accounts"
+SET "account_number" = 'SB' || lpad((98 - (("generated"."bban" || '281100')::numeric % 97))::text, 2, '0') || "generated"."bban"
+FROM (
+    SELECT "id", lpad(floor(random() * 1000000000000)::bigint::text, 12, '0') AS "bban"
+    FROM "accounts"
+) AS "generated"
+WHERE "accounts"."id" = "generated"."id";
+
+ALTER TABLE "accounts" ALTER COLUMN "account_number" SET NOT NULL;
+
+CREATE UNIQUE INDEX ON "accounts" ("account_number");
+
+COMMENT ON COLUMN "accounts"."account_number" IS 'IBAN style external account number with mod-97 check digits';
@@ -0,0 +1,41 @@
+ALTER TABLE "accounts" ADD COLUMN "held_balance" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD COLUMN "available_balance" bigint NOT NULL GENERATED ALWAYS AS ("balance" - "held_balance") STORED;
+
+CREATE TABLE "holds" (
+                         "id" bigserial PRIMARY KEY,
+                         "owner" varchar NOT NULL,
+                         "from_account_id" bigint NOT NULL,
+                         "to_account_id" bigint NOT NULL,
+                         "amount" bigint NOT NULL,
+                         "currency" varchar NOT NULL,
+                         "status" varchar NOT NULL DEFAULT 'authorized',
+                         "captured_amount" bigint NOT NULL DEFAULT 0,
+                         "transfer_id" bigint,
+                         "expires_at" timestamptz NOT NULL,
+                         "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "holds" ("owner");
+
+CREATE INDEX ON "holds" ("status", "expires_at");
+
+COMMENT ON COLUMN "accounts"."held_balance" IS 'sum of the authorized holds on the account';
+
+COMMENT ON COLUMN "accounts"."available_balance" IS 'balance minus held_balance, what the account can still spend';
+
+COMMENT ON COLUMN "holds"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "holds"."status" IS 'authorized, captured, voided or expired';
+
+COMMENT ON COLUMN "holds"."captured_amount" IS 'amount moved on capture, the rest of the hold is released';
+
+COMMENT ON COLUMN "holds"."transfer_id" IS 'set once the hold has been captured';
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
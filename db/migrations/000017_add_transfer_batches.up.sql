@@ -0,0 +1,49 @@
+CREATE TABLE "transfer_batches" (
+                                    "id" bigserial PRIMARY KEY,
+                                    "owner" varchar NOT NULL,
+                                    "from_account_id" bigint NOT NULL,
+                                    "currency" varchar NOT NULL,
+                                    "atomic" bool NOT NULL,
+                                    "total_amount" bigint NOT NULL,
+                                    "status" varchar NOT NULL DEFAULT 'pending',
+                                    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "transfer_batch_items" (
+                                        "id" bigserial PRIMARY KEY,
+                                        "batch_id" bigint NOT NULL,
+                                        "to_account_id" bigint NOT NULL,
+                                        "amount" bigint NOT NULL,
+                                        "description" varchar NOT NULL DEFAULT '',
+                                        "external_reference" varchar NOT NULL DEFAULT '',
+                                        "status" varchar NOT NULL DEFAULT 'pending',
+                                        "failure_reason" varchar NOT NULL DEFAULT '',
+                                        "transfer_id" bigint,
+                                        "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "transfer_batches" ("owner");
+
+CREATE INDEX ON "transfer_batch_items" ("batch_id");
+
+COMMENT ON COLUMN "transfer_batches"."atomic" IS 'all items are transferred in one transaction, otherwise each item on its own';
+
+COMMENT ON COLUMN "transfer_batches"."total_amount" IS 'sum of the item amounts';
+
+COMMENT ON COLUMN "transfer_batches"."status" IS 'pending, succeeded, partially_succeeded or failed';
+
+COMMENT ON COLUMN "transfer_batch_items"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "transfer_batch_items"."status" IS 'pending, succeeded or failed';
+
+COMMENT ON COLUMN "transfer_batch_items"."transfer_id" IS 'set once the item has been transferred';
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("batch_id") REFERENCES "transfer_batches" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
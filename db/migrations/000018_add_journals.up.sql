@@ -0,0 +1,18 @@
+CREATE TABLE "journals" (
+                            "id" bigserial PRIMARY KEY,
+                            "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "entries" ADD COLUMN "journal_id" bigint;
+
+ALTER TABLE "transfers" ADD COLUMN "journal_id" bigint;
+
+CREATE INDEX ON "entries" ("journal_id");
+
+COMMENT ON COLUMN "entries"."journal_id" IS 'entries posted together, their amounts sum to zero per currency';
+
+COMMENT ON COLUMN "transfers"."journal_id" IS 'journal holding the entries of the transfer';
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("journal_id") REFERENCES "journals" ("id");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("journal_id") REFERENCES "journals" ("id");
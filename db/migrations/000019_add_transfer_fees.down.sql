@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "fee_rules";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "fee";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "type";
\ No newline at end of file
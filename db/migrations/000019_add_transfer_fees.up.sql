@@ -0,0 +1,31 @@
+ALTER TABLE "accounts" ADD COLUMN "type" varchar NOT NULL DEFAULT 'personal';
+
+ALTER TABLE "transfers" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE "fee_rules" (
+                             "id" bigserial PRIMARY KEY,
+                             "currency" varchar NOT NULL,
+                             "account_type" varchar NOT NULL,
+                             "flat_fee" bigint NOT NULL DEFAULT 0,
+                             "percentage_bps" bigint NOT NULL DEFAULT 0,
+                             "min_fee" bigint NOT NULL DEFAULT 0,
+                             "max_fee" bigint NOT NULL DEFAULT 0,
+                             "revenue_account_id" bigint NOT NULL,
+                             "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "fee_rules" ("currency", "account_type");
+
+COMMENT ON COLUMN "accounts"."type" IS 'personal or business';
+
+COMMENT ON COLUMN "transfers"."fee" IS 'charged to the from account on top of the amount';
+
+COMMENT ON COLUMN "fee_rules"."account_type" IS 'type of the from account the rule applies to';
+
+COMMENT ON COLUMN "fee_rules"."percentage_bps" IS 'percentage of the amount in basis points, added to the flat fee';
+
+COMMENT ON COLUMN "fee_rules"."max_fee" IS 'zero means no maximum';
+
+COMMENT ON COLUMN "fee_rules"."revenue_account_id" IS 'account credited with the fees, in the currency of the rule';
+
+ALTER TABLE "fee_rules" ADD FOREIGN KEY ("revenue_account_id") REFERENCES "accounts" ("id");
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS "transfer_approvals";
+
+DROP TABLE IF EXISTS "account_approvers";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "expires_at";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "required_approvals";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "requested_by";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "status";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "required_approvals";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "approval_threshold";
\ No newline at end of file
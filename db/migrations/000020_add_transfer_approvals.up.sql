@@ -0,0 +1,50 @@
+ALTER TABLE "accounts" ADD COLUMN "approval_threshold" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD COLUMN "required_approvals" int NOT NULL DEFAULT 1;
+
+ALTER TABLE "transfers" ADD COLUMN "status" varchar NOT NULL DEFAULT 'completed';
+
+ALTER TABLE "transfers" ADD COLUMN "requested_by" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "required_approvals" int NOT NULL DEFAULT 0;
+
+ALTER TABLE "transfers" ADD COLUMN "expires_at" timestamptz;
+
+CREATE TABLE "account_approvers" (
+                                     "account_id" bigint NOT NULL,
+                                     "username" varchar NOT NULL,
+                                     "created_at" timestamptz NOT NULL DEFAULT (now()),
+                                     PRIMARY KEY ("account_id", "username")
+);
+
+CREATE TABLE "transfer_approvals" (
+                                      "transfer_id" bigint NOT NULL,
+                                      "approver" varchar NOT NULL,
+                                      "approved" bool NOT NULL,
+                                      "created_at" timestamptz NOT NULL DEFAULT (now()),
+                                      PRIMARY KEY ("transfer_id", "approver")
+);
+
+CREATE INDEX ON "transfers" ("status", "expires_at");
+
+COMMENT ON COLUMN "accounts"."approval_threshold" IS 'transfers of at least this amount wait for approvals, zero disables approvals';
+
+COMMENT ON COLUMN "accounts"."required_approvals" IS 'approvals a transfer of at least approval_threshold needs';
+
+COMMENT ON COLUMN "transfers"."status" IS 'pending, completed, rejected or expired, only completed transfers moved money';
+
+COMMENT ON COLUMN "transfers"."requested_by" IS 'user who requested a transfer needing approval, who cannot approve it';
+
+COMMENT ON COLUMN "transfers"."required_approvals" IS 'approvals the transfer waits for while pending';
+
+COMMENT ON COLUMN "transfers"."expires_at" IS 'a pending transfer not approved by then expires';
+
+COMMENT ON COLUMN "transfer_approvals"."approved" IS 'false when the approver rejected the transfer';
+
+ALTER TABLE "account_approvers" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_approvers" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("approver") REFERENCES "users" ("username");
@@ -0,0 +1,33 @@
+CREATE TABLE "user_transfer_limits" (
+                                        "username" varchar NOT NULL,
+                                        "currency" varchar NOT NULL,
+                                        "per_transaction" bigint NOT NULL DEFAULT 0,
+                                        "daily" bigint NOT NULL DEFAULT 0,
+                                        "monthly" bigint NOT NULL DEFAULT 0,
+                                        "updated_at" timestamptz NOT NULL DEFAULT (now()),
+                                        PRIMARY KEY ("username", "currency")
+);
+
+CREATE TABLE "account_transfer_limits" (
+                                           "account_id" bigint PRIMARY KEY,
+                                           "per_transaction" bigint NOT NULL DEFAULT 0,
+                                           "daily" bigint NOT NULL DEFAULT 0,
+                                           "monthly" bigint NOT NULL DEFAULT 0,
+                                           "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+COMMENT ON TABLE "user_transfer_limits" IS 'limits the outgoing transfers of all accounts of a user in a currency';
+
+COMMENT ON COLUMN "user_transfer_limits"."per_transaction" IS 'zero means no limit, as for daily and monthly';
+
+COMMENT ON COLUMN "user_transfer_limits"."daily" IS 'total of the calendar day in UTC';
+
+COMMENT ON COLUMN "user_transfer_limits"."monthly" IS 'total of the calendar month in UTC';
+
+COMMENT ON COLUMN "account_transfer_limits"."per_transaction" IS 'zero means no limit, as for daily and monthly';
+
+ALTER TABLE "user_transfer_limits" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
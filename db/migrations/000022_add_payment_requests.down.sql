@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "payment_request_events";
+
+DROP TABLE IF EXISTS "payment_requests";
\ No newline at end of file
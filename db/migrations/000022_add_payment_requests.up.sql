@@ -0,0 +1,50 @@
+CREATE TABLE "payment_requests" (
+                                    "id" bigserial PRIMARY KEY,
+                                    "requester" varchar NOT NULL,
+                                    "payer" varchar NOT NULL,
+                                    "to_account_id" bigint NOT NULL,
+                                    "amount" bigint NOT NULL,
+                                    "currency" varchar NOT NULL,
+                                    "description" varchar NOT NULL DEFAULT '',
+                                    "status" varchar NOT NULL DEFAULT 'pending',
+                                    "transfer_id" bigint,
+                                    "expires_at" timestamptz,
+                                    "created_at" timestamptz NOT NULL DEFAULT (now()),
+                                    "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "payment_request_events" (
+                                          "id" bigserial PRIMARY KEY,
+                                          "payment_request_id" bigint NOT NULL,
+                                          "status" varchar NOT NULL,
+                                          "actor" varchar NOT NULL DEFAULT '',
+                                          "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "payment_requests" ("requester");
+
+CREATE INDEX ON "payment_requests" ("payer");
+
+CREATE INDEX ON "payment_requests" ("status", "expires_at");
+
+CREATE INDEX ON "payment_request_events" ("payment_request_id");
+
+COMMENT ON COLUMN "payment_requests"."to_account_id" IS 'account of the requester credited when the payer accepts';
+
+COMMENT ON COLUMN "payment_requests"."status" IS 'pending, accepted, declined, cancelled or expired';
+
+COMMENT ON COLUMN "payment_requests"."transfer_id" IS 'transfer paying the request once accepted';
+
+COMMENT ON COLUMN "payment_requests"."expires_at" IS 'null when the request never expires';
+
+COMMENT ON COLUMN "payment_request_events"."actor" IS 'user who changed the status, empty when the request expired';
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("requester") REFERENCES "users" ("username");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("payer") REFERENCES "users" ("username");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "payment_request_events" ADD FOREIGN KEY ("payment_request_id") REFERENCES "payment_requests" ("id");
@@ -0,0 +1,21 @@
+CREATE TABLE "payees" (
+                          "id" bigserial PRIMARY KEY,
+                          "owner" varchar NOT NULL,
+                          "nickname" varchar NOT NULL,
+                          "account_id" bigint NOT NULL,
+                          "currency" varchar NOT NULL,
+                          "cooling_off_until" timestamptz NOT NULL DEFAULT (now()),
+                          "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "nickname");
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "account_id");
+
+COMMENT ON COLUMN "payees"."account_id" IS 'account transfers to the payee are credited to';
+
+COMMENT ON COLUMN "payees"."cooling_off_until" IS 'large transfers to the payee are refused until then';
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
@@ -0,0 +1,3 @@
+COMMENT ON COLUMN "verify_emails"."email" IS 'address the code was sent to, it only verifies the user while it is still their email';
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "pending_email";
\ No newline at end of file
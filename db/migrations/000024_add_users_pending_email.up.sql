@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN "pending_email" varchar;
+
+COMMENT ON COLUMN "users"."pending_email" IS 'new email the user asked for, it replaces email once its verification code is used';
+
+COMMENT ON COLUMN "verify_emails"."email" IS 'address the code was sent to, it only verifies the user while it is still their email or pending email';
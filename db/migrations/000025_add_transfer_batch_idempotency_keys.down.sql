@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "transfer_batches_status_created_at_idx";
+
+ALTER TABLE "transfer_batches" DROP COLUMN IF EXISTS "idempotency_key";
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE "transfer_batches" ADD COLUMN "idempotency_key" varchar;
+
+CREATE UNIQUE INDEX ON "transfer_batches" ("owner", "idempotency_key");
+
+CREATE INDEX ON "transfer_batches" ("status", "created_at");
+
+COMMENT ON COLUMN "transfer_batches"."idempotency_key" IS 'chosen by the client, a batch created again with the same key returns the first one';
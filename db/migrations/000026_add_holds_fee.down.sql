@@ -0,0 +1,3 @@
+ALTER TABLE "holds" DROP COLUMN IF EXISTS "fee_account_id";
+
+ALTER TABLE "holds" DROP COLUMN IF EXISTS "fee";
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE "holds" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "holds" ADD COLUMN "fee_account_id" bigint;
+
+COMMENT ON COLUMN "holds"."fee" IS 'held on top of the amount and charged in full on capture';
+
+COMMENT ON COLUMN "holds"."fee_account_id" IS 'account credited with the fee on capture, set when the hold has a fee';
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("fee_account_id") REFERENCES "accounts" ("id");
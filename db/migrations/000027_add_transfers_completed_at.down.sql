@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "transfers_from_account_id_completed_at_idx";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "completed_at";
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE "transfers" ADD COLUMN "completed_at" timestamptz;
+
+UPDATE "transfers" SET "completed_at" = "created_at" WHERE "status" = 'completed';
+
+CREATE INDEX ON "transfers" ("from_account_id", "completed_at");
+
+COMMENT ON COLUMN "transfers"."completed_at" IS 'when the transfer was posted, counted against the transfer limits';
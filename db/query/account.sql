@@ -1,8 +1,8 @@
 -- name: CreateAccount :one
 INSERT INTO accounts (
-    owner, balance,currency
+    owner, balance,currency, account_number, type
 ) VALUES (
-             $1, $2,$3
+             $1, $2,$3, $4, $5
          )
     RETURNING *;
 
@@ -10,6 +10,10 @@ INSERT INTO accounts (
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- name: GetAccountByNumber :one
+SELECT * FROM accounts
+WHERE account_number = $1 LIMIT 1;
+
 -- name: GetAccountWithUpdate :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1
@@ -38,3 +42,43 @@ WHERE id = sqlc.arg(id)
 DELETE FROM accounts
 WHERE id = $1;
 
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+set status = sqlc.arg(status)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: GetAccountByOwnerAndCurrency :one
+SELECT * FROM accounts
+WHERE owner = $1 AND currency = $2 LIMIT 1;
+
+-- name: GetAccountByAlias :one
+SELECT * FROM accounts
+WHERE alias = $1 LIMIT 1;
+
+-- name: UpdateAccountAlias :one
+UPDATE accounts
+set alias = sqlc.narg(alias)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateAccountType :one
+UPDATE accounts
+set type = sqlc.arg(type)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: AddAccountHeldBalance :one
+UPDATE accounts
+set held_balance = held_balance + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+    RETURNING *;
+
+
+-- name: UpdateAccountApprovalPolicy :one
+UPDATE accounts
+set approval_threshold = sqlc.arg(approval_threshold),
+    required_approvals = sqlc.arg(required_approvals)
+WHERE id = sqlc.arg(id)
+    RETURNING *;
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- name: CreateAccountApprover :one
+INSERT INTO account_approvers (
+    account_id,
+    username
+) VALUES (
+             $1, $2
+         )
+    RETURNING *;
+
+-- name: GetAccountApprover :one
+SELECT * FROM account_approvers
+WHERE account_id = $1
+  AND username = $2
+LIMIT 1;
+
+-- name: ListAccountApprovers :many
+SELECT * FROM account_approvers
+WHERE account_id = $1
+ORDER BY username;
+
+-- name: DeleteAccountApprovers :exec
+DELETE FROM account_approvers
+WHERE account_id = $1;
@@ -0,0 +1,19 @@
+-- name: CreateFeeRule :one
+INSERT INTO fee_rules (
+    currency,
+    account_type,
+    flat_fee,
+    percentage_bps,
+    min_fee,
+    max_fee,
+    revenue_account_id
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7
+         )
+    RETURNING *;
+
+-- name: GetFeeRule :one
+SELECT * FROM fee_rules
+WHERE currency = $1
+  AND account_type = $2
+LIMIT 1;
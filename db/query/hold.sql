@@ -0,0 +1,39 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+    owner,
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    expires_at,
+    fee,
+    fee_account_id
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8
+         )
+    RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldWithUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ClaimExpiredHold :one
+SELECT * FROM holds
+WHERE status = 'authorized'
+  AND expires_at <= sqlc.arg(now)
+ORDER BY expires_at, id
+LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED;
+
+-- name: FinishHold :one
+UPDATE holds
+SET status = sqlc.arg(status),
+    captured_amount = sqlc.arg(captured_amount),
+    transfer_id = sqlc.narg(transfer_id)
+WHERE id = sqlc.arg(id)
+    RETURNING *;
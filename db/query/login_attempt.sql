@@ -0,0 +1,23 @@
+-- name: CreateLoginAttempt :one
+INSERT INTO login_attempts (
+    username,
+    client_ip,
+    user_agent,
+    succeeded
+) VALUES (
+             $1, $2, $3, $4
+         )
+    RETURNING *;
+
+-- name: GetLoginFailures :one
+SELECT
+    COUNT(*) AS failed_attempts,
+    COALESCE(MAX(created_at), '0001-01-01 00:00:00Z')::timestamptz AS last_failed_at
+FROM login_attempts
+WHERE username = sqlc.arg(username)
+  AND succeeded = false
+  AND created_at > sqlc.arg(since)
+  AND created_at > COALESCE((
+    SELECT MAX(created_at) FROM login_attempts
+    WHERE username = sqlc.arg(username) AND succeeded = true
+), '-infinity'::timestamptz);
@@ -0,0 +1,54 @@
+-- name: UpsertUserTOTP :one
+INSERT INTO user_totp (
+    username,
+    encrypted_secret
+) VALUES (
+             $1, $2
+         )
+ON CONFLICT (username) DO UPDATE
+SET encrypted_secret = EXCLUDED.encrypted_secret,
+    last_used_step = 0,
+    created_at = now()
+WHERE user_totp.confirmed_at IS NULL
+    RETURNING *;
+
+-- name: GetUserTOTP :one
+SELECT * FROM user_totp
+WHERE username = $1 LIMIT 1;
+
+-- name: ConfirmUserTOTP :one
+UPDATE user_totp
+SET confirmed_at = now(),
+    last_used_step = sqlc.arg(last_used_step)
+WHERE username = sqlc.arg(username)
+  AND confirmed_at IS NULL
+    RETURNING *;
+
+-- name: UseTOTPStep :one
+UPDATE user_totp
+SET last_used_step = sqlc.arg(step)
+WHERE username = sqlc.arg(username)
+  AND confirmed_at IS NOT NULL
+  AND last_used_step < sqlc.arg(step)
+    RETURNING *;
+
+-- name: CreateMFARecoveryCode :one
+INSERT INTO mfa_recovery_codes (
+    username,
+    code_hash
+) VALUES (
+             $1, $2
+         )
+    RETURNING *;
+
+-- name: DeleteMFARecoveryCodes :exec
+DELETE FROM mfa_recovery_codes
+WHERE username = $1;
+
+-- name: UseMFARecoveryCode :one
+UPDATE mfa_recovery_codes
+SET used_at = now()
+WHERE username = $1
+  AND code_hash = $2
+  AND used_at IS NULL
+    RETURNING *;
@@ -0,0 +1,13 @@
+-- name: ArchiveUserPassword :exec
+INSERT INTO password_history (
+    username,
+    hashed_password
+)
+SELECT username, hashed_password FROM users
+WHERE username = sqlc.arg(username);
+
+-- name: ListPasswordHistory :many
+SELECT * FROM password_history
+WHERE username = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2;
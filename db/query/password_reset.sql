@@ -0,0 +1,30 @@
+-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (
+    username,
+    token_hash,
+    expired_at
+) VALUES (
+             $1, $2, $3
+         )
+    RETURNING *;
+
+-- name: UsePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE token_hash = sqlc.arg(token_hash)
+  AND used_at IS NULL
+  AND expired_at > now()
+    RETURNING *;
+
+-- name: InvalidatePasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE username = sqlc.arg(username)
+  AND used_at IS NULL;
+
+-- name: GetPasswordResetToken :one
+SELECT * FROM password_reset_tokens
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expired_at > now()
+LIMIT 1;
@@ -0,0 +1,61 @@
+-- name: CreatePaymentRequest :one
+INSERT INTO payment_requests (
+    requester,
+    payer,
+    to_account_id,
+    amount,
+    currency,
+    description,
+    expires_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7
+         )
+    RETURNING *;
+
+-- name: GetPaymentRequest :one
+SELECT * FROM payment_requests
+WHERE id = $1 LIMIT 1;
+
+-- name: GetPaymentRequestForUpdate :one
+SELECT * FROM payment_requests
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListPaymentRequests :many
+SELECT * FROM payment_requests
+WHERE requester = sqlc.arg(username)
+   OR payer = sqlc.arg(username)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: FinishPaymentRequest :one
+UPDATE payment_requests
+SET status = $1,
+    transfer_id = $2,
+    updated_at = now()
+WHERE id = $3
+    RETURNING *;
+
+-- name: ExpirePaymentRequests :many
+UPDATE payment_requests
+SET status = 'expired',
+    updated_at = now()
+WHERE status = 'pending'
+  AND expires_at <= sqlc.arg(now)::timestamptz
+    RETURNING *;
+
+-- name: CreatePaymentRequestEvent :one
+INSERT INTO payment_request_events (
+    payment_request_id,
+    status,
+    actor
+) VALUES (
+             $1, $2, $3
+         )
+    RETURNING *;
+
+-- name: ListPaymentRequestEvents :many
+SELECT * FROM payment_request_events
+WHERE payment_request_id = $1
+ORDER BY id;
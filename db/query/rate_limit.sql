@@ -0,0 +1,18 @@
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets (
+    key,
+    tokens,
+    allowed
+) VALUES (
+             sqlc.arg(key), sqlc.arg(burst)::float8 - 1, true
+         )
+ON CONFLICT (key) DO UPDATE
+SET tokens = LEAST(sqlc.arg(burst)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM (now() - rate_limit_buckets.updated_at))::float8 * sqlc.arg(rate)::float8)
+        - (LEAST(sqlc.arg(burst)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM (now() - rate_limit_buckets.updated_at))::float8 * sqlc.arg(rate)::float8) >= 1)::int,
+    allowed = LEAST(sqlc.arg(burst)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM (now() - rate_limit_buckets.updated_at))::float8 * sqlc.arg(rate)::float8) >= 1,
+    updated_at = now()
+RETURNING tokens, allowed;
+
+-- name: DeleteStaleRateLimitBuckets :exec
+DELETE FROM rate_limit_buckets
+WHERE updated_at < $1;
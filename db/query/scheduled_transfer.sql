@@ -0,0 +1,61 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+    owner,
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    execute_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6
+         )
+    RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: ListScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE owner = $1
+ORDER BY execute_at, id
+LIMIT $2
+OFFSET $3;
+
+-- name: CancelScheduledTransfer :one
+UPDATE scheduled_transfers
+SET status = 'cancelled'
+WHERE id = $1
+  AND status = 'pending'
+    RETURNING *;
+
+-- name: ClaimDueScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE status = 'pending'
+  AND execute_at <= sqlc.arg(now)
+ORDER BY execute_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: FinishScheduledTransfer :one
+UPDATE scheduled_transfers
+SET status = sqlc.arg(status),
+    transfer_id = sqlc.narg(transfer_id)
+WHERE id = sqlc.arg(id)
+    RETURNING *;
+
+-- name: CreateScheduledTransferAttempt :one
+INSERT INTO scheduled_transfer_attempts (
+    scheduled_transfer_id,
+    succeeded,
+    failure_reason,
+    transfer_id
+) VALUES (
+             $1, $2, $3, $4
+         )
+    RETURNING *;
+
+-- name: ListScheduledTransferAttempts :many
+SELECT * FROM scheduled_transfer_attempts
+WHERE scheduled_transfer_id = $1
+ORDER BY id;
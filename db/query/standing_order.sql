@@ -0,0 +1,71 @@
+-- name: CreateStandingOrder :one
+INSERT INTO standing_orders (
+    owner,
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    frequency,
+    day_of_month,
+    start_at,
+    end_at,
+    max_occurrences,
+    next_occurrence_at,
+    next_run_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11
+         )
+    RETURNING *;
+
+-- name: GetStandingOrder :one
+SELECT * FROM standing_orders
+WHERE id = $1 LIMIT 1;
+
+-- name: ListStandingOrders :many
+SELECT * FROM standing_orders
+WHERE owner = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: CancelStandingOrder :one
+UPDATE standing_orders
+SET status = 'cancelled'
+WHERE id = $1
+  AND status = 'active'
+    RETURNING *;
+
+-- name: ClaimDueStandingOrder :one
+SELECT * FROM standing_orders
+WHERE status = 'active'
+  AND next_run_at <= sqlc.arg(now)
+ORDER BY next_run_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: UpdateStandingOrderSchedule :one
+UPDATE standing_orders
+SET occurrences = sqlc.arg(occurrences),
+    next_occurrence_at = sqlc.arg(next_occurrence_at),
+    next_run_at = sqlc.arg(next_run_at),
+    retries = sqlc.arg(retries),
+    status = sqlc.arg(status)
+WHERE id = sqlc.arg(id)
+    RETURNING *;
+
+-- name: CreateStandingOrderExecution :one
+INSERT INTO standing_order_executions (
+    standing_order_id,
+    occurrence_at,
+    succeeded,
+    failure_reason,
+    transfer_id
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+    RETURNING *;
+
+-- name: ListStandingOrderExecutions :many
+SELECT * FROM standing_order_executions
+WHERE standing_order_id = $1
+ORDER BY id;
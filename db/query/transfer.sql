@@ -2,15 +2,32 @@
 INSERT INTO transfers (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    reverses_transfer_id,
+    description,
+    external_reference,
+    metadata,
+    journal_id,
+    fee,
+    completed_at
 ) VALUES (
-             $1, $2, $3
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, now()
          ) RETURNING *;
 
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferWithUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetReversedAmount :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS reversed_amount
+FROM transfers
+WHERE reverses_transfer_id = $1;
+
 -- name: ListTransfers :many
 SELECT * FROM transfers
 WHERE
@@ -18,4 +35,53 @@ WHERE
         to_account_id = $2
 ORDER BY id
     LIMIT $3
-OFFSET $4;
\ No newline at end of file
+OFFSET $4;
+
+-- name: SearchTransfers :many
+SELECT * FROM transfers
+WHERE
+        (from_account_id = sqlc.arg(account_id) OR to_account_id = sqlc.arg(account_id))
+  AND description ILIKE '%' || sqlc.arg(description)::text || '%'
+  AND (sqlc.arg(external_reference)::text = '' OR external_reference = sqlc.arg(external_reference))
+  AND metadata @> sqlc.arg(metadata)::jsonb
+ORDER BY id DESC
+    LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: CreatePendingTransfer :one
+INSERT INTO transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    description,
+    external_reference,
+    metadata,
+    status,
+    requested_by,
+    required_approvals,
+    expires_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, 'pending', $7, $8, $9
+         ) RETURNING *;
+
+-- name: CompleteTransfer :one
+UPDATE transfers
+SET status = 'completed',
+    journal_id = sqlc.arg(journal_id),
+    fee = sqlc.arg(fee),
+    completed_at = now()
+WHERE id = sqlc.arg(id)
+    RETURNING *;
+
+-- name: UpdateTransferStatus :one
+UPDATE transfers
+SET status = $1
+WHERE id = $2
+    RETURNING *;
+
+-- name: ExpirePendingTransfers :many
+UPDATE transfers
+SET status = 'expired'
+WHERE status = 'pending'
+  AND expires_at <= sqlc.arg(now)::timestamptz
+    RETURNING *;
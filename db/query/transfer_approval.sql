@@ -0,0 +1,20 @@
+-- name: CreateTransferApproval :one
+INSERT INTO transfer_approvals (
+    transfer_id,
+    approver,
+    approved
+) VALUES (
+             $1, $2, $3
+         )
+ON CONFLICT DO NOTHING
+    RETURNING *;
+
+-- name: CountTransferApprovals :one
+SELECT count(*) FROM transfer_approvals
+WHERE transfer_id = $1
+  AND approved;
+
+-- name: ListTransferApprovals :many
+SELECT * FROM transfer_approvals
+WHERE transfer_id = $1
+ORDER BY created_at, approver;
@@ -0,0 +1,64 @@
+-- name: CreateTransferBatch :one
+INSERT INTO transfer_batches (
+    owner,
+    from_account_id,
+    currency,
+    atomic,
+    total_amount,
+    idempotency_key
+) VALUES (
+             $1, $2, $3, $4, $5, $6
+         )
+    RETURNING *;
+
+-- name: GetTransferBatch :one
+SELECT * FROM transfer_batches
+WHERE id = $1 LIMIT 1;
+
+-- name: GetTransferBatchByIdempotencyKey :one
+SELECT * FROM transfer_batches
+WHERE owner = $1 AND idempotency_key = $2 LIMIT 1;
+
+-- name: ClaimStaleTransferBatch :one
+SELECT * FROM transfer_batches
+WHERE status = 'pending'
+  AND created_at <= sqlc.arg(before)
+ORDER BY created_at, id
+LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED;
+
+-- name: FinishTransferBatch :one
+UPDATE transfer_batches
+SET status = $1
+WHERE id = $2
+    RETURNING *;
+
+-- name: CreateTransferBatchItem :one
+INSERT INTO transfer_batch_items (
+    batch_id,
+    to_account_id,
+    amount,
+    description,
+    external_reference
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+    RETURNING *;
+
+-- name: FinishTransferBatchItem :one
+UPDATE transfer_batch_items
+SET status = sqlc.arg(status),
+    failure_reason = sqlc.arg(failure_reason),
+    transfer_id = sqlc.narg(transfer_id)
+WHERE id = sqlc.arg(id)
+    RETURNING *;
+
+-- name: GetTransferBatchItemForUpdate :one
+SELECT * FROM transfer_batch_items
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListTransferBatchItems :many
+SELECT * FROM transfer_batch_items
+WHERE batch_id = $1
+ORDER BY id;
@@ -0,0 +1,73 @@
+-- name: UpsertUserTransferLimit :one
+INSERT INTO user_transfer_limits (
+    username,
+    currency,
+    per_transaction,
+    daily,
+    monthly
+) VALUES (
+             $1, $2, $3, $4, $5
+         )
+ON CONFLICT (username, currency) DO UPDATE
+SET per_transaction = EXCLUDED.per_transaction,
+    daily = EXCLUDED.daily,
+    monthly = EXCLUDED.monthly,
+    updated_at = now()
+    RETURNING *;
+
+-- name: GetUserTransferLimitForUpdate :one
+SELECT * FROM user_transfer_limits
+WHERE username = $1
+  AND currency = $2
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListUserTransferLimits :many
+SELECT * FROM user_transfer_limits
+WHERE username = $1
+ORDER BY currency;
+
+-- name: UpsertAccountTransferLimit :one
+INSERT INTO account_transfer_limits (
+    account_id,
+    per_transaction,
+    daily,
+    monthly
+) VALUES (
+             $1, $2, $3, $4
+         )
+ON CONFLICT (account_id) DO UPDATE
+SET per_transaction = EXCLUDED.per_transaction,
+    daily = EXCLUDED.daily,
+    monthly = EXCLUDED.monthly,
+    updated_at = now()
+    RETURNING *;
+
+-- name: GetAccountTransferLimit :one
+SELECT * FROM account_transfer_limits
+WHERE account_id = $1
+LIMIT 1;
+
+-- name: ListAccountTransferLimits :many
+SELECT * FROM account_transfer_limits
+WHERE account_id IN (
+    SELECT id FROM accounts
+    WHERE owner = $1
+)
+ORDER BY account_id;
+
+-- name: GetAccountOutgoingAmount :one
+SELECT COALESCE(SUM(amount), 0)::bigint FROM transfers
+WHERE from_account_id = sqlc.arg(from_account_id)
+  AND status = 'completed'
+  AND reverses_transfer_id IS NULL
+  AND completed_at >= sqlc.arg(since)::timestamptz;
+
+-- name: GetOwnerOutgoingAmount :one
+SELECT COALESCE(SUM(transfers.amount), 0)::bigint FROM transfers
+JOIN accounts ON accounts.id = transfers.from_account_id
+WHERE accounts.owner = sqlc.arg(owner)
+  AND accounts.currency = sqlc.arg(currency)
+  AND transfers.status = 'completed'
+  AND transfers.reverses_transfer_id IS NULL
+  AND transfers.completed_at >= sqlc.arg(since)::timestamptz;
@@ -11,4 +11,37 @@ INSERT INTO users (
 
 -- name: GetUser :one
 SELECT * FROM users
-WHERE username = $1 LIMIT 1;
\ No newline at end of file
+WHERE username = $1 LIMIT 1;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: GetPasswordChangedAt :one
+SELECT password_changed_at FROM users
+WHERE username = $1 LIMIT 1;
+
+-- name: UpdateUser :one
+UPDATE users
+SET hashed_password = COALESCE(sqlc.narg(hashed_password), hashed_password),
+    password_changed_at = COALESCE(sqlc.narg(password_changed_at), password_changed_at),
+    full_name = COALESCE(sqlc.narg(full_name), full_name),
+    email = COALESCE(sqlc.narg(email), email),
+    is_email_verified = COALESCE(sqlc.narg(is_email_verified), is_email_verified)
+WHERE username = sqlc.arg(username)
+    RETURNING *;
+
+-- name: SetUserPendingEmail :one
+UPDATE users
+SET pending_email = sqlc.arg(pending_email)
+WHERE username = sqlc.arg(username)
+    RETURNING *;
+
+-- name: ConfirmUserPendingEmail :one
+UPDATE users
+SET email = pending_email,
+    pending_email = NULL,
+    is_email_verified = TRUE
+WHERE username = sqlc.arg(username)
+  AND pending_email = sqlc.arg(pending_email)
+    RETURNING *;
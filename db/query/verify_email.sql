@@ -0,0 +1,19 @@
+-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+    username,
+    email,
+    secret_code_hash,
+    expired_at
+) VALUES (
+             $1, $2, $3, $4
+         )
+    RETURNING *;
+
+-- name: UseVerifyEmail :one
+UPDATE verify_emails
+SET is_used = TRUE
+WHERE id = sqlc.arg(id)
+  AND secret_code_hash = sqlc.arg(secret_code_hash)
+  AND is_used = FALSE
+  AND expired_at > now()
+    RETURNING *;
@@ -7,13 +7,14 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 set balance = balance + $1
 WHERE id = $2
-    RETURNING id, owner, balance, currency, created_at
+    RETURNING id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals
 `
 
 type AddAccountBalanceParams struct {
@@ -30,27 +31,76 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
+	)
+	return i, err
+}
+
+const addAccountHeldBalance = `-- name: AddAccountHeldBalance :one
+UPDATE accounts
+set held_balance = held_balance + $1
+WHERE id = $2
+    RETURNING id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals
+`
+
+type AddAccountHeldBalanceParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddAccountHeldBalance(ctx context.Context, arg AddAccountHeldBalanceParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addAccountHeldBalance, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
 	)
 	return i, err
 }
 
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
-    owner, balance,currency
+    owner, balance,currency, account_number, type
 ) VALUES (
-             $1, $2,$3
+             $1, $2,$3, $4, $5
          )
-    RETURNING id, owner, balance, currency, created_at
+    RETURNING id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals
 `
 
 type CreateAccountParams struct {
-	Owner    string `json:"owner"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	Owner         string `json:"owner"`
+	Balance       int64  `json:"balance"`
+	Currency      string `json:"currency"`
+	AccountNumber string `json:"account_number"`
+	Type          string `json:"type"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRowContext(ctx, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.AccountNumber,
+		arg.Type,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -58,6 +108,14 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
 	)
 	return i, err
 }
@@ -73,7 +131,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -86,12 +144,103 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
+	)
+	return i, err
+}
+
+const getAccountByAlias = `-- name: GetAccountByAlias :one
+SELECT id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals FROM accounts
+WHERE alias = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountByAlias(ctx context.Context, alias sql.NullString) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByAlias, alias)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
+	)
+	return i, err
+}
+
+const getAccountByNumber = `-- name: GetAccountByNumber :one
+SELECT id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals FROM accounts
+WHERE account_number = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByNumber, accountNumber)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
+	)
+	return i, err
+}
+
+const getAccountByOwnerAndCurrency = `-- name: GetAccountByOwnerAndCurrency :one
+SELECT id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals FROM accounts
+WHERE owner = $1 AND currency = $2 LIMIT 1
+`
+
+type GetAccountByOwnerAndCurrencyParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByOwnerAndCurrency, arg.Owner, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
 	)
 	return i, err
 }
 
 const getAccountWithUpdate = `-- name: GetAccountWithUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -105,12 +254,20 @@ func (q *Queries) GetAccountWithUpdate(ctx context.Context, id int64) (Account,
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals FROM accounts
 WHERE owner = $1
 ORDER BY id
 LIMIT $2
@@ -138,6 +295,14 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Status,
+			&i.Alias,
+			&i.AccountNumber,
+			&i.HeldBalance,
+			&i.AvailableBalance,
+			&i.Type,
+			&i.ApprovalThreshold,
+			&i.RequiredApprovals,
 		); err != nil {
 			return nil, err
 		}
@@ -156,7 +321,7 @@ const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 set balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals
 `
 
 type UpdateAccountParams struct {
@@ -173,6 +338,148 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
+	)
+	return i, err
+}
+
+const updateAccountAlias = `-- name: UpdateAccountAlias :one
+UPDATE accounts
+set alias = $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals
+`
+
+type UpdateAccountAliasParams struct {
+	Alias sql.NullString `json:"alias"`
+	ID    int64          `json:"id"`
+}
+
+func (q *Queries) UpdateAccountAlias(ctx context.Context, arg UpdateAccountAliasParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountAlias, arg.Alias, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
+	)
+	return i, err
+}
+
+const updateAccountApprovalPolicy = `-- name: UpdateAccountApprovalPolicy :one
+UPDATE accounts
+set approval_threshold = $1,
+    required_approvals = $2
+WHERE id = $3
+    RETURNING id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals
+`
+
+type UpdateAccountApprovalPolicyParams struct {
+	ApprovalThreshold int64 `json:"approval_threshold"`
+	RequiredApprovals int32 `json:"required_approvals"`
+	ID                int64 `json:"id"`
+}
+
+func (q *Queries) UpdateAccountApprovalPolicy(ctx context.Context, arg UpdateAccountApprovalPolicyParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountApprovalPolicy, arg.ApprovalThreshold, arg.RequiredApprovals, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+set status = $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals
+`
+
+type UpdateAccountStatusParams struct {
+	Status string `json:"status"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.Status, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
+	)
+	return i, err
+}
+
+const updateAccountType = `-- name: UpdateAccountType :one
+UPDATE accounts
+set type = $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, status, alias, account_number, held_balance, available_balance, type, approval_threshold, required_approvals
+`
+
+type UpdateAccountTypeParams struct {
+	Type string `json:"type"`
+	ID   int64  `json:"id"`
+}
+
+func (q *Queries) UpdateAccountType(ctx context.Context, arg UpdateAccountTypeParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountType, arg.Type, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Status,
+		&i.Alias,
+		&i.AccountNumber,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.Type,
+		&i.ApprovalThreshold,
+		&i.RequiredApprovals,
 	)
 	return i, err
 }
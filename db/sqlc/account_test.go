@@ -10,11 +10,19 @@ import (
 )
 
 func createRandomAccount(t *testing.T) Account {
+	return createRandomAccountWithCurrency(t, utils.RandomCurrency())
+}
+
+// createRandomAccountWithCurrency creates an account money can be transferred to from accounts in currency.
+// It starts with at least 100, transfers cannot overdraw it.
+func createRandomAccountWithCurrency(t *testing.T, currency string) Account {
 	user := createRandomUser(t)
 	params := CreateAccountParams{
-		Owner:    user.Username,
-		Balance:  utils.RandomMoney(),
-		Currency: utils.RandomCurrency(),
+		Owner:         user.Username,
+		Balance:       utils.RandomInt(100, 1000),
+		Currency:      currency,
+		AccountNumber: utils.NewAccountNumber(),
+		Type:          utils.AccountTypePersonal,
 	}
 	ctx := context.Background()
 	account, err := testQueries.CreateAccount(ctx, params)
@@ -24,8 +32,11 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, params.Owner, account.Owner)
 	require.Equal(t, params.Balance, account.Balance)
 	require.Equal(t, params.Currency, account.Currency)
+	require.Equal(t, params.AccountNumber, account.AccountNumber)
+	require.Equal(t, params.Type, account.Type)
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
+	require.Equal(t, utils.AccountStatusActive, account.Status)
 	return account
 }
 
@@ -92,3 +103,95 @@ func TestListAccount(t *testing.T) {
 		require.Equal(t, lastAccount.Owner, accounts[i].Owner)
 	}
 }
+
+func TestUpdateAccountStatusTx(t *testing.T) {
+	store := NewStore(testDB)
+	ctx := context.Background()
+	account := createRandomAccount(t)
+
+	result, err := store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    utils.AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.AccountStatusFrozen, result.Account.Status)
+
+	// money left in the account
+	_, err = store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    utils.AccountStatusClosed,
+	})
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+	_, err = testQueries.UpdateAccount(ctx, UpdateAccountParams{ID: account.ID, Balance: 0})
+	require.NoError(t, err)
+	result, err = store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    utils.AccountStatusClosed,
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.AccountStatusClosed, result.Account.Status)
+
+	// closed accounts can only be reopened
+	_, err = store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    utils.AccountStatusFrozen,
+	})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	result, err = store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    utils.AccountStatusActive,
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.AccountStatusActive, result.Account.Status)
+
+	_, err = store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{
+		AccountID: 0,
+		Status:    utils.AccountStatusFrozen,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestGetAccountByOwnerAndCurrency(t *testing.T) {
+	ctx := context.Background()
+	createdAccount := createRandomAccount(t)
+
+	gotAccount, err := testQueries.GetAccountByOwnerAndCurrency(ctx, GetAccountByOwnerAndCurrencyParams{
+		Owner:    createdAccount.Owner,
+		Currency: createdAccount.Currency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, createdAccount.ID, gotAccount.ID)
+}
+
+func TestUpdateAccountAlias(t *testing.T) {
+	ctx := context.Background()
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	alias := sql.NullString{String: utils.RandomString(12), Valid: true}
+
+	updatedAccount, err := testQueries.UpdateAccountAlias(ctx, UpdateAccountAliasParams{Alias: alias, ID: account1.ID})
+	require.NoError(t, err)
+	require.Equal(t, alias, updatedAccount.Alias)
+
+	gotAccount, err := testQueries.GetAccountByAlias(ctx, alias)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, gotAccount.ID)
+
+	_, err = testQueries.UpdateAccountAlias(ctx, UpdateAccountAliasParams{Alias: alias, ID: account2.ID})
+	require.Error(t, err)
+
+	updatedAccount, err = testQueries.UpdateAccountAlias(ctx, UpdateAccountAliasParams{ID: account1.ID})
+	require.NoError(t, err)
+	require.False(t, updatedAccount.Alias.Valid)
+}
+
+func TestGetAccountByNumber(t *testing.T) {
+	ctx := context.Background()
+	createdAccount := createRandomAccount(t)
+
+	gotAccount, err := testQueries.GetAccountByNumber(ctx, createdAccount.AccountNumber)
+	require.NoError(t, err)
+	require.Equal(t, createdAccount.ID, gotAccount.ID)
+}
@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// authorizeRandomHold holds 10 on a new account topped up with 100
+func authorizeRandomHold(t *testing.T, store Store, expiresAt time.Time) (Account, Account, Hold) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	account1, err := testQueries.AddAccountBalance(ctx, AddAccountBalanceParams{Amount: 100, ID: account1.ID})
+	require.NoError(t, err)
+
+	result, err := store.AuthorizeTransferTx(ctx, AuthorizeTransferTxParams{
+		Owner:         account1.Owner,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Currency:      account1.Currency,
+		ExpiresAt:     expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.HoldStatusAuthorized, result.Hold.Status)
+	require.Equal(t, int64(10), result.Hold.Amount)
+	require.Equal(t, account1.Balance, result.FromAccount.Balance)
+	require.Equal(t, int64(10), result.FromAccount.HeldBalance)
+	require.Equal(t, account1.Balance-10, result.FromAccount.AvailableBalance)
+	return account1, account2, result.Hold
+}
+
+func TestAuthorizeTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1, account2, _ := authorizeRandomHold(t, store, time.Now().Add(time.Hour))
+	ctx := context.Background()
+
+	_, err := store.AuthorizeTransferTx(ctx, AuthorizeTransferTxParams{
+		Owner:         account1.Owner,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance - 9,
+		Currency:      account1.Currency,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.TransferTx(ctx, TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        account1.Balance - 9,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestAuthorizeTransferTxLimit(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	_, err := testQueries.UpsertAccountTransferLimit(ctx, UpsertAccountTransferLimitParams{
+		AccountID:      account1.ID,
+		PerTransaction: 20,
+	})
+	require.NoError(t, err)
+
+	arg := AuthorizeTransferTxParams{
+		Owner:         account1.Owner,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        21,
+		Currency:      account1.Currency,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+	_, err = store.AuthorizeTransferTx(ctx, arg)
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	// nothing is held when the hold is refused
+	got, err := testQueries.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, got.HeldBalance)
+
+	arg.Amount = 20
+	_, err = store.AuthorizeTransferTx(ctx, arg)
+	require.NoError(t, err)
+}
+
+func TestCaptureHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1, account2, hold := authorizeRandomHold(t, store, time.Now().Add(time.Hour))
+	ctx := context.Background()
+
+	_, err := store.CaptureHoldTx(ctx, CaptureHoldTxParams{HoldID: hold.ID, Amount: 11})
+	require.ErrorIs(t, err, ErrCaptureExceedsHold)
+
+	result, err := store.CaptureHoldTx(ctx, CaptureHoldTxParams{HoldID: hold.ID, Amount: 4})
+	require.NoError(t, err)
+	require.Equal(t, utils.HoldStatusCaptured, result.Hold.Status)
+	require.Equal(t, int64(4), result.Hold.CapturedAmount)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Hold.TransferID.Int64)
+	require.Equal(t, int64(4), result.Transfer.Transfer.Amount)
+	require.Equal(t, account1.Balance-4, result.Transfer.FromAccount.Balance)
+	require.Zero(t, result.Transfer.FromAccount.HeldBalance)
+	require.Equal(t, account2.Balance+4, result.Transfer.ToAccount.Balance)
+
+	_, err = store.CaptureHoldTx(ctx, CaptureHoldTxParams{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+}
+
+func TestVoidHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1, _, hold := authorizeRandomHold(t, store, time.Now().Add(time.Hour))
+	ctx := context.Background()
+
+	result, err := store.VoidHoldTx(ctx, VoidHoldTxParams{HoldID: hold.ID})
+	require.NoError(t, err)
+	require.Equal(t, utils.HoldStatusVoided, result.Hold.Status)
+	require.Zero(t, result.Hold.CapturedAmount)
+	require.Equal(t, account1.Balance, result.FromAccount.AvailableBalance)
+
+	_, err = store.VoidHoldTx(ctx, VoidHoldTxParams{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrHoldNotAuthorized)
+}
+
+func TestExpireHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1, _, hold := authorizeRandomHold(t, store, time.Now().Add(-time.Minute))
+	ctx := context.Background()
+
+	_, err := store.CaptureHoldTx(ctx, CaptureHoldTxParams{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrHoldExpired)
+
+	// other tests may leave expired holds behind, so expire until this one is released
+	for {
+		result, err := store.ExpireHoldTx(ctx, ExpireHoldTxParams{Now: time.Now()})
+		require.NoError(t, err)
+		if result.Hold.ID == hold.ID {
+			require.Equal(t, utils.HoldStatusExpired, result.Hold.Status)
+			require.Equal(t, account1.Balance, result.FromAccount.AvailableBalance)
+			break
+		}
+	}
+
+	_, err = store.ExpireHoldTx(ctx, ExpireHoldTxParams{Now: time.Now().Add(-time.Hour)})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCaptureHoldTxWithFee(t *testing.T) {
+	store := NewStore(testDB)
+	revenueAccount := createRandomAccount(t)
+	account1 := createRandomAccountWithFee(t, revenueAccount, 2)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	authorized, err := store.AuthorizeTransferTx(ctx, AuthorizeTransferTxParams{
+		Owner:         account1.Owner,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Currency:      account1.Currency,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), authorized.Hold.Fee)
+	require.Equal(t, revenueAccount.ID, authorized.Hold.FeeAccountID.Int64)
+	require.Equal(t, int64(12), authorized.FromAccount.HeldBalance)
+
+	// the fee is charged in full on a partial capture
+	result, err := store.CaptureHoldTx(ctx, CaptureHoldTxParams{HoldID: authorized.Hold.ID, Amount: 4})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Transfer.Transfer.Fee)
+	require.Equal(t, account1.Balance-6, result.Transfer.FromAccount.Balance)
+	require.Zero(t, result.Transfer.FromAccount.HeldBalance)
+	require.NotNil(t, result.Transfer.FeeEntry)
+
+	updatedRevenueAccount, err := store.GetAccount(ctx, revenueAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, revenueAccount.Balance+2, updatedRevenueAccount.Balance)
+}
+
+func TestVoidHoldTxWithFee(t *testing.T) {
+	store := NewStore(testDB)
+	revenueAccount := createRandomAccount(t)
+	account1 := createRandomAccountWithFee(t, revenueAccount, 2)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	authorized, err := store.AuthorizeTransferTx(ctx, AuthorizeTransferTxParams{
+		Owner:         account1.Owner,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Currency:      account1.Currency,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	result, err := store.VoidHoldTx(ctx, VoidHoldTxParams{HoldID: authorized.Hold.ID})
+	require.NoError(t, err)
+	require.Zero(t, result.FromAccount.HeldBalance)
+	require.Equal(t, account1.Balance, result.FromAccount.Balance)
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomLoginAttempt(t *testing.T, username string, succeeded bool) LoginAttempt {
+	arg := CreateLoginAttemptParams{
+		Username:  username,
+		ClientIp:  "127.0.0.1",
+		UserAgent: utils.RandomString(10),
+		Succeeded: succeeded,
+	}
+	attempt, err := testQueries.CreateLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, attempt)
+
+	require.Equal(t, arg.Username, attempt.Username)
+	require.Equal(t, arg.ClientIp, attempt.ClientIp)
+	require.Equal(t, arg.UserAgent, attempt.UserAgent)
+	require.Equal(t, arg.Succeeded, attempt.Succeeded)
+	require.NotZero(t, attempt.ID)
+	require.NotZero(t, attempt.CreatedAt)
+	return attempt
+}
+
+func TestCreateLoginAttempt(t *testing.T) {
+	createRandomLoginAttempt(t, utils.RandomOwner(), false)
+}
+
+func TestGetLoginFailures(t *testing.T) {
+	ctx := context.Background()
+	username := utils.RandomOwner()
+	arg := GetLoginFailuresParams{
+		Username: username,
+		Since:    time.Now().Add(-time.Hour),
+	}
+
+	failures, err := testQueries.GetLoginFailures(ctx, arg)
+	require.NoError(t, err)
+	require.Zero(t, failures.FailedAttempts)
+	require.True(t, failures.LastFailedAt.IsZero())
+
+	createRandomLoginAttempt(t, username, false)
+	last := createRandomLoginAttempt(t, username, false)
+
+	failures, err = testQueries.GetLoginFailures(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), failures.FailedAttempts)
+	require.WithinDuration(t, last.CreatedAt, failures.LastFailedAt, time.Second)
+
+	// a successful login resets the count
+	createRandomLoginAttempt(t, username, true)
+	failures, err = testQueries.GetLoginFailures(ctx, arg)
+	require.NoError(t, err)
+	require.Zero(t, failures.FailedAttempts)
+}
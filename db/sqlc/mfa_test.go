@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func createRandomUserTOTP(t *testing.T, username string) UserTotp {
+	arg := UpsertUserTOTPParams{
+		Username:        username,
+		EncryptedSecret: utils.RandomString(32),
+	}
+	userTotp, err := testQueries.UpsertUserTOTP(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, userTotp.Username)
+	require.Equal(t, arg.EncryptedSecret, userTotp.EncryptedSecret)
+	require.False(t, userTotp.ConfirmedAt.Valid)
+	require.Zero(t, userTotp.LastUsedStep)
+	return userTotp
+}
+
+func TestConfirmUserTOTP(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUser(t)
+	createRandomUserTOTP(t, user.Username)
+
+	// the secret can be replaced until it is confirmed
+	pending := createRandomUserTOTP(t, user.Username)
+
+	confirmed, err := testQueries.ConfirmUserTOTP(ctx, ConfirmUserTOTPParams{
+		Username:     user.Username,
+		LastUsedStep: 10,
+	})
+	require.NoError(t, err)
+	require.True(t, confirmed.ConfirmedAt.Valid)
+	require.Equal(t, pending.EncryptedSecret, confirmed.EncryptedSecret)
+	require.Equal(t, int64(10), confirmed.LastUsedStep)
+
+	_, err = testQueries.UpsertUserTOTP(ctx, UpsertUserTOTPParams{
+		Username:        user.Username,
+		EncryptedSecret: utils.RandomString(32),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	got, err := testQueries.GetUserTOTP(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, pending.EncryptedSecret, got.EncryptedSecret)
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUser(t)
+	createRandomUserTOTP(t, user.Username)
+
+	arg := UseTOTPStepParams{Username: user.Username, Step: 20}
+	_, err := testQueries.UseTOTPStep(ctx, arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.ConfirmUserTOTP(ctx, ConfirmUserTOTPParams{Username: user.Username, LastUsedStep: 10})
+	require.NoError(t, err)
+
+	userTotp, err := testQueries.UseTOTPStep(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Step, userTotp.LastUsedStep)
+
+	_, err = testQueries.UseTOTPStep(ctx, arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUseMFARecoveryCode(t *testing.T) {
+	ctx := context.Background()
+	user := createRandomUser(t)
+	codeHash := utils.HashToken(utils.RandomString(10))
+
+	code, err := testQueries.CreateMFARecoveryCode(ctx, CreateMFARecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: codeHash,
+	})
+	require.NoError(t, err)
+	require.False(t, code.UsedAt.Valid)
+
+	arg := UseMFARecoveryCodeParams{Username: user.Username, CodeHash: codeHash}
+	used, err := testQueries.UseMFARecoveryCode(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, code.ID, used.ID)
+	require.True(t, used.UsedAt.Valid)
+
+	_, err = testQueries.UseMFARecoveryCode(ctx, arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestEnrollTOTPTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	codeHashes := []string{utils.HashToken(utils.RandomString(10)), utils.HashToken(utils.RandomString(10))}
+	result, err := store.EnrollTOTPTx(ctx, EnrollTOTPTxParams{
+		Username:           user.Username,
+		EncryptedSecret:    utils.RandomString(32),
+		RecoveryCodeHashes: codeHashes,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.UserTotp.Username)
+
+	// enrolling again replaces the recovery codes
+	_, err = store.EnrollTOTPTx(ctx, EnrollTOTPTxParams{
+		Username:           user.Username,
+		EncryptedSecret:    utils.RandomString(32),
+		RecoveryCodeHashes: []string{utils.HashToken(utils.RandomString(10))},
+	})
+	require.NoError(t, err)
+
+	_, err = store.UseMFARecoveryCode(ctx, UseMFARecoveryCodeParams{Username: user.Username, CodeHash: codeHashes[0]})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -5,6 +5,8 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -14,6 +16,37 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// active, frozen or closed, only active accounts can send or receive money
+	Status string `json:"status"`
+	// optional name senders can pay the account by instead of its id
+	Alias sql.NullString `json:"alias"`
+	// IBAN style external account number with mod-97 check digits
+	AccountNumber string `json:"account_number"`
+	// sum of the authorized holds on the account
+	HeldBalance int64 `json:"held_balance"`
+	// balance minus held_balance, what the account can still spend
+	AvailableBalance int64 `json:"available_balance"`
+	// personal or business
+	Type string `json:"type"`
+	// transfers of at least this amount wait for approvals, zero disables approvals
+	ApprovalThreshold int64 `json:"approval_threshold"`
+	// approvals a transfer of at least approval_threshold needs
+	RequiredApprovals int32 `json:"required_approvals"`
+}
+
+type AccountApprover struct {
+	AccountID int64     `json:"account_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AccountTransferLimit struct {
+	AccountID int64 `json:"account_id"`
+	// zero means no limit, as for daily and monthly
+	PerTransaction int64     `json:"per_transaction"`
+	Daily          int64     `json:"daily"`
+	Monthly        int64     `json:"monthly"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 type Entry struct {
@@ -22,6 +55,200 @@ type Entry struct {
 	// can be positive or negative
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// entries posted together, their amounts sum to zero per currency
+	JournalID sql.NullInt64 `json:"journal_id"`
+}
+
+type FeeRule struct {
+	ID       int64  `json:"id"`
+	Currency string `json:"currency"`
+	// type of the from account the rule applies to
+	AccountType string `json:"account_type"`
+	FlatFee     int64  `json:"flat_fee"`
+	// percentage of the amount in basis points, added to the flat fee
+	PercentageBps int64 `json:"percentage_bps"`
+	MinFee        int64 `json:"min_fee"`
+	// zero means no maximum
+	MaxFee int64 `json:"max_fee"`
+	// account credited with the fees, in the currency of the rule
+	RevenueAccountID int64     `json:"revenue_account_id"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type Hold struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	// must be positive
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	// authorized, captured, voided or expired
+	Status string `json:"status"`
+	// amount moved on capture, the rest of the hold is released
+	CapturedAmount int64 `json:"captured_amount"`
+	// set once the hold has been captured
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+	// held on top of the amount and charged in full on capture
+	Fee int64 `json:"fee"`
+	// account credited with the fee on capture, set when the hold has a fee
+	FeeAccountID sql.NullInt64 `json:"fee_account_id"`
+}
+
+type Journal struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginAttempt struct {
+	ID int64 `json:"id"`
+	// not a foreign key, attempts for unknown usernames are recorded too
+	Username  string    `json:"username"`
+	ClientIp  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent"`
+	Succeeded bool      `json:"succeeded"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MfaRecoveryCode struct {
+	ID        int64        `json:"id"`
+	Username  string       `json:"username"`
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// previous password hashes of each user, used to refuse reusing them
+type PasswordHistory struct {
+	ID             int64     `json:"id"`
+	Username       string    `json:"username"`
+	HashedPassword string    `json:"hashed_password"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the token sent in the reset email
+	TokenHash string `json:"token_hash"`
+	// set when the token is used or when another token resets the password
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+	ExpiredAt time.Time    `json:"expired_at"`
+}
+
+type Payee struct {
+	ID       int64  `json:"id"`
+	Owner    string `json:"owner"`
+	Nickname string `json:"nickname"`
+	// account transfers to the payee are credited to
+	AccountID int64  `json:"account_id"`
+	Currency  string `json:"currency"`
+	// large transfers to the payee are refused until then
+	CoolingOffUntil time.Time `json:"cooling_off_until"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type PaymentRequest struct {
+	ID        int64  `json:"id"`
+	Requester string `json:"requester"`
+	Payer     string `json:"payer"`
+	// account of the requester credited when the payer accepts
+	ToAccountID int64  `json:"to_account_id"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Description string `json:"description"`
+	// pending, accepted, declined, cancelled or expired
+	Status string `json:"status"`
+	// transfer paying the request once accepted
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// null when the request never expires
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+type PaymentRequestEvent struct {
+	ID               int64  `json:"id"`
+	PaymentRequestID int64  `json:"payment_request_id"`
+	Status           string `json:"status"`
+	// user who changed the status, empty when the request expired
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RateLimitBucket struct {
+	Key    string  `json:"key"`
+	Tokens float64 `json:"tokens"`
+	// whether the last request taken from the bucket was allowed
+	Allowed   bool      `json:"allowed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	// must be positive
+	Amount    int64     `json:"amount"`
+	Currency  string    `json:"currency"`
+	ExecuteAt time.Time `json:"execute_at"`
+	// pending, succeeded, failed or cancelled
+	Status string `json:"status"`
+	// set once the transfer has been executed
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type ScheduledTransferAttempt struct {
+	ID                  int64         `json:"id"`
+	ScheduledTransferID int64         `json:"scheduled_transfer_id"`
+	Succeeded           bool          `json:"succeeded"`
+	FailureReason       string        `json:"failure_reason"`
+	TransferID          sql.NullInt64 `json:"transfer_id"`
+	CreatedAt           time.Time     `json:"created_at"`
+}
+
+type StandingOrder struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	// must be positive
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	// daily, weekly or monthly
+	Frequency string `json:"frequency"`
+	// day monthly orders are paid on, zero for other frequencies
+	DayOfMonth int32        `json:"day_of_month"`
+	StartAt    time.Time    `json:"start_at"`
+	EndAt      sql.NullTime `json:"end_at"`
+	// null when the order only ends at end_at or when cancelled
+	MaxOccurrences sql.NullInt32 `json:"max_occurrences"`
+	// number of past occurrences, paid or failed
+	Occurrences      int32     `json:"occurrences"`
+	NextOccurrenceAt time.Time `json:"next_occurrence_at"`
+	// next execution attempt, later than next_occurrence_at while retrying
+	NextRunAt time.Time `json:"next_run_at"`
+	// failed attempts of the next occurrence
+	Retries int32 `json:"retries"`
+	// active, completed or cancelled
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type StandingOrderExecution struct {
+	ID              int64 `json:"id"`
+	StandingOrderID int64 `json:"standing_order_id"`
+	// the occurrence the execution attempt was for
+	OccurrenceAt  time.Time     `json:"occurrence_at"`
+	Succeeded     bool          `json:"succeeded"`
+	FailureReason string        `json:"failure_reason"`
+	TransferID    sql.NullInt64 `json:"transfer_id"`
+	CreatedAt     time.Time     `json:"created_at"`
 }
 
 type Transfer struct {
@@ -31,6 +258,68 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// set on reversals, the transfer they fully or partially refund
+	ReversesTransferID sql.NullInt64 `json:"reverses_transfer_id"`
+	// free text memo of what the transfer was for
+	Description string `json:"description"`
+	// reference of the payment in an external system, used for reconciliation
+	ExternalReference string `json:"external_reference"`
+	// json object of client defined key values, searchable by containment
+	Metadata json.RawMessage `json:"metadata"`
+	// journal holding the entries of the transfer
+	JournalID sql.NullInt64 `json:"journal_id"`
+	// charged to the from account on top of the amount
+	Fee int64 `json:"fee"`
+	// pending, completed, rejected or expired, only completed transfers moved money
+	Status string `json:"status"`
+	// user who requested a transfer needing approval, who cannot approve it
+	RequestedBy string `json:"requested_by"`
+	// approvals the transfer waits for while pending
+	RequiredApprovals int32 `json:"required_approvals"`
+	// a pending transfer not approved by then expires
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	// when the transfer was posted, counted against the transfer limits
+	CompletedAt sql.NullTime `json:"completed_at"`
+}
+
+type TransferApproval struct {
+	TransferID int64  `json:"transfer_id"`
+	Approver   string `json:"approver"`
+	// false when the approver rejected the transfer
+	Approved  bool      `json:"approved"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TransferBatch struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	Currency      string `json:"currency"`
+	// all items are transferred in one transaction, otherwise each item on its own
+	Atomic bool `json:"atomic"`
+	// sum of the item amounts
+	TotalAmount int64 `json:"total_amount"`
+	// pending, succeeded, partially_succeeded or failed
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	// chosen by the client, a batch created again with the same key returns the first one
+	IdempotencyKey sql.NullString `json:"idempotency_key"`
+}
+
+type TransferBatchItem struct {
+	ID          int64 `json:"id"`
+	BatchID     int64 `json:"batch_id"`
+	ToAccountID int64 `json:"to_account_id"`
+	// must be positive
+	Amount            int64  `json:"amount"`
+	Description       string `json:"description"`
+	ExternalReference string `json:"external_reference"`
+	// pending, succeeded or failed
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"`
+	// set once the item has been transferred
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	CreatedAt  time.Time     `json:"created_at"`
 }
 
 type User struct {
@@ -40,4 +329,44 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	// depositor or banker
+	Role string `json:"role"`
+	// new email the user asked for, it replaces email once its verification code is used
+	PendingEmail sql.NullString `json:"pending_email"`
+}
+
+type UserTotp struct {
+	Username        string `json:"username"`
+	EncryptedSecret string `json:"encrypted_secret"`
+	// null until the first code has been verified
+	ConfirmedAt sql.NullTime `json:"confirmed_at"`
+	// time step of the last accepted code, older codes are refused
+	LastUsedStep int64     `json:"last_used_step"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// limits the outgoing transfers of all accounts of a user in a currency
+type UserTransferLimit struct {
+	Username string `json:"username"`
+	Currency string `json:"currency"`
+	// zero means no limit, as for daily and monthly
+	PerTransaction int64 `json:"per_transaction"`
+	// total of the calendar day in UTC
+	Daily int64 `json:"daily"`
+	// total of the calendar month in UTC
+	Monthly   int64     `json:"monthly"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type VerifyEmail struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// address the code was sent to, it only verifies the user while it is still their email
+	Email string `json:"email"`
+	// sha256 of the secret code sent in the verification link
+	SecretCodeHash string    `json:"secret_code_hash"`
+	IsUsed         bool      `json:"is_used"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiredAt      time.Time `json:"expired_at"`
 }
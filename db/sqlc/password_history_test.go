@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestListPasswordHistory(t *testing.T) {
+	user := createRandomUser(t)
+	hashes := []string{user.HashedPassword}
+
+	for i := 0; i < 3; i++ {
+		err := testQueries.ArchiveUserPassword(context.Background(), user.Username)
+		require.NoError(t, err)
+
+		hashedPassword := "hash-" + user.Username + string(rune('a'+i))
+		user, err = testQueries.UpdateUser(context.Background(), UpdateUserParams{
+			Username:       user.Username,
+			HashedPassword: sql.NullString{String: hashedPassword, Valid: true},
+		})
+		require.NoError(t, err)
+		hashes = append(hashes, hashedPassword)
+	}
+
+	history, err := testQueries.ListPasswordHistory(context.Background(), ListPasswordHistoryParams{
+		Username: user.Username,
+		Limit:    2,
+	})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	// newest first, the current password is not part of the history
+	require.Equal(t, hashes[2], history[0].HashedPassword)
+	require.Equal(t, hashes[1], history[1].HashedPassword)
+	for _, entry := range history {
+		require.Equal(t, user.Username, entry.Username)
+		require.NotZero(t, entry.CreatedAt)
+	}
+}
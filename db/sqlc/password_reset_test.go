@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomPasswordResetToken(t *testing.T, username string, expiredAt time.Time) (PasswordResetToken, string) {
+	resetToken := utils.RandomString(32)
+	arg := CreatePasswordResetTokenParams{
+		Username:  username,
+		TokenHash: utils.HashToken(resetToken),
+		ExpiredAt: expiredAt,
+	}
+	passwordResetToken, err := testQueries.CreatePasswordResetToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, passwordResetToken.Username)
+	require.Equal(t, arg.TokenHash, passwordResetToken.TokenHash)
+	require.False(t, passwordResetToken.UsedAt.Valid)
+	require.WithinDuration(t, expiredAt, passwordResetToken.ExpiredAt, time.Second)
+	return passwordResetToken, resetToken
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	_, resetToken := createRandomPasswordResetToken(t, user.Username, time.Now().Add(time.Hour))
+	_, otherResetToken := createRandomPasswordResetToken(t, user.Username, time.Now().Add(time.Hour))
+
+	hashedPassword, err := utils.HashPassword(utils.RandomString(8))
+	require.NoError(t, err)
+	arg := ResetPasswordTxParams{
+		TokenHash:      utils.HashToken(resetToken),
+		HashedPassword: hashedPassword,
+		ChangedAt:      time.Now(),
+	}
+	result, err := store.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.User.Username)
+	require.Equal(t, hashedPassword, result.User.HashedPassword)
+	require.WithinDuration(t, arg.ChangedAt, result.User.PasswordChangedAt, time.Second)
+
+	// the previous password is kept for the reuse check
+	history, err := testQueries.ListPasswordHistory(context.Background(), ListPasswordHistoryParams{
+		Username: user.Username,
+		Limit:    5,
+	})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, user.HashedPassword, history[0].HashedPassword)
+
+	// the token can only be used once
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// other outstanding tokens are invalidated
+	arg.TokenHash = utils.HashToken(otherResetToken)
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestResetPasswordTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	_, resetToken := createRandomPasswordResetToken(t, user.Username, time.Now().Add(-time.Minute))
+
+	_, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:      utils.HashToken(resetToken),
+		HashedPassword: user.HashedPassword,
+		ChangedAt:      time.Now(),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestGetPasswordChangedAt(t *testing.T) {
+	user := createRandomUser(t)
+
+	passwordChangedAt, err := testQueries.GetPasswordChangedAt(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.True(t, passwordChangedAt.Before(user.CreatedAt))
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomPayee(t *testing.T, owner string, account Account) Payee {
+	arg := CreatePayeeParams{
+		Owner:           owner,
+		Nickname:        utils.RandomOwner(),
+		AccountID:       account.ID,
+		Currency:        account.Currency,
+		CoolingOffUntil: time.Now().Add(time.Hour),
+	}
+	payee, err := testQueries.CreatePayee(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, payee.ID)
+	require.Equal(t, arg.Owner, payee.Owner)
+	require.Equal(t, arg.Nickname, payee.Nickname)
+	require.Equal(t, arg.AccountID, payee.AccountID)
+	require.Equal(t, arg.Currency, payee.Currency)
+	require.WithinDuration(t, arg.CoolingOffUntil, payee.CoolingOffUntil, time.Second)
+	require.NotZero(t, payee.CreatedAt)
+	return payee
+}
+
+func TestCreatePayee(t *testing.T) {
+	user := createRandomUser(t)
+	account := createRandomAccount(t)
+	payee := createRandomPayee(t, user.Username, account)
+
+	// the same account cannot be saved twice under another nickname
+	_, err := testQueries.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:           user.Username,
+		Nickname:        utils.RandomOwner(),
+		AccountID:       payee.AccountID,
+		Currency:        payee.Currency,
+		CoolingOffUntil: time.Now(),
+	})
+	require.Error(t, err)
+	require.Equal(t, "unique_violation", err.(*pq.Error).Code.Name())
+}
+
+func TestGetPayeeByAccount(t *testing.T) {
+	user := createRandomUser(t)
+	account := createRandomAccount(t)
+	payee := createRandomPayee(t, user.Username, account)
+
+	got, err := testQueries.GetPayeeByAccount(context.Background(), GetPayeeByAccountParams{
+		Owner:     user.Username,
+		AccountID: account.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, payee.ID, got.ID)
+
+	// payees of other users are not found
+	other := createRandomUser(t)
+	_, err = testQueries.GetPayeeByAccount(context.Background(), GetPayeeByAccountParams{
+		Owner:     other.Username,
+		AccountID: account.ID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListPayees(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomPayee(t, user.Username, createRandomAccount(t))
+	}
+
+	payees, err := testQueries.ListPayees(context.Background(), ListPayeesParams{
+		Owner:  user.Username,
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, payees, 3)
+	for i, payee := range payees {
+		require.Equal(t, user.Username, payee.Owner)
+		if i > 0 {
+			require.LessOrEqual(t, payees[i-1].Nickname, payee.Nickname)
+		}
+	}
+}
+
+func TestUpdateAndDeletePayee(t *testing.T) {
+	user := createRandomUser(t)
+	payee := createRandomPayee(t, user.Username, createRandomAccount(t))
+	ctx := context.Background()
+
+	nickname := utils.RandomOwner()
+	updated, err := testQueries.UpdatePayeeNickname(ctx, UpdatePayeeNicknameParams{
+		Nickname: nickname,
+		ID:       payee.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, nickname, updated.Nickname)
+	require.Equal(t, payee.AccountID, updated.AccountID)
+	require.WithinDuration(t, payee.CoolingOffUntil, updated.CoolingOffUntil, time.Second)
+
+	err = testQueries.DeletePayee(ctx, payee.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetPayee(ctx, payee.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// createRandomPaymentRequest asks the owner of a new account to pay 10 into another new account
+func createRandomPaymentRequest(t *testing.T, store Store, expiresAt sql.NullTime) (Account, Account, PaymentRequest) {
+	toAccount := createRandomAccount(t)
+	fromAccount := createRandomAccountWithCurrency(t, toAccount.Currency)
+
+	result, err := store.CreatePaymentRequestTx(context.Background(), CreatePaymentRequestTxParams{
+		CreatePaymentRequestParams: CreatePaymentRequestParams{
+			Requester:   toAccount.Owner,
+			Payer:       fromAccount.Owner,
+			ToAccountID: toAccount.ID,
+			Amount:      10,
+			Currency:    toAccount.Currency,
+			Description: utils.RandomString(12),
+			ExpiresAt:   expiresAt,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.PaymentRequestStatusPending, result.PaymentRequest.Status)
+	require.False(t, result.PaymentRequest.TransferID.Valid)
+	require.Equal(t, result.PaymentRequest.ID, result.Event.PaymentRequestID)
+	require.Equal(t, toAccount.Owner, result.Event.Actor)
+	return fromAccount, toAccount, result.PaymentRequest
+}
+
+func TestAcceptPaymentRequestTx(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount, toAccount, request := createRandomPaymentRequest(t, store, sql.NullTime{})
+	ctx := context.Background()
+
+	result, err := store.AcceptPaymentRequestTx(ctx, AcceptPaymentRequestTxParams{
+		PaymentRequestID: request.ID,
+		Payer:            fromAccount.Owner,
+		FromAccountID:    fromAccount.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.PaymentRequestStatusAccepted, result.PaymentRequest.Status)
+	require.Equal(t, result.Transfer.Transfer.ID, result.PaymentRequest.TransferID.Int64)
+	require.Equal(t, request.Description, result.Transfer.Transfer.Description)
+	require.Equal(t, fromAccount.Balance-10, result.Transfer.FromAccount.Balance)
+	require.Equal(t, toAccount.Balance+10, result.Transfer.ToAccount.Balance)
+
+	_, err = store.AcceptPaymentRequestTx(ctx, AcceptPaymentRequestTxParams{
+		PaymentRequestID: request.ID,
+		Payer:            fromAccount.Owner,
+		FromAccountID:    fromAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+
+	events, err := testQueries.ListPaymentRequestEvents(ctx, request.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, utils.PaymentRequestStatusPending, events[0].Status)
+	require.Equal(t, utils.PaymentRequestStatusAccepted, events[1].Status)
+	require.Equal(t, fromAccount.Owner, events[1].Actor)
+}
+
+func TestAcceptPaymentRequestTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount, _, request := createRandomPaymentRequest(t, store, sql.NullTime{})
+	ctx := context.Background()
+
+	// a hold reserves all but 9 of the balance, the 10 requested cannot be covered
+	_, err := store.AuthorizeTransferTx(ctx, AuthorizeTransferTxParams{
+		Owner:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   request.ToAccountID,
+		Amount:        fromAccount.Balance - 9,
+		Currency:      fromAccount.Currency,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	_, err = store.AcceptPaymentRequestTx(ctx, AcceptPaymentRequestTxParams{
+		PaymentRequestID: request.ID,
+		Payer:            fromAccount.Owner,
+		FromAccountID:    fromAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the request stays pending and nothing moved
+	got, err := testQueries.GetPaymentRequest(ctx, request.ID)
+	require.NoError(t, err)
+	require.Equal(t, utils.PaymentRequestStatusPending, got.Status)
+
+	updated, err := testQueries.GetAccount(ctx, fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance, updated.Balance)
+}
+
+func TestUpdatePaymentRequestStatusTx(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount, toAccount, request := createRandomPaymentRequest(t, store, sql.NullTime{})
+	ctx := context.Background()
+
+	result, err := store.UpdatePaymentRequestStatusTx(ctx, UpdatePaymentRequestStatusTxParams{
+		PaymentRequestID: request.ID,
+		Status:           utils.PaymentRequestStatusDeclined,
+		Actor:            fromAccount.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.PaymentRequestStatusDeclined, result.PaymentRequest.Status)
+	require.Equal(t, fromAccount.Owner, result.Event.Actor)
+
+	_, err = store.UpdatePaymentRequestStatusTx(ctx, UpdatePaymentRequestStatusTxParams{
+		PaymentRequestID: request.ID,
+		Status:           utils.PaymentRequestStatusCancelled,
+		Actor:            toAccount.Owner,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+}
+
+func TestExpirePaymentRequestsTx(t *testing.T) {
+	store := NewStore(testDB)
+	expiresAt := sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}
+	fromAccount, _, request := createRandomPaymentRequest(t, store, expiresAt)
+	ctx := context.Background()
+
+	result, err := store.ExpirePaymentRequestsTx(ctx, ExpirePaymentRequestsTxParams{Now: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	var found bool
+	for _, expired := range result.PaymentRequests {
+		require.Equal(t, utils.PaymentRequestStatusExpired, expired.Status)
+		found = found || expired.ID == request.ID
+	}
+	require.True(t, found)
+
+	_, err = store.AcceptPaymentRequestTx(ctx, AcceptPaymentRequestTxParams{
+		PaymentRequestID: request.ID,
+		Payer:            fromAccount.Owner,
+		FromAccountID:    fromAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+
+	events, err := testQueries.ListPaymentRequestEvents(ctx, request.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, utils.PaymentRequestStatusExpired, events[1].Status)
+	require.Empty(t, events[1].Actor)
+}
@@ -6,24 +6,128 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddAccountHeldBalance(ctx context.Context, arg AddAccountHeldBalanceParams) (Account, error)
+	ArchiveUserPassword(ctx context.Context, username string) error
+	CancelScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	CancelStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	ClaimDueScheduledTransfer(ctx context.Context, now time.Time) (ScheduledTransfer, error)
+	ClaimDueStandingOrder(ctx context.Context, now time.Time) (StandingOrder, error)
+	ClaimExpiredHold(ctx context.Context, now time.Time) (Hold, error)
+	ClaimStaleTransferBatch(ctx context.Context, before time.Time) (TransferBatch, error)
+	CompleteTransfer(ctx context.Context, arg CompleteTransferParams) (Transfer, error)
+	ConfirmUserPendingEmail(ctx context.Context, arg ConfirmUserPendingEmailParams) (User, error)
+	ConfirmUserTOTP(ctx context.Context, arg ConfirmUserTOTPParams) (UserTotp, error)
+	CountTransferApprovals(ctx context.Context, transferID int64) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountApprover(ctx context.Context, arg CreateAccountApproverParams) (AccountApprover, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateFeeRule(ctx context.Context, arg CreateFeeRuleParams) (FeeRule, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CreateJournal(ctx context.Context) (Journal, error)
+	CreateLoginAttempt(ctx context.Context, arg CreateLoginAttemptParams) (LoginAttempt, error)
+	CreateMFARecoveryCode(ctx context.Context, arg CreateMFARecoveryCodeParams) (MfaRecoveryCode, error)
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
+	CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error)
+	CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error)
+	CreatePaymentRequestEvent(ctx context.Context, arg CreatePaymentRequestEventParams) (PaymentRequestEvent, error)
+	CreatePendingTransfer(ctx context.Context, arg CreatePendingTransferParams) (Transfer, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateScheduledTransferAttempt(ctx context.Context, arg CreateScheduledTransferAttemptParams) (ScheduledTransferAttempt, error)
+	CreateStandingOrder(ctx context.Context, arg CreateStandingOrderParams) (StandingOrder, error)
+	CreateStandingOrderExecution(ctx context.Context, arg CreateStandingOrderExecutionParams) (StandingOrderExecution, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferApproval(ctx context.Context, arg CreateTransferApprovalParams) (TransferApproval, error)
+	CreateTransferBatch(ctx context.Context, arg CreateTransferBatchParams) (TransferBatch, error)
+	CreateTransferBatchItem(ctx context.Context, arg CreateTransferBatchItemParams) (TransferBatchItem, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteAccountApprovers(ctx context.Context, accountID int64) error
+	DeleteMFARecoveryCodes(ctx context.Context, username string) error
+	DeletePayee(ctx context.Context, id int64) error
+	DeleteStaleRateLimitBuckets(ctx context.Context, updatedAt time.Time) error
+	ExpirePaymentRequests(ctx context.Context, now time.Time) ([]PaymentRequest, error)
+	ExpirePendingTransfers(ctx context.Context, now time.Time) ([]Transfer, error)
+	FinishHold(ctx context.Context, arg FinishHoldParams) (Hold, error)
+	FinishPaymentRequest(ctx context.Context, arg FinishPaymentRequestParams) (PaymentRequest, error)
+	FinishScheduledTransfer(ctx context.Context, arg FinishScheduledTransferParams) (ScheduledTransfer, error)
+	FinishTransferBatch(ctx context.Context, arg FinishTransferBatchParams) (TransferBatch, error)
+	FinishTransferBatchItem(ctx context.Context, arg FinishTransferBatchItemParams) (TransferBatchItem, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountApprover(ctx context.Context, arg GetAccountApproverParams) (AccountApprover, error)
+	GetAccountByAlias(ctx context.Context, alias sql.NullString) (Account, error)
+	GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error)
+	GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error)
+	GetAccountOutgoingAmount(ctx context.Context, arg GetAccountOutgoingAmountParams) (int64, error)
+	GetAccountTransferLimit(ctx context.Context, accountID int64) (AccountTransferLimit, error)
 	GetAccountWithUpdate(ctx context.Context, id int64) (Account, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetFeeRule(ctx context.Context, arg GetFeeRuleParams) (FeeRule, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldWithUpdate(ctx context.Context, id int64) (Hold, error)
+	GetLoginFailures(ctx context.Context, arg GetLoginFailuresParams) (GetLoginFailuresRow, error)
+	GetOwnerOutgoingAmount(ctx context.Context, arg GetOwnerOutgoingAmountParams) (int64, error)
+	GetPasswordChangedAt(ctx context.Context, username string) (time.Time, error)
+	GetPasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	GetPayee(ctx context.Context, id int64) (Payee, error)
+	GetPayeeByAccount(ctx context.Context, arg GetPayeeByAccountParams) (Payee, error)
+	GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error)
+	GetPaymentRequestForUpdate(ctx context.Context, id int64) (PaymentRequest, error)
+	GetReversedAmount(ctx context.Context, reversesTransferID sql.NullInt64) (int64, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferBatch(ctx context.Context, id int64) (TransferBatch, error)
+	GetTransferBatchByIdempotencyKey(ctx context.Context, arg GetTransferBatchByIdempotencyKeyParams) (TransferBatch, error)
+	GetTransferBatchItemForUpdate(ctx context.Context, id int64) (TransferBatchItem, error)
+	GetTransferWithUpdate(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserTOTP(ctx context.Context, username string) (UserTotp, error)
+	GetUserTransferLimitForUpdate(ctx context.Context, arg GetUserTransferLimitForUpdateParams) (UserTransferLimit, error)
+	InvalidatePasswordResetTokens(ctx context.Context, username string) error
+	ListAccountApprovers(ctx context.Context, accountID int64) ([]AccountApprover, error)
+	ListAccountTransferLimits(ctx context.Context, owner string) ([]AccountTransferLimit, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListJournalEntries(ctx context.Context, journalID sql.NullInt64) ([]Entry, error)
+	ListPasswordHistory(ctx context.Context, arg ListPasswordHistoryParams) ([]PasswordHistory, error)
+	ListPayees(ctx context.Context, arg ListPayeesParams) ([]Payee, error)
+	ListPaymentRequestEvents(ctx context.Context, paymentRequestID int64) ([]PaymentRequestEvent, error)
+	ListPaymentRequests(ctx context.Context, arg ListPaymentRequestsParams) ([]PaymentRequest, error)
+	ListScheduledTransferAttempts(ctx context.Context, scheduledTransferID int64) ([]ScheduledTransferAttempt, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error)
+	ListStandingOrderExecutions(ctx context.Context, standingOrderID int64) ([]StandingOrderExecution, error)
+	ListStandingOrders(ctx context.Context, arg ListStandingOrdersParams) ([]StandingOrder, error)
+	ListTransferApprovals(ctx context.Context, transferID int64) ([]TransferApproval, error)
+	ListTransferBatchItems(ctx context.Context, batchID int64) ([]TransferBatchItem, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListUserTransferLimits(ctx context.Context, username string) ([]UserTransferLimit, error)
+	SearchTransfers(ctx context.Context, arg SearchTransfersParams) ([]Transfer, error)
+	SetUserPendingEmail(ctx context.Context, arg SetUserPendingEmailParams) (User, error)
+	TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (TakeRateLimitTokenRow, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountAlias(ctx context.Context, arg UpdateAccountAliasParams) (Account, error)
+	UpdateAccountApprovalPolicy(ctx context.Context, arg UpdateAccountApprovalPolicyParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateAccountType(ctx context.Context, arg UpdateAccountTypeParams) (Account, error)
+	UpdatePayeeNickname(ctx context.Context, arg UpdatePayeeNicknameParams) (Payee, error)
+	UpdateStandingOrderSchedule(ctx context.Context, arg UpdateStandingOrderScheduleParams) (StandingOrder, error)
+	UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfer, error)
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpsertAccountTransferLimit(ctx context.Context, arg UpsertAccountTransferLimitParams) (AccountTransferLimit, error)
+	UpsertUserTOTP(ctx context.Context, arg UpsertUserTOTPParams) (UserTotp, error)
+	UpsertUserTransferLimit(ctx context.Context, arg UpsertUserTransferLimitParams) (UserTransferLimit, error)
+	UseMFARecoveryCode(ctx context.Context, arg UseMFARecoveryCodeParams) (MfaRecoveryCode, error)
+	UsePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (UserTotp, error)
+	UseVerifyEmail(ctx context.Context, arg UseVerifyEmailParams) (VerifyEmail, error)
 }
 
 var _ Querier = (*Queries)(nil)
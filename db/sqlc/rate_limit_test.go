@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTakeRateLimitToken(t *testing.T) {
+	ctx := context.Background()
+	arg := TakeRateLimitTokenParams{
+		Key:   utils.RandomString(12),
+		Burst: 2,
+		Rate:  0.001,
+	}
+
+	row, err := testQueries.TakeRateLimitToken(ctx, arg)
+	require.NoError(t, err)
+	require.True(t, row.Allowed)
+	require.InDelta(t, 1, row.Tokens, 0.01)
+
+	row, err = testQueries.TakeRateLimitToken(ctx, arg)
+	require.NoError(t, err)
+	require.True(t, row.Allowed)
+	require.InDelta(t, 0, row.Tokens, 0.01)
+
+	row, err = testQueries.TakeRateLimitToken(ctx, arg)
+	require.NoError(t, err)
+	require.False(t, row.Allowed)
+	require.Less(t, row.Tokens, float64(1))
+}
+
+func TestDeleteStaleRateLimitBuckets(t *testing.T) {
+	ctx := context.Background()
+	arg := TakeRateLimitTokenParams{
+		Key:   utils.RandomString(12),
+		Burst: 1,
+		Rate:  1,
+	}
+	_, err := testQueries.TakeRateLimitToken(ctx, arg)
+	require.NoError(t, err)
+
+	err = testQueries.DeleteStaleRateLimitBuckets(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	// the bucket starts full again once it has been deleted
+	arg.Rate = 0.001
+	row, err := testQueries.TakeRateLimitToken(ctx, arg)
+	require.NoError(t, err)
+	require.True(t, row.Allowed)
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomScheduledTransfer(t *testing.T, fromAccount, toAccount Account, amount int64, executeAt time.Time) ScheduledTransfer {
+	arg := CreateScheduledTransferParams{
+		Owner:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+		Currency:      fromAccount.Currency,
+		ExecuteAt:     executeAt,
+	}
+	scheduledTransfer, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Owner, scheduledTransfer.Owner)
+	require.Equal(t, arg.FromAccountID, scheduledTransfer.FromAccountID)
+	require.Equal(t, arg.ToAccountID, scheduledTransfer.ToAccountID)
+	require.Equal(t, arg.Amount, scheduledTransfer.Amount)
+	require.Equal(t, arg.Currency, scheduledTransfer.Currency)
+	require.WithinDuration(t, arg.ExecuteAt, scheduledTransfer.ExecuteAt, time.Second)
+	require.Equal(t, utils.ScheduledTransferStatusPending, scheduledTransfer.Status)
+	require.False(t, scheduledTransfer.TransferID.Valid)
+	return scheduledTransfer
+}
+
+// executeAtInThePast returns a time before every scheduled transfer other tests create,
+// so that executing transfers due by then only claims the ones of the calling test
+func executeAtInThePast() time.Time {
+	return time.Now().AddDate(-100, 0, -int(utils.RandomInt(0, 3650))).Truncate(time.Second)
+}
+
+func TestExecuteScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	executeAt := executeAtInThePast()
+	scheduledTransfer := createRandomScheduledTransfer(t, account1, account2, account1.Balance, executeAt)
+
+	// not due yet
+	_, err := store.ExecuteScheduledTransferTx(context.Background(), ExecuteScheduledTransferTxParams{Now: executeAt.Add(-time.Second)})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	result, err := store.ExecuteScheduledTransferTx(context.Background(), ExecuteScheduledTransferTxParams{Now: executeAt})
+	require.NoError(t, err)
+	require.Equal(t, scheduledTransfer.ID, result.ScheduledTransfer.ID)
+	require.Equal(t, utils.ScheduledTransferStatusSucceeded, result.ScheduledTransfer.Status)
+	require.True(t, result.Attempt.Succeeded)
+	require.NotNil(t, result.Transfer)
+	require.Equal(t, result.Transfer.Transfer.ID, result.ScheduledTransfer.TransferID.Int64)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Attempt.TransferID.Int64)
+	require.Zero(t, result.Transfer.FromAccount.Balance)
+	require.Equal(t, account2.Balance+account1.Balance, result.Transfer.ToAccount.Balance)
+
+	// executed only once
+	_, err = store.ExecuteScheduledTransferTx(context.Background(), ExecuteScheduledTransferTxParams{Now: executeAt})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	attempts, err := testQueries.ListScheduledTransferAttempts(context.Background(), scheduledTransfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, []ScheduledTransferAttempt{result.Attempt}, attempts)
+}
+
+func TestExecuteScheduledTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	executeAt := executeAtInThePast()
+	scheduledTransfer := createRandomScheduledTransfer(t, account1, account2, account1.Balance+1, executeAt)
+
+	result, err := store.ExecuteScheduledTransferTx(context.Background(), ExecuteScheduledTransferTxParams{Now: executeAt})
+	require.NoError(t, err)
+	require.Equal(t, scheduledTransfer.ID, result.ScheduledTransfer.ID)
+	require.Equal(t, utils.ScheduledTransferStatusFailed, result.ScheduledTransfer.Status)
+	require.False(t, result.ScheduledTransfer.TransferID.Valid)
+	require.False(t, result.Attempt.Succeeded)
+	require.Contains(t, result.Attempt.FailureReason, ErrInsufficientFunds.Error())
+	require.Nil(t, result.Transfer)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestExecuteScheduledTransferTxPayeeCoolingOff(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	executeAt := executeAtInThePast()
+	scheduledTransfer := createRandomScheduledTransfer(t, account1, account2, 10, executeAt)
+
+	// the payee was added after the transfer was scheduled
+	createRandomPayee(t, account1.Owner, account2)
+
+	result, err := store.ExecuteScheduledTransferTx(context.Background(), ExecuteScheduledTransferTxParams{
+		Now:                       executeAt,
+		PayeeCoolingOffThresholds: map[string]int64{account1.Currency: 10},
+	})
+	require.NoError(t, err)
+	require.Equal(t, scheduledTransfer.ID, result.ScheduledTransfer.ID)
+	require.Equal(t, utils.ScheduledTransferStatusFailed, result.ScheduledTransfer.Status)
+	require.Contains(t, result.Attempt.FailureReason, ErrPayeeCoolingOff.Error())
+	require.Nil(t, result.Transfer)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	scheduledTransfer := createRandomScheduledTransfer(t, account1, account2, 10, time.Now().Add(time.Hour))
+
+	cancelled, err := testQueries.CancelScheduledTransfer(context.Background(), scheduledTransfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, utils.ScheduledTransferStatusCancelled, cancelled.Status)
+
+	// only pending transfers can be cancelled
+	_, err = testQueries.CancelScheduledTransfer(context.Background(), scheduledTransfer.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListScheduledTransfers(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	for i := 0; i < 5; i++ {
+		createRandomScheduledTransfer(t, account1, account2, 10, time.Now().Add(time.Duration(i+1)*time.Hour))
+	}
+
+	scheduledTransfers, err := testQueries.ListScheduledTransfers(context.Background(), ListScheduledTransfersParams{
+		Owner:  account1.Owner,
+		Limit:  3,
+		Offset: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, scheduledTransfers, 3)
+	for i, scheduledTransfer := range scheduledTransfers {
+		require.Equal(t, account1.Owner, scheduledTransfer.Owner)
+		if i > 0 {
+			require.True(t, scheduledTransfer.ExecuteAt.After(scheduledTransfers[i-1].ExecuteAt))
+		}
+	}
+}
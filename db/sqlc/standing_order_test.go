@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomStandingOrder(t *testing.T, fromAccount, toAccount Account, amount int64, startAt time.Time, count int32) StandingOrder {
+	arg := CreateStandingOrderParams{
+		Owner:            fromAccount.Owner,
+		FromAccountID:    fromAccount.ID,
+		ToAccountID:      toAccount.ID,
+		Amount:           amount,
+		Currency:         fromAccount.Currency,
+		Frequency:        utils.FrequencyDaily,
+		StartAt:          startAt,
+		MaxOccurrences:   sql.NullInt32{Int32: count, Valid: true},
+		NextOccurrenceAt: startAt,
+	}
+	order, err := testQueries.CreateStandingOrder(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Owner, order.Owner)
+	require.Equal(t, arg.Amount, order.Amount)
+	require.Equal(t, arg.Frequency, order.Frequency)
+	require.Equal(t, arg.MaxOccurrences, order.MaxOccurrences)
+	require.False(t, order.EndAt.Valid)
+	require.WithinDuration(t, startAt, order.NextOccurrenceAt, time.Second)
+	require.WithinDuration(t, startAt, order.NextRunAt, time.Second)
+	require.Zero(t, order.Occurrences)
+	require.Equal(t, utils.StandingOrderStatusActive, order.Status)
+	return order
+}
+
+func TestExecuteStandingOrderTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	startAt := executeAtInThePast()
+	order := createRandomStandingOrder(t, account1, account2, 1, startAt, 2)
+
+	arg := ExecuteStandingOrderTxParams{Now: startAt, MaxRetries: 1, RetryInterval: time.Hour}
+	result, err := store.ExecuteStandingOrderTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, order.ID, result.StandingOrder.ID)
+	require.True(t, result.Execution.Succeeded)
+	require.NotNil(t, result.Transfer)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Execution.TransferID.Int64)
+	require.WithinDuration(t, startAt, result.Execution.OccurrenceAt, time.Second)
+	require.Equal(t, int32(1), result.StandingOrder.Occurrences)
+	require.WithinDuration(t, startAt.AddDate(0, 0, 1), result.StandingOrder.NextOccurrenceAt, time.Second)
+	require.WithinDuration(t, startAt.AddDate(0, 0, 1), result.StandingOrder.NextRunAt, time.Second)
+
+	// the next occurrence is not due yet
+	_, err = store.ExecuteStandingOrderTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the second and last occurrence completes the order
+	arg.Now = startAt.AddDate(0, 0, 1)
+	result, err = store.ExecuteStandingOrderTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.Execution.Succeeded)
+	require.Equal(t, int32(2), result.StandingOrder.Occurrences)
+	require.Equal(t, utils.StandingOrderStatusCompleted, result.StandingOrder.Status)
+
+	_, err = store.ExecuteStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{Now: startAt.AddDate(0, 0, 10)})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestExecuteStandingOrderTxRetry(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	startAt := executeAtInThePast()
+	order := createRandomStandingOrder(t, account1, account2, account1.Balance+1, startAt, 5)
+
+	arg := ExecuteStandingOrderTxParams{Now: startAt, MaxRetries: 1, RetryInterval: time.Hour}
+	result, err := store.ExecuteStandingOrderTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, order.ID, result.StandingOrder.ID)
+	require.False(t, result.Execution.Succeeded)
+	require.Contains(t, result.Execution.FailureReason, ErrInsufficientFunds.Error())
+	require.True(t, result.WillRetry)
+	require.Equal(t, int32(1), result.StandingOrder.Retries)
+	require.Zero(t, result.StandingOrder.Occurrences)
+	require.WithinDuration(t, startAt.Add(time.Hour), result.StandingOrder.NextRunAt, time.Second)
+
+	// out of retries, the occurrence is skipped
+	arg.Now = startAt.Add(time.Hour)
+	result, err = store.ExecuteStandingOrderTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, result.Execution.Succeeded)
+	require.False(t, result.WillRetry)
+	require.Zero(t, result.StandingOrder.Retries)
+	require.Equal(t, int32(1), result.StandingOrder.Occurrences)
+	require.Equal(t, utils.StandingOrderStatusActive, result.StandingOrder.Status)
+	require.WithinDuration(t, startAt.AddDate(0, 0, 1), result.StandingOrder.NextRunAt, time.Second)
+
+	executions, err := testQueries.ListStandingOrderExecutions(context.Background(), order.ID)
+	require.NoError(t, err)
+	require.Len(t, executions, 2)
+	for _, execution := range executions {
+		require.WithinDuration(t, startAt, execution.OccurrenceAt, time.Second)
+	}
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestExecuteStandingOrderTxPayeeCoolingOff(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	startAt := executeAtInThePast()
+	order := createRandomStandingOrder(t, account1, account2, 10, startAt, 2)
+	createRandomPayee(t, account1.Owner, account2)
+
+	arg := ExecuteStandingOrderTxParams{
+		Now:                       startAt,
+		MaxRetries:                1,
+		RetryInterval:             time.Hour,
+		PayeeCoolingOffThresholds: map[string]int64{account1.Currency: 10},
+	}
+	result, err := store.ExecuteStandingOrderTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, order.ID, result.StandingOrder.ID)
+	require.False(t, result.Execution.Succeeded)
+	require.Contains(t, result.Execution.FailureReason, ErrPayeeCoolingOff.Error())
+	require.False(t, result.WillRetry)
+	require.Nil(t, result.Transfer)
+	// the occurrence is skipped, not retried
+	require.Equal(t, int32(1), result.StandingOrder.Occurrences)
+
+	// below the threshold the payee is paid while cooling off
+	arg.Now = startAt.AddDate(0, 0, 1)
+	arg.PayeeCoolingOffThresholds = map[string]int64{account1.Currency: 11}
+	result, err = store.ExecuteStandingOrderTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.Execution.Succeeded)
+}
+
+func TestCancelStandingOrder(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	order := createRandomStandingOrder(t, account1, account2, 10, time.Now().Add(time.Hour), 3)
+
+	cancelled, err := testQueries.CancelStandingOrder(context.Background(), order.ID)
+	require.NoError(t, err)
+	require.Equal(t, utils.StandingOrderStatusCancelled, cancelled.Status)
+
+	_, err = testQueries.CancelStandingOrder(context.Background(), order.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
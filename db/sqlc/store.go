@@ -3,12 +3,41 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/SaishNaik/simplebank/utils"
+	"log/slog"
+	"time"
 )
 
 type Store interface {
 	Querier
+	PostJournal(ctx context.Context, arg PostJournalParams) (PostJournalResult, error)
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	TransferFees(ctx context.Context, fromAccount Account, amounts ...int64) (int64, error)
+	EnrollTOTPTx(ctx context.Context, arg EnrollTOTPTxParams) (EnrollTOTPTxResult, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (UpdateAccountStatusTxResult, error)
+	ExecuteScheduledTransferTx(ctx context.Context, arg ExecuteScheduledTransferTxParams) (ExecuteScheduledTransferTxResult, error)
+	ExecuteStandingOrderTx(ctx context.Context, arg ExecuteStandingOrderTxParams) (ExecuteStandingOrderTxResult, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error)
+	AuthorizeTransferTx(ctx context.Context, arg AuthorizeTransferTxParams) (AuthorizeTransferTxResult, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error)
+	VoidHoldTx(ctx context.Context, arg VoidHoldTxParams) (VoidHoldTxResult, error)
+	ExpireHoldTx(ctx context.Context, arg ExpireHoldTxParams) (ExpireHoldTxResult, error)
+	CreateTransferBatchTx(ctx context.Context, arg CreateTransferBatchTxParams) (CreateTransferBatchTxResult, error)
+	ResumeTransferBatchTx(ctx context.Context, arg ResumeTransferBatchTxParams) (ResumeTransferBatchTxResult, error)
+	ApproveTransferTx(ctx context.Context, arg ApproveTransferTxParams) (ApproveTransferTxResult, error)
+	RejectTransferTx(ctx context.Context, arg RejectTransferTxParams) (RejectTransferTxResult, error)
+	UpdateApprovalPolicyTx(ctx context.Context, arg UpdateApprovalPolicyTxParams) (UpdateApprovalPolicyTxResult, error)
+	CreatePaymentRequestTx(ctx context.Context, arg CreatePaymentRequestTxParams) (CreatePaymentRequestTxResult, error)
+	AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error)
+	UpdatePaymentRequestStatusTx(ctx context.Context, arg UpdatePaymentRequestStatusTxParams) (UpdatePaymentRequestStatusTxResult, error)
+	ExpirePaymentRequestsTx(ctx context.Context, arg ExpirePaymentRequestsTxParams) (ExpirePaymentRequestsTxResult, error)
 }
 
 type SQLStore struct {
@@ -18,6 +47,21 @@ type SQLStore struct {
 
 var txKey = struct{}{}
 
+var (
+	// ErrAccountNotActive is returned when a transfer debits or credits a frozen or closed account
+	ErrAccountNotActive = errors.New("account is not active")
+	// ErrInsufficientFunds is returned when a transfer that must not overdraw exceeds the available balance
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrTransferLimitExceeded is returned when a transfer goes over a per transaction, daily or monthly limit
+	ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+	// ErrApprovalRequired is returned when a transfer at or above the approval threshold of the from account
+	// is posted without going through ApproveTransferTx
+	ErrApprovalRequired = errors.New("transfer requires approval")
+	// ErrPayeeCoolingOff is returned when a scheduled payment at or above the cooling-off threshold of its currency
+	// comes due while the payee of its to account is still cooling off
+	ErrPayeeCoolingOff = errors.New("payee is in its cooling-off period for large transfers")
+)
+
 func NewStore(db *sql.DB) Store {
 	return &SQLStore{
 		Queries: New(db),
@@ -34,89 +78,321 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(queries *Queries) err
 	err = fn(q)
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
+			slog.ErrorContext(ctx, "cannot rollback transaction", "error", rbErr, "cause", err)
 			return fmt.Errorf("rbError: %v err: %v", rbErr, err)
 		}
+		slog.DebugContext(ctx, "transaction rolled back", "error", err)
 		return err
 	}
 	return tx.Commit()
 }
 
 type TransferTxParams struct {
-	FromAccountId int64 `json:"from_account_id"`
-	ToAccountId   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountId     int64           `json:"from_account_id"`
+	ToAccountId       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
 }
 
 type TransferTxResult struct {
 	Transfer    Transfer `json:"transfer"`
 	FromAccount Account  `json:"from_account"`
 	ToAccount   Account  `json:"to_account"`
-	FromEntry   Entry    `json:"from_entry"`
-	ToEntry     Entry    `json:"to_entry"`
+	// FromEntry debits the amount and the fee
+	FromEntry Entry `json:"from_entry"`
+	ToEntry   Entry `json:"to_entry"`
+	// FeeEntry credits the fee account, it is only set when the transfer has a fee
+	FeeEntry *Entry `json:"fee_entry,omitempty"`
 }
 
+// TransferTx moves the amount and fee of a transfer out of the from account. The fee is that of the fee rule
+// of the from account. Overdrafts are not allowed, it refuses to take the available balance below zero
+// so amounts reserved by holds cannot be spent.
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
 	err := store.execTx(ctx, func(queries *Queries) error {
 		var err error
+		result, err = chargedTransfer(ctx, queries, CreateTransferParams{
+			FromAccountID:     arg.FromAccountId,
+			ToAccountID:       arg.ToAccountId,
+			Amount:            arg.Amount,
+			Description:       arg.Description,
+			ExternalReference: arg.ExternalReference,
+			Metadata:          arg.Metadata,
+		}, true)
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "transfer failed",
+			"from_account_id", arg.FromAccountId,
+			"to_account_id", arg.ToAccountId,
+			"amount", arg.Amount,
+			"error", err,
+		)
+		return result, err
+	}
 
-		//txName := ctx.Value(txKey)
+	slog.InfoContext(ctx, "transfer committed",
+		"transfer_id", result.Transfer.ID,
+		"from_account_id", arg.FromAccountId,
+		"to_account_id", arg.ToAccountId,
+		"amount", arg.Amount,
+		"fee", result.Transfer.Fee,
+	)
+	return result, nil
+}
 
-		//fmt.Println(txName, "Create Transfer")
-		result.Transfer, err = queries.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountId,
-			ToAccountID:   arg.ToAccountId,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+// transfer moves money between two accounts inside the transaction of queries, as a journal of two lines.
+// With requireFunds it refuses to take the available balance of the from account below zero.
+func transfer(ctx context.Context, queries *Queries, arg CreateTransferParams, requireFunds bool) (TransferTxResult, error) {
+	return transferWithFee(ctx, queries, arg, 0, requireFunds)
+}
 
-		//fmt.Println(txName, "Create Entry 1")
-		result.FromEntry, err = queries.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountId,
-			Amount:    -arg.Amount,
-		})
+// chargedTransfer is transferWithFee with the fee of the fee rule of the from account.
+// Every transfer posted on behalf of a user goes through it, so callers cannot choose the fee.
+func chargedTransfer(ctx context.Context, queries *Queries, arg CreateTransferParams, requireFunds bool) (TransferTxResult, error) {
+	fromAccount, err := queries.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+	var feeAccountID int64
+	arg.Fee, feeAccountID, err = transferFee(ctx, queries, fromAccount, arg.Amount)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+	return transferWithFee(ctx, queries, arg, feeAccountID, requireFunds)
+}
+
+// transferFee returns the fee charged on top of a transfer of amount from fromAccount and the account credited
+// with it, according to the fee rule for the currency and type of the account. There is no fee without a rule.
+func transferFee(ctx context.Context, q *Queries, fromAccount Account, amount int64) (fee, feeAccountID int64, err error) {
+	rule, err := feeRule(ctx, q, fromAccount)
+	if err != nil {
+		return 0, 0, err
+	}
+	fee = utils.TransferFee(amount, rule.FlatFee, rule.PercentageBps, rule.MinFee, rule.MaxFee)
+	return fee, rule.RevenueAccountID, nil
+}
+
+// feeRule returns the fee rule for the currency and type of the from account, an empty rule charges nothing
+func feeRule(ctx context.Context, q *Queries, fromAccount Account) (FeeRule, error) {
+	rule, err := q.GetFeeRule(ctx, GetFeeRuleParams{
+		Currency:    fromAccount.Currency,
+		AccountType: fromAccount.Type,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return FeeRule{}, nil
+	}
+	return rule, err
+}
+
+// TransferFees returns the sum of the fees charged on transfers of each amount from fromAccount,
+// as TransferTx would charge them. It lets handlers show or check fees before posting anything.
+func (store *SQLStore) TransferFees(ctx context.Context, fromAccount Account, amounts ...int64) (int64, error) {
+	rule, err := feeRule(ctx, store.Queries, fromAccount)
+	if err != nil {
+		return 0, err
+	}
+	var fees int64
+	for _, amount := range amounts {
+		fees += utils.TransferFee(amount, rule.FlatFee, rule.PercentageBps, rule.MinFee, rule.MaxFee)
+	}
+	return fees, nil
+}
+
+// transferWithFee is transfer with the fee of arg also debited from the from account
+// and credited to feeAccountID, as a third line of the journal
+func transferWithFee(ctx context.Context, queries *Queries, arg CreateTransferParams, feeAccountID int64, requireFunds bool) (TransferTxResult, error) {
+	result, err := postTransfer(ctx, queries, arg, feeAccountID, requireFunds, false)
+	if err != nil {
+		return result, err
+	}
+
+	// transfers without metadata store an empty object, which every metadata search matches
+	if len(arg.Metadata) == 0 {
+		arg.Metadata = json.RawMessage("{}")
+	}
+	arg.JournalID = result.FromEntry.JournalID
+	result.Transfer, err = queries.CreateTransfer(ctx, arg)
+	return result, err
+}
+
+// postTransfer posts the journal moving the amount and fee of arg.
+// It sets the entries and accounts of the result, the caller records the transfer itself.
+// Unless approved, it refuses transfers at or above the approval threshold of the from account.
+func postTransfer(ctx context.Context, queries *Queries, arg CreateTransferParams, feeAccountID int64, requireFunds, approved bool) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	lines := []JournalLine{
+		{AccountID: arg.FromAccountID, Amount: -(arg.Amount + arg.Fee)},
+		{AccountID: arg.ToAccountID, Amount: arg.Amount},
+	}
+	if arg.Fee > 0 {
+		lines = append(lines, JournalLine{AccountID: feeAccountID, Amount: arg.Fee})
+	}
+
+	// reversals give money back, they do not count against the limits of the recipient
+	if !arg.ReversesTransferID.Valid {
+		// the fee account is locked along with the others, locking it later in postJournal could break the id order
+		fromAccount, err := lockAccounts(ctx, queries, arg.FromAccountID, journalAccountIDs(lines)...)
 		if err != nil {
-			return err
+			return result, err
 		}
+		if !approved && needsApproval(fromAccount, arg.Amount) {
+			return result, fmt.Errorf("%w: account [%d] needs approval from %d", ErrApprovalRequired, fromAccount.ID, fromAccount.ApprovalThreshold)
+		}
+		if err = checkTransferLimits(ctx, queries, fromAccount, arg.Amount); err != nil {
+			return result, err
+		}
+	}
 
-		//fmt.Println(txName, "Create Entry 2")
-		result.ToEntry, err = queries.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountId,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
-			return err
+	posted, err := postJournal(ctx, queries, PostJournalParams{
+		Lines:        lines,
+		RequireFunds: requireFunds,
+	})
+	if err != nil {
+		return result, err
+	}
+	result.FromEntry, result.ToEntry = posted.Entries[0], posted.Entries[1]
+	if arg.Fee > 0 {
+		result.FeeEntry = &posted.Entries[2]
+	}
+	for _, account := range posted.Accounts {
+		if account.ID == arg.FromAccountID {
+			result.FromAccount = account
+		}
+		if account.ID == arg.ToAccountID {
+			result.ToAccount = account
 		}
+	}
+	return result, nil
+}
 
-		//fmt.Println(txName, "update account 1")
-		if arg.FromAccountId < arg.ToAccountId {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, queries, arg.FromAccountId, -arg.Amount, arg.ToAccountId, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, queries, arg.ToAccountId, arg.Amount, arg.FromAccountId, -arg.Amount)
+// lockAccounts locks the from account and every other account of the transfer at once in id order,
+// the same order postJournal locks and updates them in, so their status and balance cannot change
+// before the transfer commits. Accounts must not be locked one call after another, that breaks the order.
+func lockAccounts(ctx context.Context, q *Queries, fromAccountID int64, otherAccountIDs ...int64) (fromAccount Account, err error) {
+	accounts, err := lockAccountIDs(ctx, q, append([]int64{fromAccountID}, otherAccountIDs...))
+	if err != nil {
+		return fromAccount, err
+	}
+	for _, account := range accounts {
+		if account.ID == fromAccountID {
+			fromAccount = account
 		}
+	}
+	return fromAccount, nil
+}
 
-		return err
-	})
-	return result, err
+// needsApproval reports whether a transfer of amount from account has to be approved before it is posted
+func needsApproval(account Account, amount int64) bool {
+	return account.ApprovalThreshold > 0 && amount >= account.ApprovalThreshold
 }
 
-func addMoney(ctx context.Context, q *Queries, accountID1, amount1, accountId2, amount2 int64) (account1, account2 Account, err error) {
-	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		Amount: amount1,
-		ID:     accountID1,
-	})
+// journalAccountIDs returns the account of every line
+func journalAccountIDs(lines []JournalLine) []int64 {
+	ids := make([]int64, len(lines))
+	for i, line := range lines {
+		ids[i] = line.AccountID
+	}
+	return ids
+}
+
+// checkPayeeCoolingOff refuses a payment of amount by owner into toAccountID while owner has a payee for the account
+// that is still cooling off. thresholds holds the smallest amount per currency the cooling-off applies to.
+func checkPayeeCoolingOff(ctx context.Context, q *Queries, owner string, toAccountID int64, currency string, amount int64, thresholds map[string]int64) error {
+	threshold, ok := thresholds[currency]
+	if !ok || threshold <= 0 || amount < threshold {
+		return nil
+	}
+
+	payee, err := q.GetPayeeByAccount(ctx, GetPayeeByAccountParams{Owner: owner, AccountID: toAccountID})
 	if err != nil {
-		return
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if time.Now().Before(payee.CoolingOffUntil) {
+		return fmt.Errorf("%w: payee [%d] until %s", ErrPayeeCoolingOff, payee.ID, payee.CoolingOffUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// checkTransferLimits checks a transfer of amount from the locked from account against the limits of the account
+// and of its owner in its currency. The from account is locked by the caller, so concurrent transfers from it
+// wait for this one to commit before adding up what was already sent.
+func checkTransferLimits(ctx context.Context, q *Queries, fromAccount Account, amount int64) error {
+	now := time.Now()
+
+	accountLimit, err := q.GetAccountTransferLimit(ctx, fromAccount.ID)
+	switch {
+	case err == nil:
+		err = checkTransferLimit(now, amount, accountLimit.PerTransaction, accountLimit.Daily, accountLimit.Monthly, func(since time.Time) (int64, error) {
+			return q.GetAccountOutgoingAmount(ctx, GetAccountOutgoingAmountParams{
+				FromAccountID: fromAccount.ID,
+				Since:         since,
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("account [%d]: %w", fromAccount.ID, err)
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return err
 	}
 
-	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		Amount: amount2,
-		ID:     accountId2,
+	// the limit of the owner stays locked until the transfer commits, so it is not changed underneath it
+	userLimit, err := q.GetUserTransferLimitForUpdate(ctx, GetUserTransferLimitForUpdateParams{
+		Username: fromAccount.Owner,
+		Currency: fromAccount.Currency,
 	})
+	switch {
+	case err == nil:
+		err = checkTransferLimit(now, amount, userLimit.PerTransaction, userLimit.Daily, userLimit.Monthly, func(since time.Time) (int64, error) {
+			return q.GetOwnerOutgoingAmount(ctx, GetOwnerOutgoingAmountParams{
+				Owner:    fromAccount.Owner,
+				Currency: fromAccount.Currency,
+				Since:    since,
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("user %s in %s: %w", fromAccount.Owner, fromAccount.Currency, err)
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return err
+	}
+	return nil
+}
 
-	return
+// checkTransferLimit checks amount against a per transaction, daily and monthly limit, zero meaning no limit.
+// outgoing returns the amount already sent since a time.
+func checkTransferLimit(now time.Time, amount, perTransaction, daily, monthly int64, outgoing func(since time.Time) (int64, error)) error {
+	if perTransaction > 0 && amount > perTransaction {
+		return fmt.Errorf("%w: %d is over the per transaction limit of %d", ErrTransferLimitExceeded, amount, perTransaction)
+	}
 
+	periods := []struct {
+		name  string
+		limit int64
+		since time.Time
+	}{
+		{name: "daily", limit: daily, since: utils.DayStart(now)},
+		{name: "monthly", limit: monthly, since: utils.MonthStart(now)},
+	}
+	for _, period := range periods {
+		if period.limit == 0 {
+			continue
+		}
+		sent, err := outgoing(period.since)
+		if err != nil {
+			return err
+		}
+		if sent+amount > period.limit {
+			return fmt.Errorf("%w: %d already sent of the %s limit of %d", ErrTransferLimitExceeded, sent, period.name, period.limit)
+		}
+	}
+	return nil
 }
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"fmt"
+	"github.com/SaishNaik/simplebank/utils"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -10,7 +11,7 @@ import (
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
 
 	fmt.Println(">> before", account1.Balance, account2.Balance)
 
@@ -50,6 +51,9 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, account2.ID, transfer.ToAccountID)
 		require.NotZero(t, transfer.ID)
 		require.NotZero(t, transfer.CreatedAt)
+		require.True(t, transfer.JournalID.Valid)
+		require.Equal(t, transfer.JournalID, result.FromEntry.JournalID)
+		require.Equal(t, transfer.JournalID, result.ToEntry.JournalID)
 
 		_, err = store.GetTransfer(context.Background(), transfer.ID)
 		require.NoError(t, err)
@@ -109,7 +113,7 @@ func TestTransferTx(t *testing.T) {
 func TestTransferTxDeadlock(t *testing.T) {
 	store := NewStore(testDB)
 	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
 
 	fmt.Println(">> before", account1.Balance, account2.Balance)
 
@@ -155,3 +159,249 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestTransferTxInactiveAccount(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account2.ID,
+		Status:    utils.AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	// neither debits nor credits are allowed
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: account2.ID,
+		ToAccountId:   account1.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestPostJournal(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	account3 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	lines := []JournalLine{
+		{AccountID: account3.ID, Amount: 7},
+		{AccountID: account1.ID, Amount: -10},
+		{AccountID: account2.ID, Amount: 3},
+	}
+	result, err := store.PostJournal(ctx, PostJournalParams{Lines: lines})
+	require.NoError(t, err)
+	require.NotZero(t, result.Journal.ID)
+
+	require.Len(t, result.Entries, len(lines))
+	for i, entry := range result.Entries {
+		require.Equal(t, lines[i].AccountID, entry.AccountID)
+		require.Equal(t, lines[i].Amount, entry.Amount)
+		require.Equal(t, result.Journal.ID, entry.JournalID.Int64)
+	}
+	entries, err := store.ListJournalEntries(ctx, result.Entries[0].JournalID)
+	require.NoError(t, err)
+	require.Len(t, entries, len(lines))
+
+	require.Len(t, result.Accounts, 3)
+	balances := map[int64]int64{
+		account1.ID: account1.Balance - 10,
+		account2.ID: account2.Balance + 3,
+		account3.ID: account3.Balance + 7,
+	}
+	for i, account := range result.Accounts {
+		if i > 0 {
+			require.Less(t, result.Accounts[i-1].ID, account.ID)
+		}
+		require.Equal(t, balances[account.ID], account.Balance)
+	}
+}
+
+func TestPostJournalRefused(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	other := createRandomAccountWithCurrency(t, utils.USD)
+	if account1.Currency == utils.USD {
+		other = createRandomAccountWithCurrency(t, utils.EUR)
+	}
+	ctx := context.Background()
+
+	_, err := store.PostJournal(ctx, PostJournalParams{Lines: []JournalLine{
+		{AccountID: account1.ID, Amount: -10},
+		{AccountID: account2.ID, Amount: 9},
+	}})
+	require.ErrorIs(t, err, ErrUnbalancedJournal)
+
+	_, err = store.PostJournal(ctx, PostJournalParams{Lines: []JournalLine{
+		{AccountID: account1.ID, Amount: -10},
+		{AccountID: other.ID, Amount: 10},
+	}})
+	require.ErrorIs(t, err, ErrUnbalancedJournal)
+
+	_, err = store.PostJournal(ctx, PostJournalParams{Lines: []JournalLine{
+		{AccountID: account1.ID, Amount: 0},
+		{AccountID: account2.ID, Amount: 0},
+	}})
+	require.ErrorIs(t, err, ErrInvalidJournal)
+
+	_, err = store.PostJournal(ctx, PostJournalParams{
+		Lines: []JournalLine{
+			{AccountID: account1.ID, Amount: -(account1.Balance + 1)},
+			{AccountID: account2.ID, Amount: account1.Balance + 1},
+		},
+		RequireFunds: true,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount1, err := store.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+// createRandomAccountWithFee creates an account of a new random type, charged flatFee on every transfer
+// by a fee rule crediting revenueAccount, so the rule does not apply to the accounts of other tests.
+// Like createRandomAccountWithCurrency it starts with at least 100.
+func createRandomAccountWithFee(t *testing.T, revenueAccount Account, flatFee int64) Account {
+	ctx := context.Background()
+	account, err := testQueries.CreateAccount(ctx, CreateAccountParams{
+		Owner:         createRandomUser(t).Username,
+		Balance:       utils.RandomInt(100, 1000),
+		Currency:      revenueAccount.Currency,
+		AccountNumber: utils.NewAccountNumber(),
+		Type:          utils.RandomString(12),
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateFeeRule(ctx, CreateFeeRuleParams{
+		Currency:         account.Currency,
+		AccountType:      account.Type,
+		FlatFee:          flatFee,
+		RevenueAccountID: revenueAccount.ID,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestTransferTxWithFee(t *testing.T) {
+	store := NewStore(testDB)
+	revenueAccount := createRandomAccount(t)
+	account1 := createRandomAccountWithFee(t, revenueAccount, 2)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	result, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Transfer.Fee)
+	require.Equal(t, int64(-12), result.FromEntry.Amount)
+	require.Equal(t, int64(10), result.ToEntry.Amount)
+	require.NotNil(t, result.FeeEntry)
+	require.Equal(t, revenueAccount.ID, result.FeeEntry.AccountID)
+	require.Equal(t, int64(2), result.FeeEntry.Amount)
+	require.Equal(t, result.Transfer.JournalID, result.FeeEntry.JournalID)
+	require.Equal(t, account1.Balance-12, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+10, result.ToAccount.Balance)
+
+	updatedRevenueAccount, err := store.GetAccount(ctx, revenueAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, revenueAccount.Balance+2, updatedRevenueAccount.Balance)
+}
+
+func TestTransferTxWithFeeDeadlock(t *testing.T) {
+	store := NewStore(testDB)
+	// the fee account has the lowest id, so it is locked first
+	revenueAccount := createRandomAccount(t)
+	account1 := createRandomAccountWithFee(t, revenueAccount, 1)
+	account2 := createRandomAccountWithCurrency(t, revenueAccount.Currency)
+
+	n := 10
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		arg := TransferTxParams{
+			FromAccountId: account1.ID,
+			ToAccountId:   account2.ID,
+			Amount:        5,
+		}
+		// the other transfers lock the fee account and account1 the other way around
+		if i%2 == 0 {
+			arg = TransferTxParams{
+				FromAccountId: revenueAccount.ID,
+				ToAccountId:   account1.ID,
+				Amount:        1,
+			}
+		}
+
+		go func() {
+			_, err := store.TransferTx(context.Background(), arg)
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.NoError(t, err)
+	}
+
+	updatedRevenueAccount, err := store.GetAccount(context.Background(), revenueAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, revenueAccount.Balance, updatedRevenueAccount.Balance)
+}
+
+func TestTransferFees(t *testing.T) {
+	store := NewStore(testDB)
+	revenueAccount := createRandomAccount(t)
+	account := createRandomAccountWithFee(t, revenueAccount, 2)
+
+	fees, err := store.TransferFees(context.Background(), account, 10, 20)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), fees)
+
+	// without a rule transfers are free
+	fees, err = store.TransferFees(context.Background(), revenueAccount, 10)
+	require.NoError(t, err)
+	require.Zero(t, fees)
+}
+
+func TestGetFeeRule(t *testing.T) {
+	revenueAccount := createRandomAccount(t)
+	ctx := context.Background()
+
+	// a random account type keeps the rule apart from the rules of other tests
+	arg := CreateFeeRuleParams{
+		Currency:         revenueAccount.Currency,
+		AccountType:      utils.RandomString(12),
+		FlatFee:          25,
+		PercentageBps:    100,
+		MinFee:           50,
+		MaxFee:           500,
+		RevenueAccountID: revenueAccount.ID,
+	}
+	rule, err := testQueries.CreateFeeRule(ctx, arg)
+	require.NoError(t, err)
+
+	gotRule, err := testQueries.GetFeeRule(ctx, GetFeeRuleParams{Currency: arg.Currency, AccountType: arg.AccountType})
+	require.NoError(t, err)
+	require.Equal(t, rule.ID, gotRule.ID)
+	require.Equal(t, arg.PercentageBps, gotRule.PercentageBps)
+	require.Equal(t, arg.RevenueAccountID, gotRule.RevenueAccountID)
+
+	_, err = testQueries.CreateFeeRule(ctx, arg)
+	require.Error(t, err)
+}
@@ -7,26 +7,155 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
 )
 
+const completeTransfer = `-- name: CompleteTransfer :one
+UPDATE transfers
+SET status = 'completed',
+    journal_id = $1,
+    fee = $2,
+    completed_at = now()
+WHERE id = $3
+    RETURNING id, from_account_id, to_account_id, amount, created_at, reverses_transfer_id, description, external_reference, metadata, journal_id, fee, status, requested_by, required_approvals, expires_at, completed_at
+`
+
+type CompleteTransferParams struct {
+	JournalID sql.NullInt64 `json:"journal_id"`
+	Fee       int64         `json:"fee"`
+	ID        int64         `json:"id"`
+}
+
+func (q *Queries) CompleteTransfer(ctx context.Context, arg CompleteTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, completeTransfer, arg.JournalID, arg.Fee, arg.ID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversesTransferID,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.JournalID,
+		&i.Fee,
+		&i.Status,
+		&i.RequestedBy,
+		&i.RequiredApprovals,
+		&i.ExpiresAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const createPendingTransfer = `-- name: CreatePendingTransfer :one
+INSERT INTO transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    description,
+    external_reference,
+    metadata,
+    status,
+    requested_by,
+    required_approvals,
+    expires_at
+) VALUES (
+             $1, $2, $3, $4, $5, $6, 'pending', $7, $8, $9
+         ) RETURNING id, from_account_id, to_account_id, amount, created_at, reverses_transfer_id, description, external_reference, metadata, journal_id, fee, status, requested_by, required_approvals, expires_at, completed_at
+`
+
+type CreatePendingTransferParams struct {
+	FromAccountID     int64           `json:"from_account_id"`
+	ToAccountID       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+	RequestedBy       string          `json:"requested_by"`
+	RequiredApprovals int32           `json:"required_approvals"`
+	ExpiresAt         sql.NullTime    `json:"expires_at"`
+}
+
+func (q *Queries) CreatePendingTransfer(ctx context.Context, arg CreatePendingTransferParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, createPendingTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Description,
+		arg.ExternalReference,
+		arg.Metadata,
+		arg.RequestedBy,
+		arg.RequiredApprovals,
+		arg.ExpiresAt,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversesTransferID,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.JournalID,
+		&i.Fee,
+		&i.Status,
+		&i.RequestedBy,
+		&i.RequiredApprovals,
+		&i.ExpiresAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    reverses_transfer_id,
+    description,
+    external_reference,
+    metadata,
+    journal_id,
+    fee,
+    completed_at
 ) VALUES (
-             $1, $2, $3
-         ) RETURNING id, from_account_id, to_account_id, amount, created_at
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, now()
+         ) RETURNING id, from_account_id, to_account_id, amount, created_at, reverses_transfer_id, description, external_reference, metadata, journal_id, fee, status, requested_by, required_approvals, expires_at, completed_at
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID      int64           `json:"from_account_id"`
+	ToAccountID        int64           `json:"to_account_id"`
+	Amount             int64           `json:"amount"`
+	ReversesTransferID sql.NullInt64   `json:"reverses_transfer_id"`
+	Description        string          `json:"description"`
+	ExternalReference  string          `json:"external_reference"`
+	Metadata           json.RawMessage `json:"metadata"`
+	JournalID          sql.NullInt64   `json:"journal_id"`
+	Fee                int64           `json:"fee"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ReversesTransferID,
+		arg.Description,
+		arg.ExternalReference,
+		arg.Metadata,
+		arg.JournalID,
+		arg.Fee,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -34,12 +163,84 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ReversesTransferID,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.JournalID,
+		&i.Fee,
+		&i.Status,
+		&i.RequestedBy,
+		&i.RequiredApprovals,
+		&i.ExpiresAt,
+		&i.CompletedAt,
 	)
 	return i, err
 }
 
+const expirePendingTransfers = `-- name: ExpirePendingTransfers :many
+UPDATE transfers
+SET status = 'expired'
+WHERE status = 'pending'
+  AND expires_at <= $1::timestamptz
+    RETURNING id, from_account_id, to_account_id, amount, created_at, reverses_transfer_id, description, external_reference, metadata, journal_id, fee, status, requested_by, required_approvals, expires_at, completed_at
+`
+
+func (q *Queries) ExpirePendingTransfers(ctx context.Context, now time.Time) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, expirePendingTransfers, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ReversesTransferID,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
+			&i.JournalID,
+			&i.Fee,
+			&i.Status,
+			&i.RequestedBy,
+			&i.RequiredApprovals,
+			&i.ExpiresAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReversedAmount = `-- name: GetReversedAmount :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS reversed_amount
+FROM transfers
+WHERE reverses_transfer_id = $1
+`
+
+func (q *Queries) GetReversedAmount(ctx context.Context, reversesTransferID sql.NullInt64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getReversedAmount, reversesTransferID)
+	var reversed_amount int64
+	err := row.Scan(&reversed_amount)
+	return reversed_amount, err
+}
+
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, reverses_transfer_id, description, external_reference, metadata, journal_id, fee, status, requested_by, required_approvals, expires_at, completed_at FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -52,12 +253,53 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ReversesTransferID,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.JournalID,
+		&i.Fee,
+		&i.Status,
+		&i.RequestedBy,
+		&i.RequiredApprovals,
+		&i.ExpiresAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getTransferWithUpdate = `-- name: GetTransferWithUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, reverses_transfer_id, description, external_reference, metadata, journal_id, fee, status, requested_by, required_approvals, expires_at, completed_at FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferWithUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferWithUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversesTransferID,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.JournalID,
+		&i.Fee,
+		&i.Status,
+		&i.RequestedBy,
+		&i.RequiredApprovals,
+		&i.ExpiresAt,
+		&i.CompletedAt,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, reverses_transfer_id, description, external_reference, metadata, journal_id, fee, status, requested_by, required_approvals, expires_at, completed_at FROM transfers
 WHERE
         from_account_id = $1 OR
         to_account_id = $2
@@ -93,6 +335,17 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ReversesTransferID,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
+			&i.JournalID,
+			&i.Fee,
+			&i.Status,
+			&i.RequestedBy,
+			&i.RequiredApprovals,
+			&i.ExpiresAt,
+			&i.CompletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -106,3 +359,107 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	}
 	return items, nil
 }
+
+const searchTransfers = `-- name: SearchTransfers :many
+SELECT id, from_account_id, to_account_id, amount, created_at, reverses_transfer_id, description, external_reference, metadata, journal_id, fee, status, requested_by, required_approvals, expires_at, completed_at FROM transfers
+WHERE
+        (from_account_id = $1 OR to_account_id = $1)
+  AND description ILIKE '%' || $2::text || '%'
+  AND ($3::text = '' OR external_reference = $3)
+  AND metadata @> $4::jsonb
+ORDER BY id DESC
+    LIMIT $5
+OFFSET $6
+`
+
+type SearchTransfersParams struct {
+	AccountID         int64           `json:"account_id"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+	Limit             int32           `json:"limit"`
+	Offset            int32           `json:"offset"`
+}
+
+func (q *Queries) SearchTransfers(ctx context.Context, arg SearchTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, searchTransfers,
+		arg.AccountID,
+		arg.Description,
+		arg.ExternalReference,
+		arg.Metadata,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ReversesTransferID,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
+			&i.JournalID,
+			&i.Fee,
+			&i.Status,
+			&i.RequestedBy,
+			&i.RequiredApprovals,
+			&i.ExpiresAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateTransferStatus = `-- name: UpdateTransferStatus :one
+UPDATE transfers
+SET status = $1
+WHERE id = $2
+    RETURNING id, from_account_id, to_account_id, amount, created_at, reverses_transfer_id, description, external_reference, metadata, journal_id, fee, status, requested_by, required_approvals, expires_at, completed_at
+`
+
+type UpdateTransferStatusParams struct {
+	Status string `json:"status"`
+	ID     int64  `json:"id"`
+}
+
+func (q *Queries) UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, updateTransferStatus, arg.Status, arg.ID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ReversesTransferID,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
+		&i.JournalID,
+		&i.Fee,
+		&i.Status,
+		&i.RequestedBy,
+		&i.RequiredApprovals,
+		&i.ExpiresAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
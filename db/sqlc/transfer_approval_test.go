@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// createRandomPendingTransfer requests a transfer of 10 from a new account topped up with 100,
+// to be approved by two new users
+func createRandomPendingTransfer(t *testing.T, store Store, expiresAt sql.NullTime) (Account, Account, Transfer, []string) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	account1, err := testQueries.AddAccountBalance(ctx, AddAccountBalanceParams{Amount: 100, ID: account1.ID})
+	require.NoError(t, err)
+
+	approvers := []string{createRandomUser(t).Username, createRandomUser(t).Username}
+	policy, err := store.UpdateApprovalPolicyTx(ctx, UpdateApprovalPolicyTxParams{
+		AccountID:         account1.ID,
+		ApprovalThreshold: 10,
+		RequiredApprovals: 2,
+		Approvers:         approvers,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), policy.Account.ApprovalThreshold)
+	require.Equal(t, int32(2), policy.Account.RequiredApprovals)
+	require.Len(t, policy.Approvers, 2)
+
+	transfer, err := testQueries.CreatePendingTransfer(ctx, CreatePendingTransferParams{
+		FromAccountID:     account1.ID,
+		ToAccountID:       account2.ID,
+		Amount:            10,
+		Metadata:          json.RawMessage(`{}`),
+		RequestedBy:       account1.Owner,
+		RequiredApprovals: policy.Account.RequiredApprovals,
+		ExpiresAt:         expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.TransferStatusPending, transfer.Status)
+	require.False(t, transfer.JournalID.Valid)
+	return account1, account2, transfer, approvers
+}
+
+func TestApproveTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1, account2, transfer, approvers := createRandomPendingTransfer(t, store, sql.NullTime{})
+	ctx := context.Background()
+
+	_, err := store.ApproveTransferTx(ctx, ApproveTransferTxParams{TransferID: transfer.ID, Approver: account1.Owner})
+	require.ErrorIs(t, err, ErrSelfApproval)
+
+	first, err := store.ApproveTransferTx(ctx, ApproveTransferTxParams{TransferID: transfer.ID, Approver: approvers[0]})
+	require.NoError(t, err)
+	require.True(t, first.Approval.Approved)
+	require.Equal(t, utils.TransferStatusPending, first.Transfer.Status)
+	require.Nil(t, first.Completed)
+
+	// no money moves before the required approvals are collected
+	got, err := testQueries.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, got.Balance)
+
+	_, err = store.ApproveTransferTx(ctx, ApproveTransferTxParams{TransferID: transfer.ID, Approver: approvers[0]})
+	require.ErrorIs(t, err, ErrAlreadyDecided)
+
+	second, err := store.ApproveTransferTx(ctx, ApproveTransferTxParams{TransferID: transfer.ID, Approver: approvers[1]})
+	require.NoError(t, err)
+	require.Equal(t, utils.TransferStatusCompleted, second.Transfer.Status)
+	require.True(t, second.Transfer.JournalID.Valid)
+	require.NotNil(t, second.Completed)
+	require.Equal(t, account1.Balance-10, second.Completed.FromAccount.Balance)
+	require.Equal(t, account2.Balance+10, second.Completed.ToAccount.Balance)
+
+	approvals, err := testQueries.ListTransferApprovals(ctx, transfer.ID)
+	require.NoError(t, err)
+	require.Len(t, approvals, 2)
+
+	_, err = store.RejectTransferTx(ctx, RejectTransferTxParams{TransferID: transfer.ID, Approver: approvers[1]})
+	require.ErrorIs(t, err, ErrTransferNotPending)
+}
+
+func TestTransferTxApprovalRequired(t *testing.T) {
+	store := NewStore(testDB)
+	account1, account2, _, _ := createRandomPendingTransfer(t, store, sql.NullTime{})
+	ctx := context.Background()
+
+	// transfers at the threshold only move once approved
+	_, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrApprovalRequired)
+
+	_, err = store.TransferTx(ctx, TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        9,
+	})
+	require.NoError(t, err)
+
+	scheduledTransfer := createRandomScheduledTransfer(t, account1, account2, 10, executeAtInThePast())
+	result, err := store.ExecuteScheduledTransferTx(ctx, ExecuteScheduledTransferTxParams{Now: scheduledTransfer.ExecuteAt})
+	require.NoError(t, err)
+	require.Equal(t, scheduledTransfer.ID, result.ScheduledTransfer.ID)
+	require.Equal(t, utils.ScheduledTransferStatusFailed, result.ScheduledTransfer.Status)
+	require.Contains(t, result.Attempt.FailureReason, ErrApprovalRequired.Error())
+}
+
+func TestRejectTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1, _, transfer, approvers := createRandomPendingTransfer(t, store, sql.NullTime{})
+	ctx := context.Background()
+
+	result, err := store.RejectTransferTx(ctx, RejectTransferTxParams{TransferID: transfer.ID, Approver: approvers[0]})
+	require.NoError(t, err)
+	require.False(t, result.Approval.Approved)
+	require.Equal(t, utils.TransferStatusRejected, result.Transfer.Status)
+
+	_, err = store.ApproveTransferTx(ctx, ApproveTransferTxParams{TransferID: transfer.ID, Approver: approvers[1]})
+	require.ErrorIs(t, err, ErrTransferNotPending)
+
+	_, err = store.ReverseTransferTx(ctx, ReverseTransferTxParams{TransferID: transfer.ID})
+	require.ErrorIs(t, err, ErrTransferNotCompleted)
+
+	got, err := testQueries.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, got.Balance)
+}
+
+func TestExpirePendingTransfers(t *testing.T) {
+	store := NewStore(testDB)
+	expiresAt := sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}
+	_, _, transfer, approvers := createRandomPendingTransfer(t, store, expiresAt)
+	ctx := context.Background()
+
+	expired, err := testQueries.ExpirePendingTransfers(ctx, time.Now())
+	require.NoError(t, err)
+	for _, e := range expired {
+		require.NotEqual(t, transfer.ID, e.ID)
+	}
+
+	expired, err = testQueries.ExpirePendingTransfers(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	var found bool
+	for _, e := range expired {
+		require.Equal(t, utils.TransferStatusExpired, e.Status)
+		found = found || e.ID == transfer.ID
+	}
+	require.True(t, found)
+
+	_, err = store.ApproveTransferTx(ctx, ApproveTransferTxParams{TransferID: transfer.ID, Approver: approvers[0]})
+	require.ErrorIs(t, err, ErrTransferNotPending)
+}
@@ -0,0 +1,221 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCreateTransferBatchTxAtomic(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	account3 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	account1, err := testQueries.AddAccountBalance(ctx, AddAccountBalanceParams{Amount: 100, ID: account1.ID})
+	require.NoError(t, err)
+
+	arg := CreateTransferBatchTxParams{
+		Owner:         account1.Owner,
+		FromAccountID: account1.ID,
+		Currency:      account1.Currency,
+		Atomic:        true,
+		Items: []TransferBatchItemParams{
+			{ToAccountID: account2.ID, Amount: 10, Description: "Salary"},
+			{ToAccountID: account3.ID, Amount: 20},
+		},
+	}
+	result, err := store.CreateTransferBatchTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, utils.TransferBatchStatusSucceeded, result.Batch.Status)
+	require.Equal(t, int64(30), result.Batch.TotalAmount)
+	require.Len(t, result.Items, 2)
+	for _, item := range result.Items {
+		require.Equal(t, utils.TransferBatchItemStatusSucceeded, item.Status)
+		require.True(t, item.TransferID.Valid)
+	}
+
+	updated, err := testQueries.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-30, updated.Balance)
+
+	// the last item overdraws the account, so nothing is transferred
+	arg.Items = append(arg.Items, TransferBatchItemParams{ToAccountID: account2.ID, Amount: updated.Balance})
+	_, err = store.CreateTransferBatchTx(ctx, arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	unchanged, err := testQueries.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, updated.Balance, unchanged.Balance)
+}
+
+func TestCreateTransferBatchTxPerItem(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	account3 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	account1, err := testQueries.AddAccountBalance(ctx, AddAccountBalanceParams{Amount: 100, ID: account1.ID})
+	require.NoError(t, err)
+	_, err = testQueries.UpdateAccountStatus(ctx, UpdateAccountStatusParams{ID: account3.ID, Status: utils.AccountStatusFrozen})
+	require.NoError(t, err)
+
+	result, err := store.CreateTransferBatchTx(ctx, CreateTransferBatchTxParams{
+		Owner:         account1.Owner,
+		FromAccountID: account1.ID,
+		Currency:      account1.Currency,
+		Items: []TransferBatchItemParams{
+			{ToAccountID: account2.ID, Amount: 10},
+			{ToAccountID: account3.ID, Amount: 20},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.TransferBatchStatusPartiallySucceeded, result.Batch.Status)
+	require.Equal(t, utils.TransferBatchItemStatusSucceeded, result.Items[0].Status)
+	require.Equal(t, utils.TransferBatchItemStatusFailed, result.Items[1].Status)
+	require.Contains(t, result.Items[1].FailureReason, ErrAccountNotActive.Error())
+	require.False(t, result.Items[1].TransferID.Valid)
+
+	batch, err := store.GetTransferBatch(ctx, result.Batch.ID)
+	require.NoError(t, err)
+	require.Equal(t, utils.TransferBatchStatusPartiallySucceeded, batch.Status)
+
+	items, err := store.ListTransferBatchItems(ctx, batch.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, result.Items[0].ID, items[0].ID)
+	require.Equal(t, result.Items[1].FailureReason, items[1].FailureReason)
+
+	updated, err := testQueries.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-10, updated.Balance)
+}
+
+func TestResumeTransferBatchTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	// a batch recorded by an execution that stopped before transferring its items
+	recorded, err := recordTransferBatch(ctx, testQueries, CreateTransferBatchTxParams{
+		Owner:          account1.Owner,
+		FromAccountID:  account1.ID,
+		Currency:       account1.Currency,
+		Items:          []TransferBatchItemParams{{ToAccountID: account2.ID, Amount: 10}},
+		IdempotencyKey: utils.RandomString(12),
+	})
+	require.NoError(t, err)
+
+	// batches still running are left alone
+	arg := ResumeTransferBatchTxParams{Before: recorded.Batch.CreatedAt.Add(-time.Minute)}
+	result, err := store.ResumeTransferBatchTx(ctx, arg)
+	if err == nil {
+		require.NotEqual(t, recorded.Batch.ID, result.Batch.ID)
+	}
+
+	arg.Before = time.Now().Add(time.Minute)
+	for result.Batch.ID != recorded.Batch.ID {
+		result, err = store.ResumeTransferBatchTx(ctx, arg)
+		require.NoError(t, err)
+	}
+	require.Equal(t, utils.TransferBatchStatusSucceeded, result.Batch.Status)
+	require.Len(t, result.Items, 1)
+	require.Equal(t, utils.TransferBatchItemStatusSucceeded, result.Items[0].Status)
+
+	updated, err := testQueries.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-10, updated.Balance)
+
+	// a finished batch is never resumed again
+	for {
+		result, err = store.ResumeTransferBatchTx(ctx, arg)
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		require.NoError(t, err)
+		require.NotEqual(t, recorded.Batch.ID, result.Batch.ID)
+	}
+}
+
+func TestCreateTransferBatchTxIdempotencyKey(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	arg := CreateTransferBatchTxParams{
+		Owner:          account1.Owner,
+		FromAccountID:  account1.ID,
+		Currency:       account1.Currency,
+		Items:          []TransferBatchItemParams{{ToAccountID: account2.ID, Amount: 10}},
+		IdempotencyKey: utils.RandomString(12),
+	}
+	result, err := store.CreateTransferBatchTx(ctx, arg)
+	require.NoError(t, err)
+
+	batch, err := store.GetTransferBatchByIdempotencyKey(ctx, GetTransferBatchByIdempotencyKeyParams{
+		Owner:          arg.Owner,
+		IdempotencyKey: sql.NullString{String: arg.IdempotencyKey, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.Batch.ID, batch.ID)
+
+	// a retry with the same key transfers nothing
+	_, err = store.CreateTransferBatchTx(ctx, arg)
+	require.Error(t, err)
+
+	updated, err := testQueries.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-10, updated.Balance)
+}
+
+func TestCreateTransferBatchTxWithFee(t *testing.T) {
+	store := NewStore(testDB)
+	revenueAccount := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, revenueAccount.Currency)
+	ctx := context.Background()
+
+	// a random account type keeps the rule apart from the rules of other tests
+	account1, err := testQueries.CreateAccount(ctx, CreateAccountParams{
+		Owner:         createRandomUser(t).Username,
+		Balance:       100,
+		Currency:      revenueAccount.Currency,
+		AccountNumber: utils.NewAccountNumber(),
+		Type:          utils.RandomString(12),
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateFeeRule(ctx, CreateFeeRuleParams{
+		Currency:         account1.Currency,
+		AccountType:      account1.Type,
+		FlatFee:          1,
+		RevenueAccountID: revenueAccount.ID,
+	})
+	require.NoError(t, err)
+
+	result, err := store.CreateTransferBatchTx(ctx, CreateTransferBatchTxParams{
+		Owner:         account1.Owner,
+		FromAccountID: account1.ID,
+		Currency:      account1.Currency,
+		Items: []TransferBatchItemParams{
+			{ToAccountID: account2.ID, Amount: 10},
+			{ToAccountID: account2.ID, Amount: 20},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, utils.TransferBatchStatusSucceeded, result.Batch.Status)
+
+	updated, err := testQueries.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-32, updated.Balance)
+
+	updatedRevenueAccount, err := testQueries.GetAccount(ctx, revenueAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, revenueAccount.Balance+2, updatedRevenueAccount.Balance)
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTransferTxAccountLimit(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	limit, err := testQueries.UpsertAccountTransferLimit(ctx, UpsertAccountTransferLimitParams{
+		AccountID:      account1.ID,
+		PerTransaction: 20,
+		Daily:          30,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(20), limit.PerTransaction)
+
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 21})
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 20})
+	require.NoError(t, err)
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 11})
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+	transferred, err := store.TransferTx(ctx, TransferTxParams{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 10})
+	require.NoError(t, err)
+
+	sent, err := testQueries.GetAccountOutgoingAmount(ctx, GetAccountOutgoingAmountParams{
+		FromAccountID: account1.ID,
+		Since:         time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(30), sent)
+
+	// reversals move money back from the recipient and are not limited
+	_, err = testQueries.UpsertAccountTransferLimit(ctx, UpsertAccountTransferLimitParams{AccountID: account2.ID, PerTransaction: 1})
+	require.NoError(t, err)
+	_, err = store.ReverseTransferTx(ctx, ReverseTransferTxParams{TransferID: transferred.Transfer.ID})
+	require.NoError(t, err)
+}
+
+func TestTransferTxUserLimit(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	_, err := testQueries.UpsertUserTransferLimit(ctx, UpsertUserTransferLimitParams{
+		Username: account1.Owner,
+		Currency: account1.Currency,
+		Daily:    50,
+	})
+	require.NoError(t, err)
+	_, err = testQueries.UpsertAccountTransferLimit(ctx, UpsertAccountTransferLimitParams{
+		AccountID: account1.ID,
+		Daily:     100,
+	})
+	require.NoError(t, err)
+
+	// the stricter of the user and account limits applies
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 30})
+	require.NoError(t, err)
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 21})
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	sent, err := testQueries.GetOwnerOutgoingAmount(ctx, GetOwnerOutgoingAmountParams{
+		Owner:    account1.Owner,
+		Currency: account1.Currency,
+		Since:    time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(30), sent)
+
+	// limits in other currencies do not apply
+	_, err = testQueries.UpsertUserTransferLimit(ctx, UpsertUserTransferLimitParams{
+		Username:       account2.Owner,
+		Currency:       account2.Currency + "X",
+		PerTransaction: 1,
+	})
+	require.NoError(t, err)
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountId: account2.ID, ToAccountId: account1.ID, Amount: 10})
+	require.NoError(t, err)
+
+	limits, err := testQueries.ListUserTransferLimits(ctx, account2.Owner)
+	require.NoError(t, err)
+	require.Len(t, limits, 1)
+}
+
+func TestApproveTransferTxCountsAgainstLimitOfApprovalDay(t *testing.T) {
+	store := NewStore(testDB)
+	account1, _, transfer, approvers := createRandomPendingTransfer(t, store, sql.NullTime{})
+	ctx := context.Background()
+
+	// requested yesterday, approved today
+	requestedAt := time.Now().Add(-24 * time.Hour)
+	_, err := testDB.ExecContext(ctx, "UPDATE transfers SET created_at = $1 WHERE id = $2", requestedAt, transfer.ID)
+	require.NoError(t, err)
+
+	_, err = store.ApproveTransferTx(ctx, ApproveTransferTxParams{TransferID: transfer.ID, Approver: approvers[0]})
+	require.NoError(t, err)
+	result, err := store.ApproveTransferTx(ctx, ApproveTransferTxParams{TransferID: transfer.ID, Approver: approvers[1]})
+	require.NoError(t, err)
+	require.True(t, result.Transfer.CompletedAt.Valid)
+	require.True(t, result.Transfer.CompletedAt.Time.After(requestedAt))
+
+	sent, err := testQueries.GetAccountOutgoingAmount(ctx, GetAccountOutgoingAmountParams{
+		FromAccountID: account1.ID,
+		Since:         time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, transfer.Amount, sent)
+}
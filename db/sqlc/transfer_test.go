@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/SaishNaik/simplebank/utils"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -30,13 +31,13 @@ func CreateRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
 
 func TestCreateTransfer(t *testing.T) {
 	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
 	CreateRandomTransfer(t, account1, account2)
 }
 
 func TestGetTransfer(t *testing.T) {
 	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
 	expectedTransfer := CreateRandomTransfer(t, account1, account2)
 	gotTransfer, err := testQueries.GetTransfer(context.Background(), expectedTransfer.ID)
 	require.NoError(t, err)
@@ -51,7 +52,7 @@ func TestGetTransfer(t *testing.T) {
 
 func TestListTransfers(t *testing.T) {
 	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
 	for i := 0; i < 5; i++ {
 		CreateRandomTransfer(t, account1, account2)
 		CreateRandomTransfer(t, account2, account1)
@@ -70,3 +71,89 @@ func TestListTransfers(t *testing.T) {
 		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
 	}
 }
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	original, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	partial, err := store.ReverseTransferTx(ctx, ReverseTransferTxParams{TransferID: original.Transfer.ID, Amount: 4})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), partial.ReversedAmount)
+	require.Equal(t, account2.ID, partial.Reversal.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, partial.Reversal.Transfer.ToAccountID)
+	require.Equal(t, original.Transfer.ID, partial.Reversal.Transfer.ReversesTransferID.Int64)
+	require.Equal(t, original.ToAccount.Balance-4, partial.Reversal.FromAccount.Balance)
+
+	_, err = store.ReverseTransferTx(ctx, ReverseTransferTxParams{TransferID: original.Transfer.ID, Amount: 7})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+
+	_, err = store.ReverseTransferTx(ctx, ReverseTransferTxParams{TransferID: partial.Reversal.Transfer.ID})
+	require.ErrorIs(t, err, ErrTransferIsReversal)
+
+	rest, err := store.ReverseTransferTx(ctx, ReverseTransferTxParams{TransferID: original.Transfer.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(6), rest.Reversal.Transfer.Amount)
+	require.Equal(t, int64(10), rest.ReversedAmount)
+	require.Equal(t, account1.Balance, rest.Reversal.ToAccount.Balance)
+
+	_, err = store.ReverseTransferTx(ctx, ReverseTransferTxParams{TransferID: original.Transfer.ID})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+}
+
+func TestSearchTransfers(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	ctx := context.Background()
+
+	reference := utils.RandomString(12)
+	tagged, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountId:     account1.ID,
+		ToAccountId:       account2.ID,
+		Amount:            10,
+		Description:       "Rent for March",
+		ExternalReference: reference,
+		Metadata:          json.RawMessage(`{"invoice": "2041", "channel": "web"}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Rent for March", tagged.Transfer.Description)
+	require.Equal(t, reference, tagged.Transfer.ExternalReference)
+
+	plain, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountId: account2.ID,
+		ToAccountId:   account1.ID,
+		Amount:        5,
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(plain.Transfer.Metadata))
+
+	arg := SearchTransfersParams{
+		AccountID: account1.ID,
+		Metadata:  json.RawMessage(`{}`),
+		Limit:     10,
+	}
+	transfers, err := store.SearchTransfers(ctx, arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.Equal(t, plain.Transfer.ID, transfers[0].ID)
+
+	for _, filter := range []SearchTransfersParams{
+		{AccountID: account2.ID, Description: "rent", Metadata: json.RawMessage(`{}`), Limit: 10},
+		{AccountID: account2.ID, ExternalReference: reference, Metadata: json.RawMessage(`{}`), Limit: 10},
+		{AccountID: account2.ID, Metadata: json.RawMessage(`{"invoice": "2041"}`), Limit: 10},
+	} {
+		transfers, err = store.SearchTransfers(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, tagged.Transfer.ID, transfers[0].ID)
+	}
+}
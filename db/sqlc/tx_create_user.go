@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+type CreateUserTxParams struct {
+	CreateUserParams
+	// VerifyEmailCodeHash is the hash of the secret code sent to the new user to verify their email
+	VerifyEmailCodeHash  string    `json:"verify_email_code_hash"`
+	VerifyEmailExpiredAt time.Time `json:"verify_email_expired_at"`
+}
+
+type CreateUserTxResult struct {
+	User        User        `json:"user"`
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// CreateUserTx creates a user together with the code that verifies their email.
+// The code is left to the caller to send once the transaction has committed.
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		var err error
+
+		result.User, err = queries.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		result.VerifyEmail, err = queries.CreateVerifyEmail(ctx, CreateVerifyEmailParams{
+			Username:       result.User.Username,
+			Email:          result.User.Email,
+			SecretCodeHash: arg.VerifyEmailCodeHash,
+			ExpiredAt:      arg.VerifyEmailExpiredAt,
+		})
+		return err
+	})
+	return result, err
+}
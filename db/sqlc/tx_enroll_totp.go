@@ -0,0 +1,48 @@
+package db
+
+import "context"
+
+type EnrollTOTPTxParams struct {
+	Username           string   `json:"username"`
+	EncryptedSecret    string   `json:"encrypted_secret"`
+	RecoveryCodeHashes []string `json:"recovery_code_hashes"`
+}
+
+type EnrollTOTPTxResult struct {
+	UserTotp UserTotp `json:"user_totp"`
+}
+
+// EnrollTOTPTx stores a new unconfirmed totp secret for the user and replaces their recovery codes.
+// It returns sql.ErrNoRows if the user has already confirmed a totp secret.
+func (store *SQLStore) EnrollTOTPTx(ctx context.Context, arg EnrollTOTPTxParams) (EnrollTOTPTxResult, error) {
+	var result EnrollTOTPTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		var err error
+
+		result.UserTotp, err = queries.UpsertUserTOTP(ctx, UpsertUserTOTPParams{
+			Username:        arg.Username,
+			EncryptedSecret: arg.EncryptedSecret,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = queries.DeleteMFARecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			_, err = queries.CreateMFARecoveryCode(ctx, CreateMFARecoveryCodeParams{
+				Username: arg.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return result, err
+}
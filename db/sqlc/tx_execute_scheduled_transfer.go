@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/SaishNaik/simplebank/utils"
+	"time"
+)
+
+type ExecuteScheduledTransferTxParams struct {
+	// Now is the time up to which scheduled transfers are due
+	Now time.Time `json:"now"`
+	// PayeeCoolingOffThresholds holds per currency the smallest amount refused while the payee is cooling off
+	PayeeCoolingOffThresholds map[string]int64 `json:"payee_cooling_off_thresholds"`
+}
+
+type ExecuteScheduledTransferTxResult struct {
+	ScheduledTransfer ScheduledTransfer        `json:"scheduled_transfer"`
+	Attempt           ScheduledTransferAttempt `json:"attempt"`
+	// Transfer is only set when the attempt succeeded
+	Transfer *TransferTxResult `json:"transfer,omitempty"`
+}
+
+// ExecuteScheduledTransferTx claims the oldest due scheduled transfer and executes it.
+// The row stays locked until the transaction commits, rows locked by other executors are skipped,
+// so several server instances can run it concurrently without executing a transfer twice.
+// A transfer refused because of the state of the accounts, a transfer limit or the cooling-off of its payee
+// is recorded as a failed attempt.
+// It returns sql.ErrNoRows if no scheduled transfer is due.
+func (store *SQLStore) ExecuteScheduledTransferTx(ctx context.Context, arg ExecuteScheduledTransferTxParams) (ExecuteScheduledTransferTxResult, error) {
+	var result ExecuteScheduledTransferTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		scheduledTransfer, err := queries.ClaimDueScheduledTransfer(ctx, arg.Now)
+		if err != nil {
+			return err
+		}
+
+		attempt := CreateScheduledTransferAttemptParams{ScheduledTransferID: scheduledTransfer.ID}
+		finish := FinishScheduledTransferParams{ID: scheduledTransfer.ID}
+
+		// the payee may have been added after the payment was scheduled
+		var transferResult TransferTxResult
+		err = checkPayeeCoolingOff(ctx, queries, scheduledTransfer.Owner, scheduledTransfer.ToAccountID, scheduledTransfer.Currency, scheduledTransfer.Amount, arg.PayeeCoolingOffThresholds)
+		if err == nil {
+			transferResult, err = chargedTransfer(ctx, queries, CreateTransferParams{
+				FromAccountID: scheduledTransfer.FromAccountID,
+				ToAccountID:   scheduledTransfer.ToAccountID,
+				Amount:        scheduledTransfer.Amount,
+			}, true)
+		}
+		switch {
+		case err == nil:
+			transferID := sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true}
+			attempt.Succeeded = true
+			attempt.TransferID = transferID
+			finish.Status = utils.ScheduledTransferStatusSucceeded
+			finish.TransferID = transferID
+			result.Transfer = &transferResult
+		case errors.Is(err, ErrAccountNotActive), errors.Is(err, ErrInsufficientFunds), errors.Is(err, ErrTransferLimitExceeded),
+			errors.Is(err, ErrApprovalRequired), errors.Is(err, ErrPayeeCoolingOff):
+			// nothing has been written yet, the transaction can still record the failure
+			attempt.FailureReason = err.Error()
+			finish.Status = utils.ScheduledTransferStatusFailed
+		default:
+			return err
+		}
+
+		result.Attempt, err = queries.CreateScheduledTransferAttempt(ctx, attempt)
+		if err != nil {
+			return err
+		}
+		result.ScheduledTransfer, err = queries.FinishScheduledTransfer(ctx, finish)
+		return err
+	})
+	return result, err
+}
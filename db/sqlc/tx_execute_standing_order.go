@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/SaishNaik/simplebank/utils"
+	"time"
+)
+
+type ExecuteStandingOrderTxParams struct {
+	// Now is the time up to which standing orders are due
+	Now time.Time `json:"now"`
+	// MaxRetries is how many times an occurrence refused for insufficient funds is attempted again
+	MaxRetries    int           `json:"max_retries"`
+	RetryInterval time.Duration `json:"retry_interval"`
+	// PayeeCoolingOffThresholds holds per currency the smallest amount refused while the payee is cooling off
+	PayeeCoolingOffThresholds map[string]int64 `json:"payee_cooling_off_thresholds"`
+}
+
+type ExecuteStandingOrderTxResult struct {
+	StandingOrder StandingOrder          `json:"standing_order"`
+	Execution     StandingOrderExecution `json:"execution"`
+	// Transfer is only set when the execution succeeded
+	Transfer *TransferTxResult `json:"transfer,omitempty"`
+	// WillRetry is set when a failed occurrence is attempted again later
+	WillRetry bool `json:"will_retry"`
+}
+
+// ExecuteStandingOrderTx claims the standing order with the oldest due occurrence and pays it.
+// Like ExecuteScheduledTransferTx, orders locked by another executor are skipped.
+// An occurrence refused for insufficient funds is retried up to MaxRetries times,
+// after that, or when an account is not active, a transfer limit is exceeded or the payee is cooling off,
+// it is recorded as failed and the order moves on.
+// It returns sql.ErrNoRows if no standing order is due.
+func (store *SQLStore) ExecuteStandingOrderTx(ctx context.Context, arg ExecuteStandingOrderTxParams) (ExecuteStandingOrderTxResult, error) {
+	var result ExecuteStandingOrderTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		order, err := queries.ClaimDueStandingOrder(ctx, arg.Now)
+		if err != nil {
+			return err
+		}
+
+		execution := CreateStandingOrderExecutionParams{
+			StandingOrderID: order.ID,
+			OccurrenceAt:    order.NextOccurrenceAt,
+		}
+		schedule := UpdateStandingOrderScheduleParams{
+			Occurrences:      order.Occurrences,
+			NextOccurrenceAt: order.NextOccurrenceAt,
+			NextRunAt:        order.NextRunAt,
+			Retries:          order.Retries,
+			Status:           order.Status,
+			ID:               order.ID,
+		}
+
+		// the payee may have been added after the payment was scheduled
+		var transferResult TransferTxResult
+		err = checkPayeeCoolingOff(ctx, queries, order.Owner, order.ToAccountID, order.Currency, order.Amount, arg.PayeeCoolingOffThresholds)
+		if err == nil {
+			transferResult, err = chargedTransfer(ctx, queries, CreateTransferParams{
+				FromAccountID: order.FromAccountID,
+				ToAccountID:   order.ToAccountID,
+				Amount:        order.Amount,
+			}, true)
+		}
+		switch {
+		case err == nil:
+			execution.Succeeded = true
+			execution.TransferID = sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true}
+			result.Transfer = &transferResult
+			nextOccurrence(order, &schedule)
+		case errors.Is(err, ErrInsufficientFunds) && int(order.Retries) < arg.MaxRetries:
+			execution.FailureReason = err.Error()
+			schedule.Retries++
+			schedule.NextRunAt = arg.Now.Add(arg.RetryInterval)
+			result.WillRetry = true
+		case errors.Is(err, ErrAccountNotActive), errors.Is(err, ErrInsufficientFunds), errors.Is(err, ErrTransferLimitExceeded),
+			errors.Is(err, ErrApprovalRequired), errors.Is(err, ErrPayeeCoolingOff):
+			// nothing has been written yet, the transaction can still record the failure
+			execution.FailureReason = err.Error()
+			nextOccurrence(order, &schedule)
+		default:
+			return err
+		}
+
+		result.Execution, err = queries.CreateStandingOrderExecution(ctx, execution)
+		if err != nil {
+			return err
+		}
+		result.StandingOrder, err = queries.UpdateStandingOrderSchedule(ctx, schedule)
+		return err
+	})
+	return result, err
+}
+
+// nextOccurrence moves the schedule past the current occurrence, completing the order after its last one
+func nextOccurrence(order StandingOrder, schedule *UpdateStandingOrderScheduleParams) {
+	schedule.Occurrences = order.Occurrences + 1
+	schedule.Retries = 0
+
+	next, ok := StandingOrderRecurrence(order).Occurrence(int(schedule.Occurrences))
+	if !ok {
+		schedule.Status = utils.StandingOrderStatusCompleted
+		return
+	}
+	schedule.NextOccurrenceAt = next
+	schedule.NextRunAt = next
+}
+
+// StandingOrderRecurrence returns the recurrence rule of a standing order
+func StandingOrderRecurrence(order StandingOrder) utils.Recurrence {
+	return utils.Recurrence{
+		Frequency:  order.Frequency,
+		DayOfMonth: int(order.DayOfMonth),
+		StartAt:    order.StartAt,
+		EndAt:      order.EndAt.Time,
+		Count:      int(order.MaxOccurrences.Int32),
+	}
+}
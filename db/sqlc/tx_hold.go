@@ -0,0 +1,254 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/SaishNaik/simplebank/utils"
+	"log/slog"
+	"time"
+)
+
+var (
+	// ErrHoldNotAuthorized is returned when capturing or voiding a hold that was already captured, voided or expired
+	ErrHoldNotAuthorized = errors.New("hold is no longer authorized")
+	// ErrHoldExpired is returned when capturing a hold past its expiry that has not been released yet
+	ErrHoldExpired = errors.New("hold has expired")
+	// ErrCaptureExceedsHold is returned when capturing more than the amount held
+	ErrCaptureExceedsHold = errors.New("capture exceeds the amount held")
+)
+
+type AuthorizeTransferTxParams struct {
+	Owner         string    `json:"owner"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type AuthorizeTransferTxResult struct {
+	Hold        Hold    `json:"hold"`
+	FromAccount Account `json:"from_account"`
+}
+
+// AuthorizeTransferTx reserves the amount and fee of a transfer on the from account without moving them.
+// The fee is that of the fee rule of the from account, charged in full on capture.
+// They are taken off the available balance until the hold is captured, voided or expires.
+// Like TransferTx it is refused at the approval threshold or over the transfer limits of the from account.
+func (store *SQLStore) AuthorizeTransferTx(ctx context.Context, arg AuthorizeTransferTxParams) (AuthorizeTransferTxResult, error) {
+	var result AuthorizeTransferTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		fromAccount, err := lockAccounts(ctx, queries, arg.FromAccountID, arg.ToAccountID)
+		if err != nil {
+			return err
+		}
+		if needsApproval(fromAccount, arg.Amount) {
+			return fmt.Errorf("%w: account [%d] needs approval from %d", ErrApprovalRequired, fromAccount.ID, fromAccount.ApprovalThreshold)
+		}
+		if err := checkTransferLimits(ctx, queries, fromAccount, arg.Amount); err != nil {
+			return err
+		}
+		fee, feeAccountID, err := transferFee(ctx, queries, fromAccount, arg.Amount)
+		if err != nil {
+			return err
+		}
+		if fromAccount.AvailableBalance < arg.Amount+fee {
+			return fmt.Errorf("%w: account [%d]", ErrInsufficientFunds, fromAccount.ID)
+		}
+
+		result.FromAccount, err = queries.AddAccountHeldBalance(ctx, AddAccountHeldBalanceParams{
+			Amount: arg.Amount + fee,
+			ID:     arg.FromAccountID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = queries.CreateHold(ctx, CreateHoldParams{
+			Owner:         arg.Owner,
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			Currency:      arg.Currency,
+			ExpiresAt:     arg.ExpiresAt,
+			Fee:           fee,
+			FeeAccountID:  sql.NullInt64{Int64: feeAccountID, Valid: fee > 0},
+		})
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	slog.InfoContext(ctx, "transfer authorized",
+		"hold_id", result.Hold.ID,
+		"from_account_id", arg.FromAccountID,
+		"to_account_id", arg.ToAccountID,
+		"amount", arg.Amount,
+		"fee", result.Hold.Fee,
+	)
+	return result, nil
+}
+
+type CaptureHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+	// Amount to move, zero captures the whole hold. The rest of the hold is released.
+	Amount int64 `json:"amount"`
+}
+
+type CaptureHoldTxResult struct {
+	Hold     Hold             `json:"hold"`
+	Transfer TransferTxResult `json:"transfer"`
+}
+
+// CaptureHoldTx settles an authorized hold, fully or partially, with a transfer to the to account
+// and releases the whole hold from the held balance of the from account.
+// The fee held is charged in full, even when only part of the amount is captured.
+func (store *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		hold, err := lockAuthorizedHold(ctx, queries, arg.HoldID)
+		if err != nil {
+			return err
+		}
+		if !hold.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("%w: hold [%d] expired at %s", ErrHoldExpired, hold.ID, hold.ExpiresAt)
+		}
+		amount := arg.Amount
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount > hold.Amount {
+			return fmt.Errorf("%w: hold [%d] is %d", ErrCaptureExceedsHold, hold.ID, hold.Amount)
+		}
+
+		// locks every account of the transfer in id order before the held balance of the from account is released
+		accountIDs := []int64{hold.ToAccountID}
+		if hold.Fee > 0 {
+			accountIDs = append(accountIDs, hold.FeeAccountID.Int64)
+		}
+		_, err = lockAccounts(ctx, queries, hold.FromAccountID, accountIDs...)
+		if err != nil {
+			return err
+		}
+		_, err = queries.AddAccountHeldBalance(ctx, AddAccountHeldBalanceParams{
+			Amount: -(hold.Amount + hold.Fee),
+			ID:     hold.FromAccountID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, err = transferWithFee(ctx, queries, CreateTransferParams{
+			FromAccountID: hold.FromAccountID,
+			ToAccountID:   hold.ToAccountID,
+			Amount:        amount,
+			Fee:           hold.Fee,
+		}, hold.FeeAccountID.Int64, false)
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = queries.FinishHold(ctx, FinishHoldParams{
+			Status:         utils.HoldStatusCaptured,
+			CapturedAmount: amount,
+			TransferID:     sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true},
+			ID:             hold.ID,
+		})
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	slog.InfoContext(ctx, "hold captured",
+		"hold_id", result.Hold.ID,
+		"transfer_id", result.Transfer.Transfer.ID,
+		"amount", result.Hold.CapturedAmount,
+	)
+	return result, nil
+}
+
+type VoidHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+}
+
+type VoidHoldTxResult struct {
+	Hold        Hold    `json:"hold"`
+	FromAccount Account `json:"from_account"`
+}
+
+// VoidHoldTx cancels an authorized hold and releases its amount and fee
+func (store *SQLStore) VoidHoldTx(ctx context.Context, arg VoidHoldTxParams) (VoidHoldTxResult, error) {
+	var result VoidHoldTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		hold, err := lockAuthorizedHold(ctx, queries, arg.HoldID)
+		if err != nil {
+			return err
+		}
+		result.Hold, result.FromAccount, err = releaseHold(ctx, queries, hold, utils.HoldStatusVoided)
+		return err
+	})
+	return result, err
+}
+
+type ExpireHoldTxParams struct {
+	// Now is the time up to which authorized holds have expired
+	Now time.Time `json:"now"`
+}
+
+type ExpireHoldTxResult struct {
+	Hold        Hold    `json:"hold"`
+	FromAccount Account `json:"from_account"`
+}
+
+// ExpireHoldTx claims the oldest expired authorized hold and releases its amount and fee.
+// Holds locked by other executors are skipped, so several server instances can run it concurrently.
+// It returns sql.ErrNoRows if no hold has expired.
+func (store *SQLStore) ExpireHoldTx(ctx context.Context, arg ExpireHoldTxParams) (ExpireHoldTxResult, error) {
+	var result ExpireHoldTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		hold, err := queries.ClaimExpiredHold(ctx, arg.Now)
+		if err != nil {
+			return err
+		}
+		result.Hold, result.FromAccount, err = releaseHold(ctx, queries, hold, utils.HoldStatusExpired)
+		return err
+	})
+	return result, err
+}
+
+// lockAuthorizedHold locks a hold, so it cannot be captured and voided concurrently, and checks it is still authorized
+func lockAuthorizedHold(ctx context.Context, q *Queries, holdID int64) (Hold, error) {
+	hold, err := q.GetHoldWithUpdate(ctx, holdID)
+	if err != nil {
+		return hold, err
+	}
+	if hold.Status != utils.HoldStatusAuthorized {
+		return hold, fmt.Errorf("%w: hold [%d] is %s", ErrHoldNotAuthorized, hold.ID, hold.Status)
+	}
+	return hold, nil
+}
+
+// releaseHold gives the amount and fee of a locked hold back to the available balance and finishes it with status
+func releaseHold(ctx context.Context, q *Queries, hold Hold, status string) (Hold, Account, error) {
+	fromAccount, err := q.AddAccountHeldBalance(ctx, AddAccountHeldBalanceParams{
+		Amount: -(hold.Amount + hold.Fee),
+		ID:     hold.FromAccountID,
+	})
+	if err != nil {
+		return hold, fromAccount, err
+	}
+
+	hold, err = q.FinishHold(ctx, FinishHoldParams{
+		Status: status,
+		ID:     hold.ID,
+	})
+	return hold, fromAccount, err
+}
@@ -0,0 +1,193 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/SaishNaik/simplebank/utils"
+	"log/slog"
+	"time"
+)
+
+var (
+	// ErrPaymentRequestNotPending is returned when acting on a payment request that was already accepted, declined,
+	// cancelled or expired
+	ErrPaymentRequestNotPending = errors.New("payment request is no longer pending")
+	// ErrPaymentRequestExpired is returned when acting on a payment request past its expiry that has not been expired yet
+	ErrPaymentRequestExpired = errors.New("payment request has expired")
+)
+
+type CreatePaymentRequestTxParams struct {
+	CreatePaymentRequestParams
+}
+
+type CreatePaymentRequestTxResult struct {
+	PaymentRequest PaymentRequest      `json:"payment_request"`
+	Event          PaymentRequestEvent `json:"event"`
+}
+
+// CreatePaymentRequestTx asks the payer to pay the requester, recording the request as the first event of its history
+func (store *SQLStore) CreatePaymentRequestTx(ctx context.Context, arg CreatePaymentRequestTxParams) (CreatePaymentRequestTxResult, error) {
+	var result CreatePaymentRequestTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		var err error
+		result.PaymentRequest, err = queries.CreatePaymentRequest(ctx, arg.CreatePaymentRequestParams)
+		if err != nil {
+			return err
+		}
+		result.Event, err = queries.CreatePaymentRequestEvent(ctx, CreatePaymentRequestEventParams{
+			PaymentRequestID: result.PaymentRequest.ID,
+			Status:           result.PaymentRequest.Status,
+			Actor:            arg.Requester,
+		})
+		return err
+	})
+	return result, err
+}
+
+type AcceptPaymentRequestTxParams struct {
+	PaymentRequestID int64  `json:"payment_request_id"`
+	Payer            string `json:"payer"`
+	FromAccountID    int64  `json:"from_account_id"`
+}
+
+type AcceptPaymentRequestTxResult struct {
+	PaymentRequest PaymentRequest      `json:"payment_request"`
+	Transfer       TransferTxResult    `json:"transfer"`
+	Event          PaymentRequestEvent `json:"event"`
+}
+
+// AcceptPaymentRequestTx pays a pending payment request with a transfer from the account of the payer.
+// The request is locked, so it cannot be paid twice or cancelled while it is paid. Like TransferTx it refuses
+// to take the available balance of the payer below zero.
+func (store *SQLStore) AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error) {
+	var result AcceptPaymentRequestTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		request, err := lockPendingPaymentRequest(ctx, queries, arg.PaymentRequestID)
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, err = chargedTransfer(ctx, queries, CreateTransferParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   request.ToAccountID,
+			Amount:        request.Amount,
+			Description:   request.Description,
+		}, true)
+		if err != nil {
+			return err
+		}
+
+		result.PaymentRequest, result.Event, err = closePaymentRequest(ctx, queries, FinishPaymentRequestParams{
+			Status:     utils.PaymentRequestStatusAccepted,
+			TransferID: sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true},
+			ID:         request.ID,
+		}, arg.Payer)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	slog.InfoContext(ctx, "payment request accepted",
+		"payment_request_id", result.PaymentRequest.ID,
+		"transfer_id", result.Transfer.Transfer.ID,
+		"amount", result.PaymentRequest.Amount,
+	)
+	return result, nil
+}
+
+type UpdatePaymentRequestStatusTxParams struct {
+	PaymentRequestID int64 `json:"payment_request_id"`
+	// Status is declined or cancelled
+	Status string `json:"status"`
+	Actor  string `json:"actor"`
+}
+
+type UpdatePaymentRequestStatusTxResult struct {
+	PaymentRequest PaymentRequest      `json:"payment_request"`
+	Event          PaymentRequestEvent `json:"event"`
+}
+
+// UpdatePaymentRequestStatusTx closes a pending payment request without paying it
+func (store *SQLStore) UpdatePaymentRequestStatusTx(ctx context.Context, arg UpdatePaymentRequestStatusTxParams) (UpdatePaymentRequestStatusTxResult, error) {
+	var result UpdatePaymentRequestStatusTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		request, err := lockPendingPaymentRequest(ctx, queries, arg.PaymentRequestID)
+		if err != nil {
+			return err
+		}
+		result.PaymentRequest, result.Event, err = closePaymentRequest(ctx, queries, FinishPaymentRequestParams{
+			Status: arg.Status,
+			ID:     request.ID,
+		}, arg.Actor)
+		return err
+	})
+	return result, err
+}
+
+type ExpirePaymentRequestsTxParams struct {
+	// Now is the time up to which pending payment requests have expired
+	Now time.Time `json:"now"`
+}
+
+type ExpirePaymentRequestsTxResult struct {
+	PaymentRequests []PaymentRequest `json:"payment_requests"`
+}
+
+// ExpirePaymentRequestsTx expires the pending payment requests expired by now and adds the expiry to their history
+func (store *SQLStore) ExpirePaymentRequestsTx(ctx context.Context, arg ExpirePaymentRequestsTxParams) (ExpirePaymentRequestsTxResult, error) {
+	var result ExpirePaymentRequestsTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		var err error
+		result.PaymentRequests, err = queries.ExpirePaymentRequests(ctx, arg.Now)
+		if err != nil {
+			return err
+		}
+		for _, request := range result.PaymentRequests {
+			_, err = queries.CreatePaymentRequestEvent(ctx, CreatePaymentRequestEventParams{
+				PaymentRequestID: request.ID,
+				Status:           request.Status,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// lockPendingPaymentRequest locks a payment request and checks it can still be accepted, declined or cancelled
+func lockPendingPaymentRequest(ctx context.Context, q *Queries, id int64) (PaymentRequest, error) {
+	request, err := q.GetPaymentRequestForUpdate(ctx, id)
+	if err != nil {
+		return request, err
+	}
+	if request.Status != utils.PaymentRequestStatusPending {
+		return request, fmt.Errorf("%w: payment request [%d] is %s", ErrPaymentRequestNotPending, request.ID, request.Status)
+	}
+	if request.ExpiresAt.Valid && !request.ExpiresAt.Time.After(time.Now()) {
+		return request, fmt.Errorf("%w: payment request [%d] expired at %s", ErrPaymentRequestExpired, request.ID, request.ExpiresAt.Time)
+	}
+	return request, nil
+}
+
+// closePaymentRequest updates the status of a locked payment request and adds it to its history
+func closePaymentRequest(ctx context.Context, q *Queries, arg FinishPaymentRequestParams, actor string) (PaymentRequest, PaymentRequestEvent, error) {
+	request, err := q.FinishPaymentRequest(ctx, arg)
+	if err != nil {
+		return request, PaymentRequestEvent{}, err
+	}
+	event, err := q.CreatePaymentRequestEvent(ctx, CreatePaymentRequestEventParams{
+		PaymentRequestID: request.ID,
+		Status:           request.Status,
+		Actor:            actor,
+	})
+	return request, event, err
+}
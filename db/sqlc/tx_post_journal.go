@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/SaishNaik/simplebank/utils"
+	"slices"
+)
+
+var (
+	// ErrInvalidJournal is returned when a journal has fewer than two lines or a line without amount
+	ErrInvalidJournal = errors.New("journal needs at least two lines with non zero amounts")
+	// ErrUnbalancedJournal is returned when the lines of a journal do not sum to zero in every currency
+	ErrUnbalancedJournal = errors.New("journal lines do not balance")
+)
+
+type JournalLine struct {
+	AccountID int64 `json:"account_id"`
+	// Amount credits the account when positive and debits it when negative
+	Amount int64 `json:"amount"`
+}
+
+type PostJournalParams struct {
+	Lines []JournalLine `json:"lines"`
+	// RequireFunds refuses to take the available balance of a debited account below zero
+	RequireFunds bool `json:"require_funds"`
+}
+
+type PostJournalResult struct {
+	Journal Journal `json:"journal"`
+	// Entries holds the entry of each line, in the order of the lines
+	Entries []Entry `json:"entries"`
+	// Accounts holds every account of the lines once, in id order, as they are after posting
+	Accounts []Account `json:"accounts"`
+}
+
+// PostJournal writes the lines of a journal as entries and updates the balances of their accounts.
+// The lines must sum to zero per currency, a journal cannot create or destroy money.
+func (store *SQLStore) PostJournal(ctx context.Context, arg PostJournalParams) (PostJournalResult, error) {
+	var result PostJournalResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		var err error
+		result, err = postJournal(ctx, queries, arg)
+		return err
+	})
+	return result, err
+}
+
+// postJournal posts a journal inside the transaction of queries.
+// Every check runs before anything is written, so a caller can still record why a journal was refused.
+func postJournal(ctx context.Context, queries *Queries, arg PostJournalParams) (PostJournalResult, error) {
+	var result PostJournalResult
+	if len(arg.Lines) < 2 {
+		return result, ErrInvalidJournal
+	}
+
+	net := make(map[int64]int64)
+	ids := make([]int64, 0, len(arg.Lines))
+	for _, line := range arg.Lines {
+		if line.Amount == 0 {
+			return result, fmt.Errorf("%w: account [%d]", ErrInvalidJournal, line.AccountID)
+		}
+		if _, ok := net[line.AccountID]; !ok {
+			ids = append(ids, line.AccountID)
+		}
+		net[line.AccountID] += line.Amount
+	}
+
+	accounts, err := lockAccountIDs(ctx, queries, ids)
+	if err != nil {
+		return result, err
+	}
+
+	totals := make(map[string]int64)
+	for _, account := range accounts {
+		totals[account.Currency] += net[account.ID]
+		if arg.RequireFunds && net[account.ID] < 0 && account.AvailableBalance+net[account.ID] < 0 {
+			return result, fmt.Errorf("%w: account [%d]", ErrInsufficientFunds, account.ID)
+		}
+	}
+	for currency, total := range totals {
+		if total != 0 {
+			return result, fmt.Errorf("%w: %s is off by %d", ErrUnbalancedJournal, currency, total)
+		}
+	}
+
+	result.Journal, err = queries.CreateJournal(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	journalID := sql.NullInt64{Int64: result.Journal.ID, Valid: true}
+	result.Entries = make([]Entry, len(arg.Lines))
+	for i, line := range arg.Lines {
+		result.Entries[i], err = queries.CreateEntry(ctx, CreateEntryParams{
+			AccountID: line.AccountID,
+			Amount:    line.Amount,
+			JournalID: journalID,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// balances are updated in the id order the accounts were locked in
+	for i, account := range accounts {
+		if net[account.ID] == 0 {
+			continue
+		}
+		accounts[i], err = queries.AddAccountBalance(ctx, AddAccountBalanceParams{
+			Amount: net[account.ID],
+			ID:     account.ID,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	result.Accounts = accounts
+	return result, nil
+}
+
+// lockAccountIDs locks the accounts in id order, so concurrent transactions locking overlapping accounts
+// cannot deadlock, and checks they are all active. It returns the accounts in id order.
+func lockAccountIDs(ctx context.Context, q *Queries, ids []int64) ([]Account, error) {
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	accounts := make([]Account, 0, len(ids))
+	for _, id := range ids {
+		account, err := q.GetAccountWithUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if account.Status != utils.AccountStatusActive {
+			return nil, fmt.Errorf("%w: account [%d] is %s", ErrAccountNotActive, account.ID, account.Status)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ResetPasswordTxParams struct {
+	TokenHash      string    `json:"token_hash"`
+	HashedPassword string    `json:"hashed_password"`
+	ChangedAt      time.Time `json:"changed_at"`
+}
+
+type ResetPasswordTxResult struct {
+	User User `json:"user"`
+}
+
+// ResetPasswordTx uses the reset token and replaces the password of its user, moving the old one to the
+// password history and invalidating any other outstanding reset token. It returns sql.ErrNoRows if the token does not exist, was used before or has expired.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		resetToken, err := queries.UsePasswordResetToken(ctx, arg.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		// keep the old password so that it cannot be chosen again
+		err = queries.ArchiveUserPassword(ctx, resetToken.Username)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = queries.UpdateUser(ctx, UpdateUserParams{
+			HashedPassword:    sql.NullString{String: arg.HashedPassword, Valid: true},
+			PasswordChangedAt: sql.NullTime{Time: arg.ChangedAt, Valid: true},
+			Username:          resetToken.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		return queries.InvalidatePasswordResetTokens(ctx, resetToken.Username)
+	})
+	return result, err
+}
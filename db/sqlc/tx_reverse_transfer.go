@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/SaishNaik/simplebank/utils"
+	"log/slog"
+)
+
+var (
+	// ErrTransferIsReversal is returned when reversing a transfer that is itself a reversal
+	ErrTransferIsReversal = errors.New("a reversal cannot be reversed")
+	// ErrReversalExceedsTransfer is returned when the reversals of a transfer would refund more than its amount
+	ErrReversalExceedsTransfer = errors.New("reversal exceeds the amount left to reverse")
+	// ErrTransferNotCompleted is returned when reversing a transfer that is pending, rejected or expired
+	ErrTransferNotCompleted = errors.New("only completed transfers can be reversed")
+)
+
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// Amount to refund, zero refunds whatever has not been reversed yet
+	Amount int64 `json:"amount"`
+}
+
+type ReverseTransferTxResult struct {
+	OriginalTransfer Transfer         `json:"original_transfer"`
+	Reversal         TransferTxResult `json:"reversal"`
+	// ReversedAmount is the total refunded on the original transfer, this reversal included
+	ReversedAmount int64 `json:"reversed_amount"`
+}
+
+// ReverseTransferTx refunds all or part of a transfer by moving the money back from its recipient.
+// The original transfer is locked so concurrent reversals cannot refund more than it moved.
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error) {
+	var result ReverseTransferTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		original, err := queries.GetTransferWithUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+		if original.ReversesTransferID.Valid {
+			return ErrTransferIsReversal
+		}
+		if original.Status != utils.TransferStatusCompleted {
+			return fmt.Errorf("%w: transfer [%d] is %s", ErrTransferNotCompleted, original.ID, original.Status)
+		}
+		result.OriginalTransfer = original
+
+		transferID := sql.NullInt64{Int64: original.ID, Valid: true}
+		reversed, err := queries.GetReversedAmount(ctx, transferID)
+		if err != nil {
+			return err
+		}
+		remaining := original.Amount - reversed
+		amount := arg.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount <= 0 || amount > remaining {
+			return fmt.Errorf("%w: transfer [%d] has %d left", ErrReversalExceedsTransfer, original.ID, remaining)
+		}
+
+		result.Reversal, err = transfer(ctx, queries, CreateTransferParams{
+			FromAccountID:      original.ToAccountID,
+			ToAccountID:        original.FromAccountID,
+			Amount:             amount,
+			ReversesTransferID: transferID,
+		}, true)
+		if err != nil {
+			return err
+		}
+		result.ReversedAmount = reversed + amount
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	slog.InfoContext(ctx, "transfer reversed",
+		"transfer_id", arg.TransferID,
+		"reversal_id", result.Reversal.Transfer.ID,
+		"amount", result.Reversal.Transfer.Amount,
+	)
+	return result, nil
+}
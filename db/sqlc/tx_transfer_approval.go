@@ -0,0 +1,218 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/SaishNaik/simplebank/utils"
+	"log/slog"
+	"time"
+)
+
+var (
+	// ErrTransferNotPending is returned when approving or rejecting a transfer that is no longer pending
+	ErrTransferNotPending = errors.New("transfer is no longer pending")
+	// ErrTransferApprovalExpired is returned when approving a pending transfer past its expiry that has not been expired yet
+	ErrTransferApprovalExpired = errors.New("transfer approval has expired")
+	// ErrSelfApproval is returned when the user who requested a transfer approves or rejects it
+	ErrSelfApproval = errors.New("a transfer cannot be approved by the user who requested it")
+	// ErrAlreadyDecided is returned when an approver decides on the same transfer twice
+	ErrAlreadyDecided = errors.New("approver already decided on the transfer")
+)
+
+type ApproveTransferTxParams struct {
+	TransferID int64  `json:"transfer_id"`
+	Approver   string `json:"approver"`
+}
+
+type ApproveTransferTxResult struct {
+	Transfer Transfer         `json:"transfer"`
+	Approval TransferApproval `json:"approval"`
+	// Completed holds the entries and accounts of the transfer, it is only set once this approval completed it
+	Completed *TransferTxResult `json:"completed,omitempty"`
+}
+
+// ApproveTransferTx records the approval of a pending transfer. The approval that brings the transfer
+// to its required approvals posts it, the money only moves then, with the fee charged at that moment.
+func (store *SQLStore) ApproveTransferTx(ctx context.Context, arg ApproveTransferTxParams) (ApproveTransferTxResult, error) {
+	var result ApproveTransferTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		pending, err := lockPendingTransfer(ctx, queries, arg.TransferID, arg.Approver)
+		if err != nil {
+			return err
+		}
+		result.Transfer = pending
+
+		result.Approval, err = decideTransfer(ctx, queries, pending, arg.Approver, true)
+		if err != nil {
+			return err
+		}
+		approvals, err := queries.CountTransferApprovals(ctx, pending.ID)
+		if err != nil {
+			return err
+		}
+		if approvals < int64(pending.RequiredApprovals) {
+			return nil
+		}
+
+		fromAccount, err := queries.GetAccount(ctx, pending.FromAccountID)
+		if err != nil {
+			return err
+		}
+		fee, feeAccountID, err := transferFee(ctx, queries, fromAccount, pending.Amount)
+		if err != nil {
+			return err
+		}
+		completed, err := postTransfer(ctx, queries, CreateTransferParams{
+			FromAccountID: pending.FromAccountID,
+			ToAccountID:   pending.ToAccountID,
+			Amount:        pending.Amount,
+			Fee:           fee,
+		}, feeAccountID, true, true)
+		if err != nil {
+			return err
+		}
+		completed.Transfer, err = queries.CompleteTransfer(ctx, CompleteTransferParams{
+			JournalID: completed.FromEntry.JournalID,
+			Fee:       fee,
+			ID:        pending.ID,
+		})
+		if err != nil {
+			return err
+		}
+		result.Transfer = completed.Transfer
+		result.Completed = &completed
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	slog.InfoContext(ctx, "transfer approved",
+		"transfer_id", result.Transfer.ID,
+		"approver", arg.Approver,
+		"status", result.Transfer.Status,
+	)
+	return result, nil
+}
+
+type RejectTransferTxParams struct {
+	TransferID int64  `json:"transfer_id"`
+	Approver   string `json:"approver"`
+}
+
+type RejectTransferTxResult struct {
+	Transfer Transfer         `json:"transfer"`
+	Approval TransferApproval `json:"approval"`
+}
+
+// RejectTransferTx records the rejection of a pending transfer, a single rejection rejects it
+func (store *SQLStore) RejectTransferTx(ctx context.Context, arg RejectTransferTxParams) (RejectTransferTxResult, error) {
+	var result RejectTransferTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		pending, err := lockPendingTransfer(ctx, queries, arg.TransferID, arg.Approver)
+		if err != nil {
+			return err
+		}
+		result.Approval, err = decideTransfer(ctx, queries, pending, arg.Approver, false)
+		if err != nil {
+			return err
+		}
+		result.Transfer, err = queries.UpdateTransferStatus(ctx, UpdateTransferStatusParams{
+			Status: utils.TransferStatusRejected,
+			ID:     pending.ID,
+		})
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	slog.InfoContext(ctx, "transfer rejected",
+		"transfer_id", result.Transfer.ID,
+		"approver", arg.Approver,
+	)
+	return result, nil
+}
+
+// lockPendingTransfer locks a transfer, so concurrent decisions are counted once,
+// and checks approver can still decide on it
+func lockPendingTransfer(ctx context.Context, q *Queries, transferID int64, approver string) (Transfer, error) {
+	transfer, err := q.GetTransferWithUpdate(ctx, transferID)
+	if err != nil {
+		return transfer, err
+	}
+	if transfer.Status != utils.TransferStatusPending {
+		return transfer, fmt.Errorf("%w: transfer [%d] is %s", ErrTransferNotPending, transfer.ID, transfer.Status)
+	}
+	if transfer.ExpiresAt.Valid && !transfer.ExpiresAt.Time.After(time.Now()) {
+		return transfer, fmt.Errorf("%w: transfer [%d] expired at %s", ErrTransferApprovalExpired, transfer.ID, transfer.ExpiresAt.Time)
+	}
+	if transfer.RequestedBy == approver {
+		return transfer, ErrSelfApproval
+	}
+	return transfer, nil
+}
+
+// decideTransfer records the decision of approver on a locked pending transfer
+func decideTransfer(ctx context.Context, q *Queries, transfer Transfer, approver string, approved bool) (TransferApproval, error) {
+	approval, err := q.CreateTransferApproval(ctx, CreateTransferApprovalParams{
+		TransferID: transfer.ID,
+		Approver:   approver,
+		Approved:   approved,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return approval, fmt.Errorf("%w: %s on transfer [%d]", ErrAlreadyDecided, approver, transfer.ID)
+	}
+	return approval, err
+}
+
+type UpdateApprovalPolicyTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// ApprovalThreshold is the amount from which transfers need approval, zero turns approvals off
+	ApprovalThreshold int64    `json:"approval_threshold"`
+	RequiredApprovals int32    `json:"required_approvals"`
+	Approvers         []string `json:"approvers"`
+}
+
+type UpdateApprovalPolicyTxResult struct {
+	Account   Account           `json:"account"`
+	Approvers []AccountApprover `json:"approvers"`
+}
+
+// UpdateApprovalPolicyTx sets the approval threshold of an account and replaces its designated approvers
+func (store *SQLStore) UpdateApprovalPolicyTx(ctx context.Context, arg UpdateApprovalPolicyTxParams) (UpdateApprovalPolicyTxResult, error) {
+	var result UpdateApprovalPolicyTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		var err error
+		result.Account, err = queries.UpdateAccountApprovalPolicy(ctx, UpdateAccountApprovalPolicyParams{
+			ApprovalThreshold: arg.ApprovalThreshold,
+			RequiredApprovals: arg.RequiredApprovals,
+			ID:                arg.AccountID,
+		})
+		if err != nil {
+			return err
+		}
+		if err = queries.DeleteAccountApprovers(ctx, arg.AccountID); err != nil {
+			return err
+		}
+
+		result.Approvers = make([]AccountApprover, 0, len(arg.Approvers))
+		for _, username := range arg.Approvers {
+			approver, err := queries.CreateAccountApprover(ctx, CreateAccountApproverParams{
+				AccountID: arg.AccountID,
+				Username:  username,
+			})
+			if err != nil {
+				return err
+			}
+			result.Approvers = append(result.Approvers, approver)
+		}
+		return nil
+	})
+	return result, err
+}
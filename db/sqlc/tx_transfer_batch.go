@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/SaishNaik/simplebank/utils"
+	"log/slog"
+	"time"
+)
+
+type TransferBatchItemParams struct {
+	ToAccountID       int64  `json:"to_account_id"`
+	Amount            int64  `json:"amount"`
+	Description       string `json:"description"`
+	ExternalReference string `json:"external_reference"`
+}
+
+type CreateTransferBatchTxParams struct {
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	Currency      string `json:"currency"`
+	// Atomic transfers every item in one transaction, otherwise each item is transferred on its own
+	Atomic bool                      `json:"atomic"`
+	Items  []TransferBatchItemParams `json:"items"`
+	// IdempotencyKey is unique per owner, the batch is recorded without one when it is empty
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+type CreateTransferBatchTxResult struct {
+	Batch TransferBatch       `json:"batch"`
+	Items []TransferBatchItem `json:"items"`
+}
+
+// CreateTransferBatchTx records a batch of transfers from one account and executes it.
+// An atomic batch either transfers every item or returns the error and records nothing.
+// Otherwise the batch and its items are recorded first and each item is transferred in its own transaction,
+// items refused because of the state of the accounts are recorded as failed and the others still go through.
+func (store *SQLStore) CreateTransferBatchTx(ctx context.Context, arg CreateTransferBatchTxParams) (CreateTransferBatchTxResult, error) {
+	var result CreateTransferBatchTxResult
+	var err error
+	if arg.Atomic {
+		err = store.execTx(ctx, func(queries *Queries) error {
+			result, err = recordTransferBatch(ctx, queries, arg)
+			if err != nil {
+				return err
+			}
+			for i := range result.Items {
+				result.Items[i], err = transferBatchItem(ctx, queries, result.Batch, result.Items[i])
+				if err != nil {
+					return err
+				}
+			}
+			result.Batch, err = queries.FinishTransferBatch(ctx, FinishTransferBatchParams{
+				Status: utils.TransferBatchStatusSucceeded,
+				ID:     result.Batch.ID,
+			})
+			return err
+		})
+	} else {
+		err = store.executeTransferBatchItems(ctx, arg, &result)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	slog.InfoContext(ctx, "transfer batch executed",
+		"batch_id", result.Batch.ID,
+		"from_account_id", arg.FromAccountID,
+		"items", len(result.Items),
+		"status", result.Batch.Status,
+	)
+	return result, nil
+}
+
+// executeTransferBatchItems records the batch, then transfers each item in its own transaction
+func (store *SQLStore) executeTransferBatchItems(ctx context.Context, arg CreateTransferBatchTxParams, result *CreateTransferBatchTxResult) error {
+	err := store.execTx(ctx, func(queries *Queries) error {
+		var err error
+		*result, err = recordTransferBatch(ctx, queries, arg)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	result.Batch, err = store.runTransferBatchItems(ctx, result.Batch, result.Items)
+	return err
+}
+
+type ResumeTransferBatchTxParams struct {
+	// Before is the time a batch must have been created by to be resumed, so batches still running are left alone
+	Before time.Time `json:"before"`
+}
+
+type ResumeTransferBatchTxResult struct {
+	Batch TransferBatch       `json:"batch"`
+	Items []TransferBatchItem `json:"items"`
+}
+
+// ResumeTransferBatchTx claims the oldest batch still pending since before, transfers its pending items and finishes it.
+// It picks up batches whose execution was interrupted after they were recorded, batches claimed by other executors
+// are skipped. It returns sql.ErrNoRows if no batch is left pending.
+func (store *SQLStore) ResumeTransferBatchTx(ctx context.Context, arg ResumeTransferBatchTxParams) (ResumeTransferBatchTxResult, error) {
+	var result ResumeTransferBatchTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		var err error
+		result.Batch, err = queries.ClaimStaleTransferBatch(ctx, arg.Before)
+		if err != nil {
+			return err
+		}
+		result.Items, err = queries.ListTransferBatchItems(ctx, result.Batch.ID)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Batch, err = store.runTransferBatchItems(ctx, result.Batch, result.Items)
+	return result, err
+}
+
+// runTransferBatchItems transfers each pending item of a recorded batch in its own transaction, updating items in place,
+// and finishes the batch. Every item is locked and skipped unless it is still pending, so a batch resumed while it is
+// still running elsewhere cannot transfer an item twice.
+func (store *SQLStore) runTransferBatchItems(ctx context.Context, batch TransferBatch, items []TransferBatchItem) (TransferBatch, error) {
+	succeeded := 0
+	for i, item := range items {
+		err := store.execTx(ctx, func(queries *Queries) error {
+			var err error
+			item, err = queries.GetTransferBatchItemForUpdate(ctx, item.ID)
+			if err != nil || item.Status != utils.TransferBatchItemStatusPending {
+				return err
+			}
+			item, err = transferBatchItem(ctx, queries, batch, item)
+			if errors.Is(err, ErrAccountNotActive) || errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrTransferLimitExceeded) ||
+				errors.Is(err, ErrApprovalRequired) {
+				// nothing has been written yet, the transaction can still record the failure
+				item, err = queries.FinishTransferBatchItem(ctx, FinishTransferBatchItemParams{
+					Status:        utils.TransferBatchItemStatusFailed,
+					FailureReason: err.Error(),
+					ID:            item.ID,
+				})
+			}
+			return err
+		})
+		if err != nil {
+			return batch, err
+		}
+		items[i] = item
+		if item.Status == utils.TransferBatchItemStatusSucceeded {
+			succeeded++
+		}
+	}
+
+	status := utils.TransferBatchStatusPartiallySucceeded
+	switch succeeded {
+	case len(items):
+		status = utils.TransferBatchStatusSucceeded
+	case 0:
+		status = utils.TransferBatchStatusFailed
+	}
+	return store.FinishTransferBatch(ctx, FinishTransferBatchParams{
+		Status: status,
+		ID:     batch.ID,
+	})
+}
+
+// recordTransferBatch records a pending batch and its pending items
+func recordTransferBatch(ctx context.Context, queries *Queries, arg CreateTransferBatchTxParams) (CreateTransferBatchTxResult, error) {
+	var result CreateTransferBatchTxResult
+
+	var total int64
+	for _, item := range arg.Items {
+		total += item.Amount
+	}
+	batch, err := queries.CreateTransferBatch(ctx, CreateTransferBatchParams{
+		Owner:          arg.Owner,
+		FromAccountID:  arg.FromAccountID,
+		Currency:       arg.Currency,
+		Atomic:         arg.Atomic,
+		TotalAmount:    total,
+		IdempotencyKey: sql.NullString{String: arg.IdempotencyKey, Valid: arg.IdempotencyKey != ""},
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Batch = batch
+
+	result.Items = make([]TransferBatchItem, len(arg.Items))
+	for i, item := range arg.Items {
+		result.Items[i], err = queries.CreateTransferBatchItem(ctx, CreateTransferBatchItemParams{
+			BatchID:           batch.ID,
+			ToAccountID:       item.ToAccountID,
+			Amount:            item.Amount,
+			Description:       item.Description,
+			ExternalReference: item.ExternalReference,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// transferBatchItem transfers a pending item without overdrawing the from account of the batch
+func transferBatchItem(ctx context.Context, queries *Queries, batch TransferBatch, item TransferBatchItem) (TransferBatchItem, error) {
+	transferResult, err := chargedTransfer(ctx, queries, CreateTransferParams{
+		FromAccountID:     batch.FromAccountID,
+		ToAccountID:       item.ToAccountID,
+		Amount:            item.Amount,
+		Description:       item.Description,
+		ExternalReference: item.ExternalReference,
+	}, true)
+	if err != nil {
+		return item, err
+	}
+
+	return queries.FinishTransferBatchItem(ctx, FinishTransferBatchItemParams{
+		Status:     utils.TransferBatchItemStatusSucceeded,
+		TransferID: sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true},
+		ID:         item.ID,
+	})
+}
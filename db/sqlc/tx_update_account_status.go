@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/SaishNaik/simplebank/utils"
+)
+
+var (
+	// ErrInvalidStatusTransition is returned when an account cannot move from its current status to the requested one
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+	// ErrAccountNotEmpty is returned when closing an account that still holds money
+	ErrAccountNotEmpty = errors.New("account balance must be zero to close it")
+)
+
+type UpdateAccountStatusTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Status    string `json:"status"`
+}
+
+type UpdateAccountStatusTxResult struct {
+	Account Account `json:"account"`
+}
+
+// UpdateAccountStatusTx freezes, unfreezes, closes or reopens an account.
+// The account is locked first so a concurrent transfer cannot change the balance of an account being closed.
+func (store *SQLStore) UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (UpdateAccountStatusTxResult, error) {
+	var result UpdateAccountStatusTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		account, err := queries.GetAccountWithUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if !utils.CanTransitionAccountStatus(account.Status, arg.Status) {
+			return ErrInvalidStatusTransition
+		}
+		if arg.Status == utils.AccountStatusClosed && account.Balance != 0 {
+			return ErrAccountNotEmpty
+		}
+
+		result.Account, err = queries.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			Status: arg.Status,
+			ID:     arg.AccountID,
+		})
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type UpdateUserTxParams struct {
+	UpdateUserParams
+	// PendingEmail is the new email the user asked for, it only replaces their email once it is verified
+	PendingEmail sql.NullString `json:"pending_email"`
+	// VerifyEmailCodeHash is the hash of the secret code sent to verify the pending email, it is only used when PendingEmail is set
+	VerifyEmailCodeHash  string    `json:"verify_email_code_hash"`
+	VerifyEmailExpiredAt time.Time `json:"verify_email_expired_at"`
+}
+
+type UpdateUserTxResult struct {
+	User User `json:"user"`
+	// VerifyEmail is the code for the pending email, it is only set when PendingEmail is
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// UpdateUserTx updates the given fields of a user. A new email is kept as their pending email with a verification code,
+// their current email stays in use until VerifyEmailTx is called with that code, which the caller sends once the
+// transaction has committed.
+func (store *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		var err error
+
+		result.User, err = queries.UpdateUser(ctx, arg.UpdateUserParams)
+		if err != nil {
+			return err
+		}
+		if !arg.PendingEmail.Valid {
+			return nil
+		}
+
+		result.User, err = queries.SetUserPendingEmail(ctx, SetUserPendingEmailParams{
+			PendingEmail: arg.PendingEmail,
+			Username:     result.User.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.VerifyEmail, err = queries.CreateVerifyEmail(ctx, CreateVerifyEmailParams{
+			Username:       result.User.Username,
+			Email:          arg.PendingEmail.String,
+			SecretCodeHash: arg.VerifyEmailCodeHash,
+			ExpiredAt:      arg.VerifyEmailExpiredAt,
+		})
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrEmailChanged is returned when a verification code is used after the user changed their email
+var ErrEmailChanged = errors.New("email has changed since the verification code was sent")
+
+type VerifyEmailTxParams struct {
+	EmailID        int64  `json:"email_id"`
+	SecretCodeHash string `json:"secret_code_hash"`
+}
+
+type VerifyEmailTxResult struct {
+	User        User        `json:"user"`
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// VerifyEmailTx uses the verification code and marks the email of its user as verified. A code sent to their pending email
+// replaces their email with it. It returns sql.ErrNoRows if the code does not exist, was used before or has expired.
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
+	var result VerifyEmailTxResult
+
+	err := store.execTx(ctx, func(queries *Queries) error {
+		var err error
+
+		result.VerifyEmail, err = queries.UseVerifyEmail(ctx, UseVerifyEmailParams{
+			ID:             arg.EmailID,
+			SecretCodeHash: arg.SecretCodeHash,
+		})
+		if err != nil {
+			return err
+		}
+
+		user, err := queries.GetUser(ctx, result.VerifyEmail.Username)
+		if err != nil {
+			return err
+		}
+
+		switch result.VerifyEmail.Email {
+		case user.Email:
+			result.User, err = queries.UpdateUser(ctx, UpdateUserParams{
+				IsEmailVerified: sql.NullBool{Bool: true, Valid: true},
+				Username:        user.Username,
+			})
+		case user.PendingEmail.String:
+			// the pending email is matched again in case it was replaced by a concurrent update
+			result.User, err = queries.ConfirmUserPendingEmail(ctx, ConfirmUserPendingEmailParams{
+				Username:     user.Username,
+				PendingEmail: user.PendingEmail,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrEmailChanged
+			}
+		default:
+			return ErrEmailChanged
+		}
+		return err
+	})
+	return result, err
+}
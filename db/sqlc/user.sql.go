@@ -7,8 +7,42 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
+const confirmUserPendingEmail = `-- name: ConfirmUserPendingEmail :one
+UPDATE users
+SET email = pending_email,
+    pending_email = NULL,
+    is_email_verified = TRUE
+WHERE username = $1
+  AND pending_email = $2
+    RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, pending_email
+`
+
+type ConfirmUserPendingEmailParams struct {
+	Username     string         `json:"username"`
+	PendingEmail sql.NullString `json:"pending_email"`
+}
+
+func (q *Queries) ConfirmUserPendingEmail(ctx context.Context, arg ConfirmUserPendingEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, confirmUserPendingEmail, arg.Username, arg.PendingEmail)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     username,
@@ -18,7 +52,7 @@ INSERT INTO users (
 ) VALUES (
              $1, $2,$3,$4
          )
-    RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+    RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, pending_email
 `
 
 type CreateUserParams struct {
@@ -43,12 +77,27 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PendingEmail,
 	)
 	return i, err
 }
 
+const getPasswordChangedAt = `-- name: GetPasswordChangedAt :one
+SELECT password_changed_at FROM users
+WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) GetPasswordChangedAt(ctx context.Context, username string) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getPasswordChangedAt, username)
+	var password_changed_at time.Time
+	err := row.Scan(&password_changed_at)
+	return password_changed_at, err
+}
+
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, pending_email FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -62,6 +111,104 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, pending_email FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const setUserPendingEmail = `-- name: SetUserPendingEmail :one
+UPDATE users
+SET pending_email = $1
+WHERE username = $2
+    RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, pending_email
+`
+
+type SetUserPendingEmailParams struct {
+	PendingEmail sql.NullString `json:"pending_email"`
+	Username     string         `json:"username"`
+}
+
+func (q *Queries) SetUserPendingEmail(ctx context.Context, arg SetUserPendingEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserPendingEmail, arg.PendingEmail, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET hashed_password = COALESCE($1, hashed_password),
+    password_changed_at = COALESCE($2, password_changed_at),
+    full_name = COALESCE($3, full_name),
+    email = COALESCE($4, email),
+    is_email_verified = COALESCE($5, is_email_verified)
+WHERE username = $6
+    RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, pending_email
+`
+
+type UpdateUserParams struct {
+	HashedPassword    sql.NullString `json:"hashed_password"`
+	PasswordChangedAt sql.NullTime   `json:"password_changed_at"`
+	FullName          sql.NullString `json:"full_name"`
+	Email             sql.NullString `json:"email"`
+	IsEmailVerified   sql.NullBool   `json:"is_email_verified"`
+	Username          string         `json:"username"`
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUser,
+		arg.HashedPassword,
+		arg.PasswordChangedAt,
+		arg.FullName,
+		arg.Email,
+		arg.IsEmailVerified,
+		arg.Username,
+	)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.PendingEmail,
 	)
 	return i, err
 }
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"github.com/SaishNaik/simplebank/utils"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -50,3 +51,51 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, createdUser.CreatedAt, gotUser.CreatedAt, time.Second)
 	require.WithinDuration(t, createdUser.PasswordChangedAt, gotUser.PasswordChangedAt, time.Second)
 }
+
+func TestUpdateUserOnlyFullName(t *testing.T) {
+	oldUser := createRandomUser(t)
+
+	newFullName := utils.RandomOwner()
+	updatedUser, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		FullName: sql.NullString{String: newFullName, Valid: true},
+		Username: oldUser.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, newFullName, updatedUser.FullName)
+	require.Equal(t, oldUser.Email, updatedUser.Email)
+	require.Equal(t, oldUser.HashedPassword, updatedUser.HashedPassword)
+	require.False(t, updatedUser.IsEmailVerified)
+}
+
+func TestUpdateUserAllFields(t *testing.T) {
+	oldUser := createRandomUser(t)
+
+	newHashedPassword, err := utils.HashPassword(utils.RandomString(6))
+	require.NoError(t, err)
+	arg := UpdateUserParams{
+		HashedPassword:    sql.NullString{String: newHashedPassword, Valid: true},
+		PasswordChangedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		FullName:          sql.NullString{String: utils.RandomOwner(), Valid: true},
+		Email:             sql.NullString{String: utils.RandomEmail(), Valid: true},
+		IsEmailVerified:   sql.NullBool{Bool: true, Valid: true},
+		Username:          oldUser.Username,
+	}
+	updatedUser, err := testQueries.UpdateUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.HashedPassword.String, updatedUser.HashedPassword)
+	require.WithinDuration(t, arg.PasswordChangedAt.Time, updatedUser.PasswordChangedAt, time.Second)
+	require.Equal(t, arg.FullName.String, updatedUser.FullName)
+	require.Equal(t, arg.Email.String, updatedUser.Email)
+	require.True(t, updatedUser.IsEmailVerified)
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	createdUser := createRandomUser(t)
+
+	gotUser, err := testQueries.GetUserByEmail(context.Background(), createdUser.Email)
+	require.NoError(t, err)
+	require.Equal(t, createdUser.Username, gotUser.Username)
+
+	_, err = testQueries.GetUserByEmail(context.Background(), utils.RandomEmail())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
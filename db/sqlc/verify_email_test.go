@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomUserTx(t *testing.T, secretCode string, expiredAt time.Time) CreateUserTxResult {
+	hashedPassword, err := utils.HashPassword(utils.RandomString(6))
+	require.NoError(t, err)
+
+	store := NewStore(testDB)
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       utils.RandomOwner(),
+			HashedPassword: hashedPassword,
+			FullName:       utils.RandomOwner(),
+			Email:          utils.RandomEmail(),
+		},
+		VerifyEmailCodeHash:  utils.HashToken(secretCode),
+		VerifyEmailExpiredAt: expiredAt,
+	}
+	result, err := store.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, result.User.Username)
+	require.False(t, result.User.IsEmailVerified)
+	require.Equal(t, arg.Username, result.VerifyEmail.Username)
+	require.Equal(t, arg.Email, result.VerifyEmail.Email)
+	require.Equal(t, arg.VerifyEmailCodeHash, result.VerifyEmail.SecretCodeHash)
+	require.False(t, result.VerifyEmail.IsUsed)
+	require.WithinDuration(t, expiredAt, result.VerifyEmail.ExpiredAt, time.Second)
+	return result
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+	secretCode := utils.RandomString(32)
+	created := createRandomUserTx(t, secretCode, time.Now().Add(time.Hour))
+
+	arg := VerifyEmailTxParams{
+		EmailID:        created.VerifyEmail.ID,
+		SecretCodeHash: utils.HashToken("wrong"),
+	}
+	_, err := store.VerifyEmailTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	arg.SecretCodeHash = utils.HashToken(secretCode)
+	result, err := store.VerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.User.IsEmailVerified)
+	require.True(t, result.VerifyEmail.IsUsed)
+
+	// the code can only be used once
+	_, err = store.VerifyEmailTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestVerifyEmailTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	secretCode := utils.RandomString(32)
+	created := createRandomUserTx(t, secretCode, time.Now().Add(-time.Minute))
+
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:        created.VerifyEmail.ID,
+		SecretCodeHash: utils.HashToken(secretCode),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestVerifyEmailTxEmailChanged(t *testing.T) {
+	store := NewStore(testDB)
+	secretCode := utils.RandomString(32)
+	created := createRandomUserTx(t, secretCode, time.Now().Add(time.Hour))
+
+	_, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Email:    sql.NullString{String: utils.RandomEmail(), Valid: true},
+		Username: created.User.Username,
+	})
+	require.NoError(t, err)
+
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:        created.VerifyEmail.ID,
+		SecretCodeHash: utils.HashToken(secretCode),
+	})
+	require.ErrorIs(t, err, ErrEmailChanged)
+
+	user, err := testQueries.GetUser(context.Background(), created.User.Username)
+	require.NoError(t, err)
+	require.False(t, user.IsEmailVerified)
+}
+
+func TestUpdateUserTxEmail(t *testing.T) {
+	store := NewStore(testDB)
+	created := createRandomUserTx(t, utils.RandomString(32), time.Now().Add(time.Hour))
+
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:        created.VerifyEmail.ID,
+		SecretCodeHash: created.VerifyEmail.SecretCodeHash,
+	})
+	require.NoError(t, err)
+
+	secretCode := utils.RandomString(32)
+	arg := UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			Username: created.User.Username,
+		},
+		PendingEmail:         sql.NullString{String: utils.RandomEmail(), Valid: true},
+		VerifyEmailCodeHash:  utils.HashToken(secretCode),
+		VerifyEmailExpiredAt: time.Now().Add(time.Hour),
+	}
+	result, err := store.UpdateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	verifyEmail := result.VerifyEmail
+	// the current email stays verified and in use until the new one is verified
+	require.Equal(t, created.User.Email, result.User.Email)
+	require.True(t, result.User.IsEmailVerified)
+	require.Equal(t, arg.PendingEmail, result.User.PendingEmail)
+	require.Equal(t, arg.PendingEmail.String, verifyEmail.Email)
+
+	verified, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:        verifyEmail.ID,
+		SecretCodeHash: utils.HashToken(secretCode),
+	})
+	require.NoError(t, err)
+	require.Equal(t, arg.PendingEmail.String, verified.User.Email)
+	require.True(t, verified.User.IsEmailVerified)
+	require.False(t, verified.User.PendingEmail.Valid)
+}
+
+func TestUpdateUserTxReplacedPendingEmail(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	codes := make([]VerifyEmail, 2)
+	for i := range codes {
+		result, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+			UpdateUserParams:     UpdateUserParams{Username: user.Username},
+			PendingEmail:         sql.NullString{String: utils.RandomEmail(), Valid: true},
+			VerifyEmailCodeHash:  utils.HashToken(fmt.Sprint(i)),
+			VerifyEmailExpiredAt: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		codes[i] = result.VerifyEmail
+	}
+
+	// the code sent to the first pending email no longer changes the email
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:        codes[0].ID,
+		SecretCodeHash: utils.HashToken("0"),
+	})
+	require.ErrorIs(t, err, ErrEmailChanged)
+
+	got, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Email, got.Email)
+	require.Equal(t, codes[1].Email, got.PendingEmail.String)
+}
+
+func TestUpdateUserTxDuplicateEmail(t *testing.T) {
+	store := NewStore(testDB)
+	user1 := createRandomUser(t)
+	user2 := createRandomUser(t)
+
+	result, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserParams:     UpdateUserParams{Username: user1.Username},
+		PendingEmail:         sql.NullString{String: user2.Email, Valid: true},
+		VerifyEmailCodeHash:  utils.HashToken("code"),
+		VerifyEmailExpiredAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// the email is only taken over when it is verified, by then it must still be free
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:        result.VerifyEmail.ID,
+		SecretCodeHash: utils.HashToken("code"),
+	})
+	require.Error(t, err)
+	require.Equal(t, "unique_violation", err.(*pq.Error).Code.Name())
+}
@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"github.com/SaishNaik/simplebank/utils"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer writes every email to its own .eml file in a directory, for local development
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir string, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("cannot create mail directory: %w", err)
+	}
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+func (mailer *FileMailer) SendEmail(_ context.Context, email Email) error {
+	now := time.Now()
+	msg, err := buildMessage(mailer.from, email, now)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), utils.RandomString(6))
+	return os.WriteFile(filepath.Join(mailer.dir, name), msg, 0o600)
+}
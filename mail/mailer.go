@@ -0,0 +1,78 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SaishNaik/simplebank/utils"
+	"mime"
+	netmail "net/mail"
+	"strings"
+	"time"
+)
+
+const (
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverMemory = "memory"
+)
+
+var errHeaderInjection = errors.New("email header must not contain line breaks")
+
+// Email is a plain text message
+type Email struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails to users
+type Mailer interface {
+	SendEmail(ctx context.Context, email Email) error
+}
+
+// New creates the mailer for the configured driver
+func New(config utils.Config) (Mailer, error) {
+	switch config.MailDriver {
+	case DriverSMTP:
+		return NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailFrom)
+	case DriverFile:
+		return NewFileMailer(config.MailDir, config.MailFrom)
+	case "", DriverMemory:
+		return NewMemoryMailer(), nil
+	}
+	return nil, fmt.Errorf("unsupported mail driver %q", config.MailDriver)
+}
+
+// buildMessage formats the email as an RFC 5322 message
+func buildMessage(from string, email Email, now time.Time) ([]byte, error) {
+	if len(email.To) == 0 {
+		return nil, errors.New("email has no recipients")
+	}
+	for _, header := range append([]string{from, email.Subject}, email.To...) {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, errHeaderInjection
+		}
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(email.Body, "\n", "\r\n"))
+	return msg.Bytes(), nil
+}
+
+// addressOf returns the bare address of a header value such as "SimpleBank <no-reply@simplebank.com>"
+func addressOf(header string) (string, error) {
+	address, err := netmail.ParseAddress(header)
+	if err != nil {
+		return "", fmt.Errorf("invalid email address %q: %w", header, err)
+	}
+	return address.Address, nil
+}
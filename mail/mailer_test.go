@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"context"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func randomEmail() Email {
+	return Email{
+		To:      []string{utils.RandomEmail()},
+		Subject: "Welcome to SimpleBank",
+		Body:    "Hello " + utils.RandomOwner() + ",\nplease verify your email.",
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	email := randomEmail()
+	now := time.Now()
+
+	msg, err := buildMessage("SimpleBank <no-reply@simplebank.local>", email, now)
+	require.NoError(t, err)
+
+	header, body, ok := strings.Cut(string(msg), "\r\n\r\n")
+	require.True(t, ok)
+	require.Contains(t, header, "From: SimpleBank <no-reply@simplebank.local>\r\n")
+	require.Contains(t, header, "To: "+email.To[0]+"\r\n")
+	require.Contains(t, header, "Subject: Welcome to SimpleBank\r\n")
+	require.Contains(t, header, "Date: "+now.Format(time.RFC1123Z))
+	require.Equal(t, strings.ReplaceAll(email.Body, "\n", "\r\n"), body)
+}
+
+func TestBuildMessageHeaderInjection(t *testing.T) {
+	email := randomEmail()
+	email.Subject = "hello\r\nBcc: victim@email.com"
+	_, err := buildMessage("no-reply@simplebank.local", email, time.Now())
+	require.ErrorIs(t, err, errHeaderInjection)
+
+	email = randomEmail()
+	email.To = nil
+	_, err = buildMessage("no-reply@simplebank.local", email, time.Now())
+	require.Error(t, err)
+}
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+	email1 := randomEmail()
+	email2 := randomEmail()
+
+	require.NoError(t, mailer.SendEmail(context.Background(), email1))
+	require.NoError(t, mailer.SendEmail(context.Background(), email2))
+	require.Equal(t, []Email{email1, email2}, mailer.Sent())
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer, err := NewFileMailer(dir, "no-reply@simplebank.local")
+	require.NoError(t, err)
+
+	email := randomEmail()
+	require.NoError(t, mailer.SendEmail(context.Background(), email))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, ".eml", filepath.Ext(files[0].Name()))
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "To: "+email.To[0])
+}
+
+func TestNewSMTPMailer(t *testing.T) {
+	_, err := NewSMTPMailer("localhost", 1025, "", "", "SimpleBank <no-reply@simplebank.local>")
+	require.NoError(t, err)
+
+	_, err = NewSMTPMailer("localhost", 1025, "", "", "not an address")
+	require.Error(t, err)
+}
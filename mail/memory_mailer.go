@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryMailer keeps the emails it is asked to send, for tests
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []Email
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (mailer *MemoryMailer) SendEmail(_ context.Context, email Email) error {
+	if _, err := buildMessage("", email, time.Time{}); err != nil {
+		return err
+	}
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+	mailer.sent = append(mailer.sent, email)
+	return nil
+}
+
+// Sent returns the emails sent so far
+func (mailer *MemoryMailer) Sent() []Email {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+	return append([]Email(nil), mailer.sent...)
+}
@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPMailer delivers emails through an smtp server
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+	// sender is the bare address of from, used as the envelope sender
+	sender string
+}
+
+func NewSMTPMailer(host string, port int, username string, password string, from string) (*SMTPMailer, error) {
+	sender, err := addressOf(from)
+	if err != nil {
+		return nil, err
+	}
+	mailer := &SMTPMailer{
+		addr:   net.JoinHostPort(host, strconv.Itoa(port)),
+		from:   from,
+		sender: sender,
+	}
+	if username != "" {
+		mailer.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return mailer, nil
+}
+
+func (mailer *SMTPMailer) SendEmail(ctx context.Context, email Email) error {
+	msg, err := buildMessage(mailer.from, email, time.Now())
+	if err != nil {
+		return err
+	}
+	to := make([]string, len(email.To))
+	for i, recipient := range email.To {
+		to[i], err = addressOf(recipient)
+		if err != nil {
+			return err
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	return smtp.SendMail(mailer.addr, mailer.auth, mailer.sender, to, msg)
+}
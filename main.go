@@ -1,32 +1,86 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"github.com/SaishNaik/simplebank/api"
 	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/mail"
+	"github.com/SaishNaik/simplebank/scheduler"
 	"github.com/SaishNaik/simplebank/utils"
 	_ "github.com/lib/pq"
-	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// shutdownTimeout bounds how long the server waits for requests in flight and background work when stopping
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	config, err := utils.LoadConfig(".")
 	if err != nil {
-		log.Fatal("cannot load configurations", err)
+		fatal("cannot load configurations", err)
+	}
+
+	logger, err := utils.NewLogger(config, os.Stdout)
+	if err != nil {
+		fatal("cannot create logger", err)
 	}
+	slog.SetDefault(logger)
 
 	conn, err := sql.Open(config.DBDriver, config.DBSource)
 	if err != nil {
-		log.Fatal("cannot connect to db", err)
+		fatal("cannot connect to db", err)
 	}
 	store := db.NewStore(conn)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if config.ScheduledTransferInterval > 0 {
+		mailer, err := mail.New(config)
+		if err != nil {
+			fatal("cannot create mailer", err)
+		}
+		executor, err := scheduler.NewExecutor(config, store, mailer)
+		if err != nil {
+			fatal("cannot create executor", err)
+		}
+		go executor.Run(ctx)
+	}
+
 	server, err := api.NewServer(config, store)
 	if err != nil {
-		log.Fatal("cannot create server", err)
+		fatal("cannot create server", err)
 	}
 
-	err = server.Start(config.ServerAddress)
-	if err != nil {
-		log.Fatal("cannot start server:", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("starting server", "address", config.ServerAddress)
+		serverErr <- server.Start(config.ServerAddress)
+	}()
+
+	select {
+	case err = <-serverErr:
+		if err != nil {
+			fatal("cannot start server", err)
+		}
+		return
+	case <-ctx.Done():
 	}
+
+	slog.Info("shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		fatal("cannot shut down server", err)
+	}
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
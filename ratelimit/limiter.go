@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"math"
+	"time"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// Policy describes a token bucket that holds up to Burst tokens and refills at Rate tokens per second
+type Policy struct {
+	Name  string
+	Rate  float64
+	Burst int
+}
+
+// PerMinute returns a policy allowing n requests per minute, all of which may be used in a burst
+func PerMinute(name string, n int) Policy {
+	return Policy{
+		Name:  name,
+		Rate:  float64(n) / 60,
+		Burst: n,
+	}
+}
+
+// Enabled reports whether the policy limits anything at all
+func (p Policy) Enabled() bool {
+	return p.Rate > 0 && p.Burst > 0
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter is a token bucket rate limiter
+type Limiter interface {
+	// Allow takes one token from the bucket identified by policy and key
+	Allow(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// New creates the limiter for the configured backend
+func New(backend string, store db.Store) (Limiter, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewMemoryLimiter(), nil
+	case BackendPostgres:
+		return NewPostgresLimiter(store), nil
+	}
+	return nil, fmt.Errorf("unsupported rate limit backend %q", backend)
+}
+
+func bucketKey(policy Policy, key string) string {
+	return policy.Name + ":" + key
+}
+
+// newResult builds the result from the tokens left in the bucket after the request
+func newResult(policy Policy, tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+	}
+	if !allowed {
+		seconds := (1 - tokens) / policy.Rate
+		result.RetryAfter = time.Duration(math.Ceil(seconds * float64(time.Second)))
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// maxIdleBuckets is the number of buckets kept before full buckets are swept from memory
+	maxIdleBuckets = 10000
+	// sweepInterval is the least time between two sweeps, so that a map of many active buckets
+	// is not scanned on every request while holding the lock
+	sweepInterval = time.Minute
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// MemoryLimiter is a Limiter keeping its buckets in process memory.
+// It is only suitable for a single server instance.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+	// lastSweep is when the buckets were last swept
+	lastSweep time.Time
+}
+
+// NewMemoryLimiter creates a new MemoryLimiter
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes one token from the bucket identified by policy and key
+func (l *MemoryLimiter) Allow(_ context.Context, key string, policy Policy) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if len(l.buckets) >= maxIdleBuckets && now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now, policy)
+		l.lastSweep = now
+	}
+
+	k := bucketKey(policy, key)
+	b, ok := l.buckets[k]
+	if !ok {
+		b = &bucket{tokens: float64(policy.Burst), updatedAt: now}
+		l.buckets[k] = b
+	}
+
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	b.tokens = math.Min(float64(policy.Burst), b.tokens+elapsed*policy.Rate)
+	b.updatedAt = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(policy, b.tokens, allowed), nil
+}
+
+// sweep drops buckets that have been idle long enough to be full again
+func (l *MemoryLimiter) sweep(now time.Time, policy Policy) {
+	refillTime := time.Duration(float64(policy.Burst) / policy.Rate * float64(time.Second))
+	for k, b := range l.buckets {
+		if now.Sub(b.updatedAt) > refillTime {
+			delete(l.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	policy := PerMinute("test", 3)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow(ctx, "user", policy)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, 2-i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, "user", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 20*time.Second, result.RetryAfter)
+
+	// other keys have their own bucket
+	result, err = limiter.Allow(ctx, "other", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	now = now.Add(20 * time.Second)
+	result, err = limiter.Allow(ctx, "user", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+}
+
+func TestMemoryLimiterRefillIsCapped(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	policy := PerMinute("test", 2)
+	ctx := context.Background()
+
+	_, err := limiter.Allow(ctx, "user", policy)
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	result, err := limiter.Allow(ctx, "user", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 1, result.Remaining)
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	policy := Policy{Name: "test", Rate: 1, Burst: 1}
+	ctx := context.Background()
+	fill := func() {
+		for i := len(limiter.buckets); i < maxIdleBuckets; i++ {
+			_, err := limiter.Allow(ctx, fmt.Sprint(now.UnixNano(), i), policy)
+			require.NoError(t, err)
+		}
+	}
+
+	fill()
+	now = now.Add(2 * time.Second)
+	_, err := limiter.Allow(ctx, "user", policy)
+	require.NoError(t, err)
+	require.Len(t, limiter.buckets, 1)
+
+	// the buckets are swept at most once per interval, even when they are full again
+	fill()
+	now = now.Add(sweepInterval / 2)
+	_, err = limiter.Allow(ctx, "user", policy)
+	require.NoError(t, err)
+	require.Len(t, limiter.buckets, maxIdleBuckets)
+
+	now = now.Add(sweepInterval / 2)
+	_, err = limiter.Allow(ctx, "user", policy)
+	require.NoError(t, err)
+	require.Len(t, limiter.buckets, 1)
+}
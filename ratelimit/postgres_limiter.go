@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// pruneEvery is the number of requests between two clean ups of stale buckets
+	pruneEvery = 1000
+	// staleBucketAge is how long a bucket must be unused before it is removed
+	staleBucketAge = 24 * time.Hour
+)
+
+// PostgresLimiter is a Limiter keeping its buckets in Postgres,
+// so that the limits are shared by every server instance.
+type PostgresLimiter struct {
+	store    db.Store
+	requests atomic.Uint64
+}
+
+// NewPostgresLimiter creates a new PostgresLimiter
+func NewPostgresLimiter(store db.Store) *PostgresLimiter {
+	return &PostgresLimiter{store: store}
+}
+
+// Allow takes one token from the bucket identified by policy and key
+func (l *PostgresLimiter) Allow(ctx context.Context, key string, policy Policy) (Result, error) {
+	if l.requests.Add(1)%pruneEvery == 0 {
+		l.prune(ctx)
+	}
+
+	row, err := l.store.TakeRateLimitToken(ctx, db.TakeRateLimitTokenParams{
+		Key:   bucketKey(policy, key),
+		Burst: float64(policy.Burst),
+		Rate:  policy.Rate,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(policy, row.Tokens, row.Allowed), nil
+}
+
+func (l *PostgresLimiter) prune(ctx context.Context) {
+	err := l.store.DeleteStaleRateLimitBuckets(ctx, time.Now().Add(-staleBucketAge))
+	if err != nil {
+		slog.WarnContext(ctx, "cannot delete stale rate limit buckets", "error", err)
+	}
+}
@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/mail"
+	"github.com/SaishNaik/simplebank/utils"
+	"log/slog"
+	"time"
+)
+
+// Executor periodically executes the scheduled transfers and standing orders that are due and releases expired holds.
+// Each transfer is claimed with a row lock skipped by other executors, so every server instance can run one.
+type Executor struct {
+	store    db.Store
+	mailer   mail.Mailer
+	interval time.Duration
+	// standing order occurrences refused for insufficient funds are retried maxRetries times, every retryInterval
+	maxRetries    int
+	retryInterval time.Duration
+	// batches pending for longer than batchResumeAfter were interrupted and are resumed
+	batchResumeAfter time.Duration
+	// scheduled payments into the account of a payee cooling off are refused from these amounts per currency
+	payeeCoolingOffThresholds map[string]int64
+}
+
+// NewExecutor creates a new Executor polling for due transfers every config.ScheduledTransferInterval
+func NewExecutor(config utils.Config, store db.Store, mailer mail.Mailer) (*Executor, error) {
+	payeeCoolingOffThresholds, err := utils.ParseCurrencyAmounts(config.PayeeCoolingOffThresholds)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payee cooling-off thresholds: %w", err)
+	}
+	return &Executor{
+		store:         store,
+		mailer:        mailer,
+		interval:      config.ScheduledTransferInterval,
+		maxRetries:    config.StandingOrderMaxRetries,
+		retryInterval: config.StandingOrderRetryInterval,
+
+		batchResumeAfter:          config.TransferBatchResumeAfter,
+		payeeCoolingOffThresholds: payeeCoolingOffThresholds,
+	}, nil
+}
+
+// Run executes due transfers until ctx is done
+func (e *Executor) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := e.RunOnce(ctx); err != nil {
+			slog.ErrorContext(ctx, "cannot execute scheduled transfers", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce executes every transfer due by now, releases holds and expires pending transfers and payment requests
+// expired by now, resumes interrupted transfer batches and returns how many were processed.
+// A phase that fails does not stop the others, their errors are returned together.
+func (e *Executor) RunOnce(ctx context.Context) (int, error) {
+	now := time.Now()
+	phases := []func(ctx context.Context, now time.Time) (int, error){
+		e.runScheduledTransfers,
+		e.runStandingOrders,
+		e.expireHolds,
+		e.expirePendingTransfers,
+		e.expirePaymentRequests,
+		e.resumeTransferBatches,
+	}
+
+	processed := 0
+	var errs []error
+	for _, phase := range phases {
+		n, err := phase(ctx, now)
+		processed += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return processed, errors.Join(errs...)
+}
+
+func (e *Executor) runScheduledTransfers(ctx context.Context, now time.Time) (int, error) {
+	executed := 0
+	for ctx.Err() == nil {
+		result, err := e.store.ExecuteScheduledTransferTx(ctx, db.ExecuteScheduledTransferTxParams{
+			Now:                       now,
+			PayeeCoolingOffThresholds: e.payeeCoolingOffThresholds,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return executed, nil
+			}
+			return executed, err
+		}
+		executed++
+
+		if result.Attempt.Succeeded {
+			slog.InfoContext(ctx, "scheduled transfer executed",
+				"scheduled_transfer_id", result.ScheduledTransfer.ID,
+				"transfer_id", result.ScheduledTransfer.TransferID.Int64,
+			)
+		} else {
+			slog.WarnContext(ctx, "scheduled transfer failed",
+				"scheduled_transfer_id", result.ScheduledTransfer.ID,
+				"reason", result.Attempt.FailureReason,
+			)
+		}
+	}
+	return executed, ctx.Err()
+}
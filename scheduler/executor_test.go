@@ -0,0 +1,381 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	mockdb "github.com/SaishNaik/simplebank/db/mock"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/mail"
+	"github.com/SaishNaik/simplebank/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newTestExecutor(t *testing.T, store db.Store, mailer mail.Mailer) *Executor {
+	config := utils.Config{
+		ScheduledTransferInterval:  time.Millisecond,
+		StandingOrderMaxRetries:    3,
+		StandingOrderRetryInterval: time.Hour,
+		TransferBatchResumeAfter:   15 * time.Minute,
+		PayeeCoolingOffThresholds:  "USD:1000",
+	}
+	executor, err := NewExecutor(config, store, mailer)
+	require.NoError(t, err)
+	return executor
+}
+
+func TestNewExecutorInvalidPayeeCoolingOffThresholds(t *testing.T) {
+	config := utils.Config{PayeeCoolingOffThresholds: "USD"}
+	_, err := NewExecutor(config, mockdb.NewMockStore(gomock.NewController(t)), mail.NewMemoryMailer())
+	require.Error(t, err)
+}
+
+func TestExecutorRunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	succeeded := db.ExecuteScheduledTransferTxResult{
+		ScheduledTransfer: db.ScheduledTransfer{ID: 1},
+		Attempt:           db.ScheduledTransferAttempt{Succeeded: true},
+	}
+	failed := db.ExecuteScheduledTransferTxResult{
+		ScheduledTransfer: db.ScheduledTransfer{ID: 2},
+		Attempt:           db.ScheduledTransferAttempt{FailureReason: db.ErrInsufficientFunds.Error()},
+	}
+	gomock.InOrder(
+		store.EXPECT().ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, arg db.ExecuteScheduledTransferTxParams) (db.ExecuteScheduledTransferTxResult, error) {
+				require.Equal(t, map[string]int64{utils.USD: 1000}, arg.PayeeCoolingOffThresholds)
+				return succeeded, nil
+			}),
+		store.EXPECT().ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).Return(failed, nil),
+		store.EXPECT().ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrNoRows),
+	)
+	store.EXPECT().
+		ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExecuteStandingOrderTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpireHoldTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExpireHoldTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpirePendingTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Transfer{}, nil)
+	store.EXPECT().
+		ExpirePaymentRequestsTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExpirePaymentRequestsTxResult{}, nil)
+	store.EXPECT().
+		ResumeTransferBatchTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ResumeTransferBatchTxResult{}, sql.ErrNoRows)
+
+	executed, err := newTestExecutor(t, store, mail.NewMemoryMailer()).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, executed)
+}
+
+func TestExecutorRunOnceError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	errExpire := errors.New("cannot expire payment requests")
+	store.EXPECT().
+		ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrConnDone)
+	// the phases after a failing one still run
+	gomock.InOrder(
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).
+			Return(db.ExecuteStandingOrderTxResult{Execution: db.StandingOrderExecution{Succeeded: true}}, nil),
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).
+			Return(db.ExecuteStandingOrderTxResult{}, sql.ErrNoRows),
+	)
+	store.EXPECT().
+		ExpireHoldTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExpireHoldTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpirePendingTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Transfer{}, nil)
+	store.EXPECT().
+		ExpirePaymentRequestsTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExpirePaymentRequestsTxResult{}, errExpire)
+	store.EXPECT().
+		ResumeTransferBatchTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ResumeTransferBatchTxResult{}, sql.ErrNoRows)
+
+	executed, err := newTestExecutor(t, store, mail.NewMemoryMailer()).RunOnce(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.ErrorIs(t, err, errExpire)
+	require.Equal(t, 1, executed)
+}
+
+func TestExecutorStandingOrders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	mailer := mail.NewMemoryMailer()
+
+	user := db.User{
+		Username: utils.RandomOwner(),
+		FullName: utils.RandomOwner(),
+		Email:    utils.RandomEmail(),
+	}
+	order := db.StandingOrder{ID: 7, Owner: user.Username, Amount: 100, Currency: utils.USD}
+
+	paid := db.ExecuteStandingOrderTxResult{
+		StandingOrder: order,
+		Execution:     db.StandingOrderExecution{Succeeded: true},
+	}
+	retrying := db.ExecuteStandingOrderTxResult{
+		StandingOrder: order,
+		Execution:     db.StandingOrderExecution{FailureReason: db.ErrInsufficientFunds.Error()},
+		WillRetry:     true,
+	}
+	failed := db.ExecuteStandingOrderTxResult{
+		StandingOrder: order,
+		Execution:     db.StandingOrderExecution{FailureReason: db.ErrInsufficientFunds.Error()},
+	}
+
+	store.EXPECT().
+		ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrNoRows)
+	gomock.InOrder(
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, arg db.ExecuteStandingOrderTxParams) (db.ExecuteStandingOrderTxResult, error) {
+				require.Equal(t, 3, arg.MaxRetries)
+				require.Equal(t, time.Hour, arg.RetryInterval)
+				require.Equal(t, map[string]int64{utils.USD: 1000}, arg.PayeeCoolingOffThresholds)
+				return paid, nil
+			}),
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).Return(retrying, nil),
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).Return(failed, nil),
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).Return(db.ExecuteStandingOrderTxResult{}, sql.ErrNoRows),
+	)
+	// only the occurrence that will not be retried is reported to the owner
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	store.EXPECT().
+		ExpireHoldTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExpireHoldTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpirePendingTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Transfer{}, nil)
+	store.EXPECT().
+		ExpirePaymentRequestsTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExpirePaymentRequestsTxResult{}, nil)
+	store.EXPECT().
+		ResumeTransferBatchTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ResumeTransferBatchTxResult{}, sql.ErrNoRows)
+
+	executed, err := newTestExecutor(t, store, mailer).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, executed)
+
+	sent := mailer.Sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, []string{user.Email}, sent[0].To)
+	require.Contains(t, sent[0].Body, db.ErrInsufficientFunds.Error())
+}
+
+func TestExecutorExpireHolds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).
+		Return(db.ExecuteStandingOrderTxResult{}, sql.ErrNoRows)
+	gomock.InOrder(
+		store.EXPECT().ExpireHoldTx(gomock.Any(), gomock.Any()).Return(db.ExpireHoldTxResult{Hold: db.Hold{ID: 1}}, nil),
+		store.EXPECT().ExpireHoldTx(gomock.Any(), gomock.Any()).Return(db.ExpireHoldTxResult{Hold: db.Hold{ID: 2}}, nil),
+		store.EXPECT().ExpireHoldTx(gomock.Any(), gomock.Any()).Return(db.ExpireHoldTxResult{}, sql.ErrNoRows),
+	)
+	store.EXPECT().
+		ExpirePendingTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Transfer{}, nil)
+	store.EXPECT().
+		ExpirePaymentRequestsTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExpirePaymentRequestsTxResult{}, nil)
+	store.EXPECT().
+		ResumeTransferBatchTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ResumeTransferBatchTxResult{}, sql.ErrNoRows)
+
+	expired, err := newTestExecutor(t, store, mail.NewMemoryMailer()).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, expired)
+}
+
+func TestExecutorExpirePendingTransfers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).
+		Return(db.ExecuteStandingOrderTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpireHoldTx(gomock.Any(), gomock.Any()).
+		Return(db.ExpireHoldTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpirePendingTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Transfer{{ID: 1}, {ID: 2}}, nil)
+	store.EXPECT().
+		ExpirePaymentRequestsTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExpirePaymentRequestsTxResult{}, nil)
+	store.EXPECT().
+		ResumeTransferBatchTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ResumeTransferBatchTxResult{}, sql.ErrNoRows)
+
+	expired, err := newTestExecutor(t, store, mail.NewMemoryMailer()).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, expired)
+}
+
+func TestExecutorExpirePaymentRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).
+		Return(db.ExecuteStandingOrderTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpireHoldTx(gomock.Any(), gomock.Any()).
+		Return(db.ExpireHoldTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpirePendingTransfers(gomock.Any(), gomock.Any()).
+		Return([]db.Transfer{}, nil)
+	store.EXPECT().
+		ExpirePaymentRequestsTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ExpirePaymentRequestsTxResult{PaymentRequests: []db.PaymentRequest{{ID: 1}}}, nil)
+	store.EXPECT().
+		ResumeTransferBatchTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ResumeTransferBatchTxResult{}, sql.ErrNoRows)
+
+	expired, err := newTestExecutor(t, store, mail.NewMemoryMailer()).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, expired)
+}
+
+func TestExecutorResumeTransferBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).
+		Return(db.ExecuteStandingOrderTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpireHoldTx(gomock.Any(), gomock.Any()).
+		Return(db.ExpireHoldTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpirePendingTransfers(gomock.Any(), gomock.Any()).
+		Return([]db.Transfer{}, nil)
+	store.EXPECT().
+		ExpirePaymentRequestsTx(gomock.Any(), gomock.Any()).
+		Return(db.ExpirePaymentRequestsTxResult{}, nil)
+
+	resumed := db.ResumeTransferBatchTxResult{
+		Batch: db.TransferBatch{ID: 1, Status: utils.TransferBatchStatusSucceeded},
+	}
+	gomock.InOrder(
+		store.EXPECT().
+			ResumeTransferBatchTx(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, arg db.ResumeTransferBatchTxParams) (db.ResumeTransferBatchTxResult, error) {
+				// batches younger than the resume delay may still be executing
+				require.WithinDuration(t, time.Now().Add(-15*time.Minute), arg.Before, time.Second)
+				return resumed, nil
+			}),
+		store.EXPECT().
+			ResumeTransferBatchTx(gomock.Any(), gomock.Any()).
+			Return(db.ResumeTransferBatchTxResult{}, sql.ErrNoRows),
+	)
+
+	processed, err := newTestExecutor(t, store, mail.NewMemoryMailer()).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, processed)
+}
+
+func TestExecutorRunStops(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(db.ExecuteStandingOrderTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpireHoldTx(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(db.ExpireHoldTxResult{}, sql.ErrNoRows)
+	store.EXPECT().
+		ExpirePendingTransfers(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return([]db.Transfer{}, nil)
+	store.EXPECT().
+		ExpirePaymentRequestsTx(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(db.ExpirePaymentRequestsTxResult{}, nil)
+	store.EXPECT().
+		ResumeTransferBatchTx(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(db.ResumeTransferBatchTxResult{}, sql.ErrNoRows)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	executor := newTestExecutor(t, store, mail.NewMemoryMailer())
+	done := make(chan struct{})
+	go func() {
+		executor.Run(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("executor did not stop")
+	}
+}
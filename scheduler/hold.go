@@ -0,0 +1,31 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"log/slog"
+	"time"
+)
+
+func (e *Executor) expireHolds(ctx context.Context, now time.Time) (int, error) {
+	expired := 0
+	for ctx.Err() == nil {
+		result, err := e.store.ExpireHoldTx(ctx, db.ExpireHoldTxParams{Now: now})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return expired, nil
+			}
+			return expired, err
+		}
+		expired++
+
+		slog.InfoContext(ctx, "hold expired",
+			"hold_id", result.Hold.ID,
+			"from_account_id", result.Hold.FromAccountID,
+			"amount", result.Hold.Amount,
+		)
+	}
+	return expired, ctx.Err()
+}
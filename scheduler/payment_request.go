@@ -0,0 +1,24 @@
+package scheduler
+
+import (
+	"context"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"log/slog"
+	"time"
+)
+
+// expirePaymentRequests expires the payment requests that were not accepted by now
+func (e *Executor) expirePaymentRequests(ctx context.Context, now time.Time) (int, error) {
+	result, err := e.store.ExpirePaymentRequestsTx(ctx, db.ExpirePaymentRequestsTxParams{Now: now})
+	if err != nil {
+		return 0, err
+	}
+	for _, request := range result.PaymentRequests {
+		slog.InfoContext(ctx, "payment request expired",
+			"payment_request_id", request.ID,
+			"requester", request.Requester,
+			"payer", request.Payer,
+		)
+	}
+	return len(result.PaymentRequests), nil
+}
@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"github.com/SaishNaik/simplebank/mail"
+	"log/slog"
+	"time"
+)
+
+func (e *Executor) runStandingOrders(ctx context.Context, now time.Time) (int, error) {
+	executed := 0
+	for ctx.Err() == nil {
+		result, err := e.store.ExecuteStandingOrderTx(ctx, db.ExecuteStandingOrderTxParams{
+			Now:           now,
+			MaxRetries:    e.maxRetries,
+			RetryInterval: e.retryInterval,
+
+			PayeeCoolingOffThresholds: e.payeeCoolingOffThresholds,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return executed, nil
+			}
+			return executed, err
+		}
+		executed++
+
+		switch {
+		case result.Execution.Succeeded:
+			slog.InfoContext(ctx, "standing order executed",
+				"standing_order_id", result.StandingOrder.ID,
+				"transfer_id", result.Execution.TransferID.Int64,
+			)
+		case result.WillRetry:
+			slog.WarnContext(ctx, "standing order failed, will retry",
+				"standing_order_id", result.StandingOrder.ID,
+				"retries", result.StandingOrder.Retries,
+				"next_run_at", result.StandingOrder.NextRunAt,
+				"reason", result.Execution.FailureReason,
+			)
+		default:
+			slog.WarnContext(ctx, "standing order failed",
+				"standing_order_id", result.StandingOrder.ID,
+				"reason", result.Execution.FailureReason,
+			)
+			e.notifyFailure(ctx, result.StandingOrder, result.Execution)
+		}
+	}
+	return executed, ctx.Err()
+}
+
+// notifyFailure emails the owner of a standing order that an occurrence could not be paid,
+// errors are only logged as the failure is also listed with the executions of the order
+func (e *Executor) notifyFailure(ctx context.Context, order db.StandingOrder, execution db.StandingOrderExecution) {
+	user, err := e.store.GetUser(ctx, order.Owner)
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot get owner of standing order", "standing_order_id", order.ID, "error", err)
+		return
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\n"+
+		"Your standing order #%d of %d %s from account %d to account %d "+
+		"could not be paid for %s:\n\n"+
+		"%s\n",
+		user.FullName, order.ID, order.Amount, order.Currency, order.FromAccountID, order.ToAccountID,
+		execution.OccurrenceAt.UTC().Format("2006-01-02 15:04 MST"), execution.FailureReason)
+
+	err = e.mailer.SendEmail(ctx, mail.Email{
+		To:      []string{user.Email},
+		Subject: "Your SimpleBank standing order could not be paid",
+		Body:    body,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot send standing order failure email", "standing_order_id", order.ID, "error", err)
+	}
+}
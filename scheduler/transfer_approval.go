@@ -0,0 +1,23 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// expirePendingTransfers expires the transfers whose approvals were not collected by now
+func (e *Executor) expirePendingTransfers(ctx context.Context, now time.Time) (int, error) {
+	transfers, err := e.store.ExpirePendingTransfers(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	for _, transfer := range transfers {
+		slog.InfoContext(ctx, "pending transfer expired",
+			"transfer_id", transfer.ID,
+			"from_account_id", transfer.FromAccountID,
+			"amount", transfer.Amount,
+		)
+	}
+	return len(transfers), nil
+}
@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "github.com/SaishNaik/simplebank/db/sqlc"
+	"log/slog"
+	"time"
+)
+
+// resumeTransferBatches finishes the batches left pending by an interrupted execution, e.g. a server restart
+func (e *Executor) resumeTransferBatches(ctx context.Context, now time.Time) (int, error) {
+	resumed := 0
+	for ctx.Err() == nil {
+		result, err := e.store.ResumeTransferBatchTx(ctx, db.ResumeTransferBatchTxParams{
+			Before: now.Add(-e.batchResumeAfter),
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return resumed, nil
+			}
+			return resumed, err
+		}
+		resumed++
+
+		slog.InfoContext(ctx, "transfer batch resumed",
+			"batch_id", result.Batch.ID,
+			"items", len(result.Items),
+			"status", result.Batch.Status,
+		)
+	}
+	return resumed, ctx.Err()
+}
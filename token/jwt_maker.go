@@ -40,6 +40,11 @@ func (J *JWTMaker) CreateToken(username string, duration time.Duration) (string,
 	if err != nil {
 		return "", err
 	}
+	return J.CreateTokenFromPayload(payload)
+}
+
+// CreateTokenFromPayload creates a new token carrying the given payload
+func (J *JWTMaker) CreateTokenFromPayload(payload *Payload) (string, error) {
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, NewJWTPayloadClaims(payload))
 	return jwtToken.SignedString([]byte(J.secretKey))
 }
@@ -6,6 +6,9 @@ type Maker interface {
 	// CreateToken creates a new token for a specific username and duration
 	CreateToken(username string, duration time.Duration) (string, error)
 
+	// CreateTokenFromPayload creates a new token carrying the given payload
+	CreateTokenFromPayload(payload *Payload) (string, error)
+
 	//VerifyToken checks if token is valid or not
 	VerifyToken(token string) (*Payload, error)
 }
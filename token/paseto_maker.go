@@ -19,6 +19,11 @@ func (p PasetoMaker) CreateToken(username string, duration time.Duration) (strin
 	if err != nil {
 		return "", err
 	}
+	return p.CreateTokenFromPayload(payload)
+}
+
+// CreateTokenFromPayload creates a new token carrying the given payload
+func (p PasetoMaker) CreateTokenFromPayload(payload *Payload) (string, error) {
 	return p.paseto.Encrypt(p.symmetricKey, payload, nil)
 }
 
@@ -27,10 +27,34 @@ func TestPasetoMaker(t *testing.T) {
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, TokenTypeAccess, payload.TokenType)
+	require.WithinDuration(t, issuedAt, payload.AuthTime, time.Second)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
 
+func TestPasetoMakerFromPayload(t *testing.T) {
+	maker, err := NewPasetoMaker(utils.RandomString(32))
+	require.NoError(t, err)
+
+	payload, err := NewPayload(utils.RandomOwner(), time.Minute)
+	require.NoError(t, err)
+	payload.TokenType = TokenTypeMFAChallenge
+	payload.AMR = []string{AMRPassword}
+
+	token, err := maker.CreateTokenFromPayload(payload)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	gotPayload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, gotPayload.ID)
+	require.Equal(t, payload.Username, gotPayload.Username)
+	require.Equal(t, TokenTypeMFAChallenge, gotPayload.TokenType)
+	require.Equal(t, payload.AMR, gotPayload.AMR)
+	require.WithinDuration(t, payload.AuthTime, gotPayload.AuthTime, time.Second)
+}
+
 func TestExpiredPASETOToken(t *testing.T) {
 	maker, err := NewPasetoMaker(utils.RandomString(32))
 	require.NoError(t, err)
@@ -11,25 +11,50 @@ var (
 	ErrExpiredToken = errors.New("token is expired")
 )
 
+// TokenType tells what a token may be used for
+type TokenType string
+
+const (
+	// TokenTypeAccess tokens authorize api requests
+	TokenTypeAccess TokenType = "access"
+	// TokenTypeMFAChallenge tokens can only be exchanged for an access token with a second factor
+	TokenTypeMFAChallenge TokenType = "mfa_challenge"
+)
+
+// Authentication method references from RFC 8176
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+	AMRMFA      = "mfa"
+)
+
 // Payload contains payload data of token
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
 	Username  string    `json:"username"`
+	TokenType TokenType `json:"token_type"`
+	// AuthTime is when the user last proved who they are, used to require a recent login for sensitive operations
+	AuthTime time.Time `json:"auth_time"`
+	// AMR lists the authentication methods used at AuthTime, as described in RFC 8176
+	AMR       []string  `json:"amr,omitempty"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
-// NewPayload creates a new token payload with a specific username and duration
+// NewPayload creates a new access token payload with a specific username and duration
 func NewPayload(username string, duration time.Duration) (*Payload, error) {
 	tokenId, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
 	}
+	now := time.Now()
 	payload := &Payload{
 		ID:        tokenId,
 		Username:  username,
-		IssuedAt:  time.Now(),
-		ExpiredAt: time.Now().Add(duration),
+		TokenType: TokenTypeAccess,
+		AuthTime:  now,
+		IssuedAt:  now,
+		ExpiredAt: now.Add(duration),
 	}
 	return payload, nil
 }
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+	"strings"
+)
+
+// Account numbers follow the IBAN layout: a country code, two check digits and a 12 digit basic account number,
+// e.g. SB23000012345678
+const (
+	accountNumberCountry = "SB"
+	accountNumberDigits  = 12
+	accountNumberLength  = len(accountNumberCountry) + 2 + accountNumberDigits
+)
+
+// NewAccountNumber returns a random account number, so account numbers do not reveal how many accounts exist
+func NewAccountNumber() string {
+	bban := fmt.Sprintf("%0*d", accountNumberDigits, rand.Int64N(1_000_000_000_000))
+	return accountNumberCountry + checkDigits(accountNumberCountry, bban) + bban
+}
+
+// IsValidAccountNumber reports whether number is an account number with valid mod-97 check digits
+func IsValidAccountNumber(number string) bool {
+	if len(number) != accountNumberLength || !strings.HasPrefix(number, accountNumberCountry) {
+		return false
+	}
+	for _, c := range number[len(accountNumberCountry):] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	check := number[len(accountNumberCountry) : len(accountNumberCountry)+2]
+	bban := number[len(accountNumberCountry)+2:]
+	return check == checkDigits(accountNumberCountry, bban)
+}
+
+// checkDigits computes the ISO 7064 mod 97-10 check digits used by IBANs
+func checkDigits(country, bban string) string {
+	var digits strings.Builder
+	digits.WriteString(bban)
+	for _, c := range country {
+		digits.WriteString(fmt.Sprint(c - 'A' + 10))
+	}
+	digits.WriteString("00")
+
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	remainder := new(big.Int).Mod(n, big.NewInt(97)).Int64()
+	return fmt.Sprintf("%02d", 98-remainder)
+}
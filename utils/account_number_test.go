@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAccountNumber(t *testing.T) {
+	require.True(t, IsValidAccountNumber("SB23000012345678"))
+
+	for i := 0; i < 100; i++ {
+		number := NewAccountNumber()
+		require.Len(t, number, accountNumberLength)
+		require.True(t, IsValidAccountNumber(number), number)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"SB24000012345678",  // wrong check digits
+		"SB23000012345687",  // transposed digits
+		"DE23000012345678",  // wrong country
+		"SB2300001234567",   // too short
+		"SB23000012345678X", // too long
+		"SB2300001234567X",  // not a digit
+		"12345",
+	} {
+		require.False(t, IsValidAccountNumber(invalid), invalid)
+	}
+}
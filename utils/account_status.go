@@ -0,0 +1,30 @@
+package utils
+
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+func IsSupportedAccountStatus(status string) bool {
+	switch status {
+	case AccountStatusActive, AccountStatusFrozen, AccountStatusClosed:
+		return true
+	}
+	return false
+}
+
+// CanTransitionAccountStatus reports whether an account can move from one status to another.
+// Active accounts can be frozen or closed, frozen accounts unfrozen or closed and closed accounts reopened.
+// Callers decide who may make each move, reopening and unfreezing are left to bankers.
+func CanTransitionAccountStatus(from, to string) bool {
+	switch from {
+	case AccountStatusActive:
+		return to == AccountStatusFrozen || to == AccountStatusClosed
+	case AccountStatusFrozen:
+		return to == AccountStatusActive || to == AccountStatusClosed
+	case AccountStatusClosed:
+		return to == AccountStatusActive
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCanTransitionAccountStatus(t *testing.T) {
+	allowed := map[[2]string]bool{
+		{AccountStatusActive, AccountStatusFrozen}: true,
+		{AccountStatusActive, AccountStatusClosed}: true,
+		{AccountStatusFrozen, AccountStatusActive}: true,
+		{AccountStatusFrozen, AccountStatusClosed}: true,
+		{AccountStatusClosed, AccountStatusActive}: true,
+	}
+	statuses := []string{AccountStatusActive, AccountStatusFrozen, AccountStatusClosed, "unknown"}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			require.Equal(t, allowed[[2]string{from, to}], CanTransitionAccountStatus(from, to), "%s -> %s", from, to)
+		}
+	}
+
+	require.True(t, IsSupportedAccountStatus(AccountStatusFrozen))
+	require.False(t, IsSupportedAccountStatus("unknown"))
+}
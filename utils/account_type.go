@@ -0,0 +1,14 @@
+package utils
+
+const (
+	AccountTypePersonal = "personal"
+	AccountTypeBusiness = "business"
+)
+
+func IsSupportedAccountType(accountType string) bool {
+	switch accountType {
+	case AccountTypePersonal, AccountTypeBusiness:
+		return true
+	}
+	return false
+}
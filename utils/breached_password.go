@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// breachedPrefixLength is the number of hex characters of the sha1 hash used to name a range file
+const breachedPrefixLength = 5
+
+// BreachedPasswords looks passwords up in a local copy of a k-anonymity breached password list.
+// The directory holds one file per 5 character prefix of the uppercase hex sha1 hash, such as 5BAA6,
+// with a SUFFIX:COUNT line for each breached password, the format of the Pwned Passwords range api.
+type BreachedPasswords struct {
+	dir string
+}
+
+func NewBreachedPasswords(dir string) *BreachedPasswords {
+	return &BreachedPasswords{dir: dir}
+}
+
+// Contains reports whether the password is in the list, a missing range file means it is not
+func (b *BreachedPasswords) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:breachedPrefixLength], hash[breachedPrefixLength:]
+
+	file, err := os.Open(filepath.Join(b.dir, prefix))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot open breached password range: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return false, fmt.Errorf("cannot read breached password range: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBreachedPasswords(t *testing.T) {
+	dir := t.TempDir()
+	// sha1("password") is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	rangeFile := "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365\r\n"
+	err := os.WriteFile(filepath.Join(dir, "5BAA6"), []byte(rangeFile), 0o600)
+	require.NoError(t, err)
+
+	breached := NewBreachedPasswords(dir)
+
+	found, err := breached.Contains("password")
+	require.NoError(t, err)
+	require.True(t, found)
+
+	// same prefix file, suffix not listed
+	found, err = breached.Contains("Correct-Horse7")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	policy := PasswordPolicy{Breached: breached}
+	err = policy.Validate("password", "alice", "alice@email.com")
+	var policyErr *PasswordPolicyError
+	require.True(t, errors.As(err, &policyErr))
+	require.Equal(t, []string{"password has appeared in a data breach, please choose another one"}, policyErr.Violations)
+}
+
+func TestBreachedPasswordsUnreadableRange(t *testing.T) {
+	dir := t.TempDir()
+	// a directory where the range file is expected cannot be read
+	err := os.Mkdir(filepath.Join(dir, "5BAA6"), 0o700)
+	require.NoError(t, err)
+
+	_, err = NewBreachedPasswords(dir).Contains("password")
+	require.Error(t, err)
+
+	err = PasswordPolicy{Breached: NewBreachedPasswords(dir)}.Validate("password", "alice", "alice@email.com")
+	var policyErr *PasswordPolicyError
+	require.Error(t, err)
+	require.False(t, errors.As(err, &policyErr))
+}
@@ -11,6 +11,73 @@ type Config struct {
 	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
 	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
 	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	LogLevel            string        `mapstructure:"LOG_LEVEL"`
+	LogFormat           string        `mapstructure:"LOG_FORMAT"`
+	TrustedProxies      []string      `mapstructure:"TRUSTED_PROXIES"`
+	RateLimitBackend    string        `mapstructure:"RATE_LIMIT_BACKEND"`
+	// rate limits are in requests per minute, zero disables the limit
+	PublicRateLimit        int `mapstructure:"PUBLIC_RATE_LIMIT"`
+	LoginRateLimit         int `mapstructure:"LOGIN_RATE_LIMIT"`
+	AuthenticatedRateLimit int `mapstructure:"AUTHENTICATED_RATE_LIMIT"`
+	TransferRateLimit      int `mapstructure:"TRANSFER_RATE_LIMIT"`
+	// logins are locked out after this many consecutive failures, zero disables the lockout
+	LoginMaxFailedAttempts  int           `mapstructure:"LOGIN_MAX_FAILED_ATTEMPTS"`
+	LoginLockoutDuration    time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	LoginMaxLockoutDuration time.Duration `mapstructure:"LOGIN_MAX_LOCKOUT_DURATION"`
+	LoginFailureWindow      time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	MFAEncryptionKey        string        `mapstructure:"MFA_ENCRYPTION_KEY"`
+	MFAIssuer               string        `mapstructure:"MFA_ISSUER"`
+	MFAChallengeDuration    time.Duration `mapstructure:"MFA_CHALLENGE_DURATION"`
+	// transfers of at least the threshold for their currency, e.g. "USD:100000,EUR:100000", need a recent login
+	StepUpThresholds    string        `mapstructure:"STEP_UP_THRESHOLDS"`
+	StepUpMaxAge        time.Duration `mapstructure:"STEP_UP_MAX_AGE"`
+	StepUpTokenDuration time.Duration `mapstructure:"STEP_UP_TOKEN_DURATION"`
+	// MailDriver is one of smtp, file or memory
+	MailDriver   string `mapstructure:"MAIL_DRIVER"`
+	MailFrom     string `mapstructure:"MAIL_FROM"`
+	MailDir      string `mapstructure:"MAIL_DIR"`
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     int    `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	// EmailVerificationURL is the address of the verify email endpoint put into the emails
+	EmailVerificationURL      string        `mapstructure:"EMAIL_VERIFICATION_URL"`
+	EmailVerificationDuration time.Duration `mapstructure:"EMAIL_VERIFICATION_DURATION"`
+	// PasswordResetURL is the address of the page where users choose a new password, put into the emails
+	PasswordResetURL      string        `mapstructure:"PASSWORD_RESET_URL"`
+	PasswordResetDuration time.Duration `mapstructure:"PASSWORD_RESET_DURATION"`
+	// PasswordHasher is argon2id or bcrypt, parameters left at zero take their default value
+	PasswordHasher    string `mapstructure:"PASSWORD_HASHER"`
+	Argon2Memory      uint32 `mapstructure:"ARGON2_MEMORY"`
+	Argon2Iterations  uint32 `mapstructure:"ARGON2_ITERATIONS"`
+	Argon2Parallelism uint8  `mapstructure:"ARGON2_PARALLELISM"`
+	BcryptCost        int    `mapstructure:"BCRYPT_COST"`
+	// password policy, zero values disable a rule
+	PasswordMinLength          int  `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength          int  `mapstructure:"PASSWORD_MAX_LENGTH"`
+	PasswordMinCharClasses     int  `mapstructure:"PASSWORD_MIN_CHAR_CLASSES"`
+	PasswordRejectPersonalInfo bool `mapstructure:"PASSWORD_REJECT_PERSONAL_INFO"`
+	PasswordHistorySize        int  `mapstructure:"PASSWORD_HISTORY_SIZE"`
+	// BreachedPasswordsDir holds the k-anonymity range files of breached password hashes, empty disables the check
+	BreachedPasswordsDir string `mapstructure:"BREACHED_PASSWORDS_DIR"`
+	// ScheduledTransferInterval is how often due scheduled transfers and standing orders are executed and expired holds
+	// released, zero disables the executor
+	ScheduledTransferInterval time.Duration `mapstructure:"SCHEDULED_TRANSFER_INTERVAL"`
+	// standing order payments refused for insufficient funds are retried this many times
+	StandingOrderMaxRetries    int           `mapstructure:"STANDING_ORDER_MAX_RETRIES"`
+	StandingOrderRetryInterval time.Duration `mapstructure:"STANDING_ORDER_RETRY_INTERVAL"`
+	// TransferBatchResumeAfter is how long a batch can stay pending before the executor resumes its remaining items
+	TransferBatchResumeAfter time.Duration `mapstructure:"TRANSFER_BATCH_RESUME_AFTER"`
+	// HoldDuration is how long an authorized transfer holds its amount before expiring, zero disables authorizing transfers
+	HoldDuration time.Duration `mapstructure:"HOLD_DURATION"`
+	// TransferApprovalDuration is how long a transfer waits for its approvals before expiring, zero never expires them
+	TransferApprovalDuration time.Duration `mapstructure:"TRANSFER_APPROVAL_DURATION"`
+	// PaymentRequestDuration is how long a payment request can be accepted before expiring, zero never expires them
+	PaymentRequestDuration time.Duration `mapstructure:"PAYMENT_REQUEST_DURATION"`
+	// transfers to a payee of at least the threshold for their currency, e.g. "USD:50000,EUR:50000", are refused
+	// during the cooling-off period after it was added, zero disables the cooling-off period
+	PayeeCoolingOffPeriod     time.Duration `mapstructure:"PAYEE_COOLING_OFF_PERIOD"`
+	PayeeCoolingOffThresholds string        `mapstructure:"PAYEE_COOLING_OFF_THRESHOLDS"`
 }
 
 // LoadConfig reads configuration from file or environment variables
@@ -1,5 +1,11 @@
 package utils
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 const (
 	USD = "USD"
 	EUR = "EUR"
@@ -14,3 +20,28 @@ func IsSupportedCurrency(currency string) bool {
 	return false
 
 }
+
+// ParseCurrencyAmounts parses a comma separated list such as "USD:1000,EUR:900" into an amount per currency
+func ParseCurrencyAmounts(s string) (map[string]int64, error) {
+	amounts := make(map[string]int64)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		currency, value, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid currency amount %q: expected CURRENCY:AMOUNT", item)
+		}
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if !IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("invalid currency amount %q: unsupported currency", item)
+		}
+		amount, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || amount < 0 {
+			return nil, fmt.Errorf("invalid currency amount %q: amount must be a non negative integer", item)
+		}
+		amounts[currency] = amount
+	}
+	return amounts, nil
+}
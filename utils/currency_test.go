@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseCurrencyAmounts(t *testing.T) {
+	amounts, err := ParseCurrencyAmounts("USD:1000, eur:900,CAD:0")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{USD: 1000, EUR: 900, CAD: 0}, amounts)
+
+	amounts, err = ParseCurrencyAmounts("")
+	require.NoError(t, err)
+	require.Empty(t, amounts)
+
+	for _, invalid := range []string{"USD", "USD:abc", "USD:-1", "XYZ:10"} {
+		_, err = ParseCurrencyAmounts(invalid)
+		require.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,16 @@
+package utils
+
+// TransferFee computes the fee of a transfer of amount: the flat fee plus percentageBps basis points of the amount,
+// rounded half up, then raised to minFee and capped at maxFee. A zero maxFee means no maximum.
+func TransferFee(amount, flatFee, percentageBps, minFee, maxFee int64) int64 {
+	// splits the amount so the multiplication cannot overflow for any amount
+	percentage := amount/10000*percentageBps + (amount%10000*percentageBps+5000)/10000
+	fee := flatFee + percentage
+	if fee < minFee {
+		fee = minFee
+	}
+	if maxFee > 0 && fee > maxFee {
+		fee = maxFee
+	}
+	return fee
+}
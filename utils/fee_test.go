@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTransferFee(t *testing.T) {
+	testCases := []struct {
+		name          string
+		amount        int64
+		flatFee       int64
+		percentageBps int64
+		minFee        int64
+		maxFee        int64
+		fee           int64
+	}{
+		{name: "NoFee", amount: 1000},
+		{name: "Flat", amount: 1000, flatFee: 25, fee: 25},
+		{name: "Percentage", amount: 10000, percentageBps: 150, fee: 150},
+		{name: "RoundsHalfUp", amount: 150, percentageBps: 100, fee: 2},
+		{name: "RoundsDown", amount: 149, percentageBps: 100, fee: 1},
+		{name: "FlatAndPercentage", amount: 10000, flatFee: 10, percentageBps: 100, fee: 110},
+		{name: "Min", amount: 100, percentageBps: 100, minFee: 50, fee: 50},
+		{name: "Max", amount: 1000000, percentageBps: 100, maxFee: 500, fee: 500},
+		{name: "LargeAmount", amount: 9000000000000000000, percentageBps: 10, fee: 9000000000000000},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fee := TransferFee(tc.amount, tc.flatFee, tc.percentageBps, tc.minFee, tc.maxFee)
+			require.Equal(t, tc.fee, fee)
+		})
+	}
+}
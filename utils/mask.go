@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaskName keeps the first letter of each word of a name, so a sender can confirm a recipient
+// without the full name being disclosed, e.g. "John Smith" becomes "J*** S***"
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return "***"
+	}
+	for i, word := range words {
+		first, _ := utf8.DecodeRuneInString(word)
+		words[i] = string(first) + "***"
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,12 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMaskName(t *testing.T) {
+	require.Equal(t, "J*** S***", MaskName("John Smith"))
+	require.Equal(t, "É*** d*** l***", MaskName("  Élodie de  la"))
+	require.Equal(t, "***", MaskName(""))
+}
@@ -1,20 +1,211 @@
 package utils
 
 import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 )
 
-// HashPassword returns the bcrypt hash of the password
+const (
+	PasswordHasherArgon2id = "argon2id"
+	PasswordHasherBcrypt   = "bcrypt"
+)
+
+// bcryptMaxPasswordLength is the number of bytes bcrypt looks at, anything after it is ignored
+const bcryptMaxPasswordLength = 72
+
+var (
+	ErrPasswordMismatch      = errors.New("password does not match")
+	ErrUnsupportedHash       = errors.New("unsupported password hash")
+	ErrPasswordTooLongBcrypt = fmt.Errorf("password must not be longer than %d bytes for bcrypt", bcryptMaxPasswordLength)
+)
+
+// PasswordHasher hashes new passwords, CheckPassword verifies the hashes of every hasher
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// NeedsRehash reports whether the hash was made by another algorithm or with other parameters
+	NeedsRehash(hashedPassword string) bool
+}
+
+// Argon2idParams are the cost parameters of argon2id, memory is in KiB
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follows the OWASP recommendation of 19 MiB of memory and 2 iterations
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// defaultPasswordHasher is used by HashPassword
+var defaultPasswordHasher PasswordHasher = Argon2idHasher{Params: DefaultArgon2idParams}
+
+// NewPasswordHasher creates the hasher configured by PASSWORD_HASHER, zero parameters take their default value
+func NewPasswordHasher(config Config) (PasswordHasher, error) {
+	switch config.PasswordHasher {
+	case "", PasswordHasherArgon2id:
+		params := DefaultArgon2idParams
+		if config.Argon2Memory > 0 {
+			params.Memory = config.Argon2Memory
+		}
+		if config.Argon2Iterations > 0 {
+			params.Iterations = config.Argon2Iterations
+		}
+		if config.Argon2Parallelism > 0 {
+			params.Parallelism = config.Argon2Parallelism
+		}
+		return Argon2idHasher{Params: params}, nil
+	case PasswordHasherBcrypt:
+		cost := bcrypt.DefaultCost
+		if config.BcryptCost > 0 {
+			cost = config.BcryptCost
+		}
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("invalid bcrypt cost %d", cost)
+		}
+		return BcryptHasher{Cost: cost}, nil
+	}
+	return nil, fmt.Errorf("unsupported password hasher %q", config.PasswordHasher)
+}
+
+// HashPassword returns the argon2id hash of the password with the default parameters
 func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return defaultPasswordHasher.Hash(password)
+}
+
+// CheckPassword checks if the provided password is correct or not, the hash may be bcrypt or argon2id
+func CheckPassword(password string, hashedPassword string) error {
+	switch {
+	case strings.HasPrefix(hashedPassword, "$argon2id$"):
+		return checkArgon2id(password, hashedPassword)
+	case isBcryptHash(hashedPassword):
+		err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrPasswordMismatch
+		}
+		return err
+	}
+	return ErrUnsupportedHash
+}
+
+// Argon2idHasher produces PHC formatted argon2id hashes such as $argon2id$v=19$m=19456,t=2,p=1$salt$key
+type Argon2idHasher struct {
+	Params Argon2idParams
+}
+
+func (hasher Argon2idHasher) Hash(password string) (string, error) {
+	salt, err := RandomBytes(int(hasher.Params.SaltLength))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, hasher.Params.Iterations, hasher.Params.Memory, hasher.Params.Parallelism, hasher.Params.KeyLength)
+	return encodeArgon2id(hasher.Params, salt, key), nil
+}
+
+func (hasher Argon2idHasher) NeedsRehash(hashedPassword string) bool {
+	params, salt, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return params.Memory != hasher.Params.Memory ||
+		params.Iterations != hasher.Params.Iterations ||
+		params.Parallelism != hasher.Params.Parallelism ||
+		uint32(len(salt)) < hasher.Params.SaltLength ||
+		uint32(len(key)) != hasher.Params.KeyLength
+}
+
+// BcryptHasher produces bcrypt hashes, it refuses passwords that bcrypt would truncate
+type BcryptHasher struct {
+	Cost int
+}
+
+func (hasher BcryptHasher) Hash(password string) (string, error) {
+	if len(password) > bcryptMaxPasswordLength {
+		return "", ErrPasswordTooLongBcrypt
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), hasher.Cost)
 	if err != nil {
 		return "", fmt.Errorf("failed to hash password %w", err)
 	}
 	return string(hashedPassword), nil
 }
 
-// CheckPassword checks if the provided password is correct or not
-func CheckPassword(password string, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+func (hasher BcryptHasher) NeedsRehash(hashedPassword string) bool {
+	if !isBcryptHash(hashedPassword) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost != hasher.Cost
+}
+
+func isBcryptHash(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$2a$") ||
+		strings.HasPrefix(hashedPassword, "$2b$") ||
+		strings.HasPrefix(hashedPassword, "$2y$")
+}
+
+func checkArgon2id(password string, hashedPassword string) error {
+	params, salt, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return err
+	}
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func encodeArgon2id(params Argon2idParams, salt []byte, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func decodeArgon2id(hashedPassword string) (params Argon2idParams, salt []byte, key []byte, err error) {
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		err = ErrUnsupportedHash
+		return
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		err = fmt.Errorf("%w: argon2 version %q", ErrUnsupportedHash, parts[2])
+		return
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		err = fmt.Errorf("%w: invalid argon2id parameters: %v", ErrUnsupportedHash, err)
+		return
+	}
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		err = fmt.Errorf("%w: invalid argon2id parameters", ErrUnsupportedHash)
+		return
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		err = fmt.Errorf("%w: invalid argon2id salt: %v", ErrUnsupportedHash, err)
+		return
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		err = fmt.Errorf("%w: invalid argon2id key", ErrUnsupportedHash)
+		return
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return
 }
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy describes the passwords users may choose, zero values disable a rule
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	// MinCharClasses is how many of lowercase letters, uppercase letters, digits and symbols are required
+	MinCharClasses int
+	// RejectPersonalInfo refuses passwords that contain the username or the email, or are contained in them
+	RejectPersonalInfo bool
+	// HistorySize is the number of most recent passwords, the current one included, that cannot be used again
+	HistorySize int
+	// Breached refuses passwords found in data breaches when set
+	Breached *BreachedPasswords
+}
+
+// PasswordPolicyError lists every rule a password breaks
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return strings.Join(e.Violations, "; ")
+}
+
+// NewPasswordPolicy creates the policy configured by the PASSWORD_* settings
+func NewPasswordPolicy(config Config) PasswordPolicy {
+	policy := PasswordPolicy{
+		MinLength:          config.PasswordMinLength,
+		MaxLength:          config.PasswordMaxLength,
+		MinCharClasses:     config.PasswordMinCharClasses,
+		RejectPersonalInfo: config.PasswordRejectPersonalInfo,
+		HistorySize:        config.PasswordHistorySize,
+	}
+	if config.BreachedPasswordsDir != "" {
+		policy.Breached = NewBreachedPasswords(config.BreachedPasswordsDir)
+	}
+	return policy
+}
+
+// Validate returns a *PasswordPolicyError if the password of the user breaks the policy.
+// Other errors mean the breached password list could not be read.
+func (policy PasswordPolicy) Validate(password string, username string, email string) error {
+	var violations []string
+
+	length := len([]rune(password))
+	if policy.MinLength > 0 && length < policy.MinLength {
+		violations = append(violations, fmt.Sprintf("password must be at least %d characters long", policy.MinLength))
+	}
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		violations = append(violations, fmt.Sprintf("password must be at most %d characters long", policy.MaxLength))
+	}
+	if policy.MinCharClasses > 0 && charClasses(password) < policy.MinCharClasses {
+		violations = append(violations, fmt.Sprintf(
+			"password must contain at least %d of lowercase letters, uppercase letters, digits and symbols", policy.MinCharClasses))
+	}
+	if policy.RejectPersonalInfo && containsPersonalInfo(password, username, email) {
+		violations = append(violations, "password must not be similar to the username or email")
+	}
+	if policy.Breached != nil {
+		breached, err := policy.Breached.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violations = append(violations, "password has appeared in a data breach, please choose another one")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// ErrPasswordReused is returned when a password matches one of the last PasswordPolicy.HistorySize passwords
+var ErrPasswordReused = &PasswordPolicyError{Violations: []string{"password must not be one of your recent passwords"}}
+
+func charClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// personalInfoMinLength avoids refusing passwords for containing very short usernames
+const personalInfoMinLength = 3
+
+func containsPersonalInfo(password string, username string, email string) bool {
+	password = strings.ToLower(password)
+	localPart, _, _ := strings.Cut(email, "@")
+	for _, info := range []string{username, localPart, email} {
+		info = strings.ToLower(info)
+		if len(info) < personalInfoMinLength {
+			continue
+		}
+		if strings.Contains(password, info) || strings.Contains(info, password) {
+			return true
+		}
+	}
+	return false
+}
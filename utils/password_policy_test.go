@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:          10,
+		MaxLength:          20,
+		MinCharClasses:     3,
+		RejectPersonalInfo: true,
+	}
+	username := "alice"
+	email := "alice.smith@email.com"
+
+	testCases := []struct {
+		name       string
+		password   string
+		violations []string
+	}{
+		{
+			name:     "OK",
+			password: "Correct-Horse7",
+		},
+		{
+			name:     "TooShort",
+			password: "Ab1-",
+			violations: []string{
+				"password must be at least 10 characters long",
+			},
+		},
+		{
+			name:     "TooLong",
+			password: "Correct-Horse-Battery-Staple-7",
+			violations: []string{
+				"password must be at most 20 characters long",
+			},
+		},
+		{
+			name:     "TooFewCharClasses",
+			password: "correcthorsebattery",
+			violations: []string{
+				"password must contain at least 3 of lowercase letters, uppercase letters, digits and symbols",
+			},
+		},
+		{
+			name:     "ContainsUsername",
+			password: "My-ALICE-pass1",
+			violations: []string{
+				"password must not be similar to the username or email",
+			},
+		},
+		{
+			name:     "ContainsEmailLocalPart",
+			password: "Alice.Smith99",
+			violations: []string{
+				"password must not be similar to the username or email",
+			},
+		},
+		{
+			name:     "EverythingWrong",
+			password: "alice",
+			violations: []string{
+				"password must be at least 10 characters long",
+				"password must contain at least 3 of lowercase letters, uppercase letters, digits and symbols",
+				"password must not be similar to the username or email",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Validate(tc.password, username, email)
+			if tc.violations == nil {
+				require.NoError(t, err)
+				return
+			}
+			var policyErr *PasswordPolicyError
+			require.True(t, errors.As(err, &policyErr))
+			require.Equal(t, tc.violations, policyErr.Violations)
+		})
+	}
+}
+
+func TestPasswordPolicyDisabled(t *testing.T) {
+	require.NoError(t, PasswordPolicy{}.Validate("a", "a", "a@email.com"))
+	require.NoError(t, NewPasswordPolicy(Config{}).Validate("alice", "alice", "alice@email.com"))
+}
+
+func TestNewPasswordPolicy(t *testing.T) {
+	policy := NewPasswordPolicy(Config{
+		PasswordMinLength:          10,
+		PasswordMaxLength:          128,
+		PasswordMinCharClasses:     2,
+		PasswordRejectPersonalInfo: true,
+		PasswordHistorySize:        5,
+		BreachedPasswordsDir:       t.TempDir(),
+	})
+	require.Equal(t, 10, policy.MinLength)
+	require.Equal(t, 128, policy.MaxLength)
+	require.Equal(t, 2, policy.MinCharClasses)
+	require.True(t, policy.RejectPersonalInfo)
+	require.Equal(t, 5, policy.HistorySize)
+	require.NotNil(t, policy.Breached)
+}
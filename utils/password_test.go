@@ -3,6 +3,8 @@ package utils
 import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -18,10 +20,84 @@ func TestPassword(t *testing.T) {
 
 	wrongPassword := RandomString(6)
 	err = CheckPassword(wrongPassword, hashedPassword)
-	require.EqualError(t, err, bcrypt.ErrMismatchedHashAndPassword.Error())
+	require.EqualError(t, err, ErrPasswordMismatch.Error())
 
 	hashedPassword2, err := HashPassword(password)
 	require.NoError(t, err)
 	require.NotEmpty(t, hashedPassword2)
 	require.NotEqual(t, hashedPassword, hashedPassword2)
 }
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := Argon2idHasher{Params: DefaultArgon2idParams}
+	password := RandomString(80)
+
+	hashedPassword, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.Regexp(t, regexp.MustCompile(`^\$argon2id\$v=19\$m=19456,t=2,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`), hashedPassword)
+	require.False(t, hasher.NeedsRehash(hashedPassword))
+
+	require.NoError(t, CheckPassword(password, hashedPassword))
+	// unlike bcrypt, every byte of a long password counts
+	require.ErrorIs(t, CheckPassword(password[:79], hashedPassword), ErrPasswordMismatch)
+
+	stronger := Argon2idHasher{Params: DefaultArgon2idParams}
+	stronger.Params.Iterations++
+	require.True(t, stronger.NeedsRehash(hashedPassword))
+}
+
+func TestBcryptHasher(t *testing.T) {
+	hasher := BcryptHasher{Cost: bcrypt.MinCost}
+	password := RandomString(6)
+
+	hashedPassword, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hashedPassword, "$2a$"))
+	require.False(t, hasher.NeedsRehash(hashedPassword))
+	require.True(t, BcryptHasher{Cost: bcrypt.MinCost + 1}.NeedsRehash(hashedPassword))
+
+	// existing bcrypt hashes keep working and are replaced by argon2id ones
+	require.NoError(t, CheckPassword(password, hashedPassword))
+	require.ErrorIs(t, CheckPassword(RandomString(6), hashedPassword), ErrPasswordMismatch)
+	require.True(t, Argon2idHasher{Params: DefaultArgon2idParams}.NeedsRehash(hashedPassword))
+
+	_, err = hasher.Hash(RandomString(bcryptMaxPasswordLength + 1))
+	require.ErrorIs(t, err, ErrPasswordTooLongBcrypt)
+}
+
+func TestCheckPasswordInvalidHash(t *testing.T) {
+	password := RandomString(6)
+	for _, hashedPassword := range []string{
+		"",
+		"plaintext",
+		"$argon2i$v=19$m=19456,t=2,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=16$m=19456,t=2,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=0,t=2,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=19456,t=2,p=1$c2FsdHNhbHQ$",
+	} {
+		require.ErrorIs(t, CheckPassword(password, hashedPassword), ErrUnsupportedHash, hashedPassword)
+	}
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(Config{})
+	require.NoError(t, err)
+	require.Equal(t, Argon2idHasher{Params: DefaultArgon2idParams}, hasher)
+
+	hasher, err = NewPasswordHasher(Config{PasswordHasher: PasswordHasherArgon2id, Argon2Memory: 65536, Argon2Iterations: 3, Argon2Parallelism: 4})
+	require.NoError(t, err)
+	params := hasher.(Argon2idHasher).Params
+	require.Equal(t, uint32(65536), params.Memory)
+	require.Equal(t, uint32(3), params.Iterations)
+	require.Equal(t, uint8(4), params.Parallelism)
+
+	hasher, err = NewPasswordHasher(Config{PasswordHasher: PasswordHasherBcrypt})
+	require.NoError(t, err)
+	require.Equal(t, BcryptHasher{Cost: bcrypt.DefaultCost}, hasher)
+
+	_, err = NewPasswordHasher(Config{PasswordHasher: PasswordHasherBcrypt, BcryptCost: 100})
+	require.Error(t, err)
+
+	_, err = NewPasswordHasher(Config{PasswordHasher: "md5"})
+	require.Error(t, err)
+}
@@ -0,0 +1,9 @@
+package utils
+
+const (
+	PaymentRequestStatusPending   = "pending"
+	PaymentRequestStatusAccepted  = "accepted"
+	PaymentRequestStatusDeclined  = "declined"
+	PaymentRequestStatusCancelled = "cancelled"
+	PaymentRequestStatusExpired   = "expired"
+)
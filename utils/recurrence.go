@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+)
+
+func IsSupportedFrequency(frequency string) bool {
+	switch frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+		return true
+	}
+	return false
+}
+
+// Recurrence describes when a standing order is paid.
+// Weekly orders repeat on the weekday of StartAt, monthly ones on DayOfMonth,
+// or on the last day of shorter months. Every occurrence keeps the time of day of StartAt.
+type Recurrence struct {
+	Frequency  string
+	DayOfMonth int
+	StartAt    time.Time
+	// EndAt is the last time an occurrence may happen, zero means no end date
+	EndAt time.Time
+	// Count is the number of occurrences, zero means no limit
+	Count int
+}
+
+// Validate checks that the rule is complete
+func (r Recurrence) Validate() error {
+	if !IsSupportedFrequency(r.Frequency) {
+		return errors.New("frequency must be daily, weekly or monthly")
+	}
+	if r.Frequency == FrequencyMonthly && (r.DayOfMonth < 1 || r.DayOfMonth > 31) {
+		return errors.New("monthly orders need a day of month between 1 and 31")
+	}
+	if r.Frequency != FrequencyMonthly && r.DayOfMonth != 0 {
+		return errors.New("day of month is only allowed for monthly orders")
+	}
+	if r.Count < 0 {
+		return errors.New("count must not be negative")
+	}
+	if _, ok := r.Occurrence(0); !ok {
+		return errors.New("the order ends before its first occurrence")
+	}
+	return nil
+}
+
+// Occurrence returns the time of the nth occurrence, counting from zero,
+// and false once the end date or the count has been reached
+func (r Recurrence) Occurrence(n int) (time.Time, bool) {
+	if n < 0 || (r.Count > 0 && n >= r.Count) {
+		return time.Time{}, false
+	}
+
+	var at time.Time
+	switch r.Frequency {
+	case FrequencyDaily:
+		at = r.StartAt.AddDate(0, 0, n)
+	case FrequencyWeekly:
+		at = r.StartAt.AddDate(0, 0, 7*n)
+	case FrequencyMonthly:
+		// the first occurrence is the first day of month on or after the start
+		if r.monthlyAt(0).Before(r.StartAt) {
+			n++
+		}
+		at = r.monthlyAt(n)
+	default:
+		return time.Time{}, false
+	}
+
+	if !r.EndAt.IsZero() && at.After(r.EndAt) {
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+// monthlyAt returns the day of month n months after the month of StartAt
+func (r Recurrence) monthlyAt(n int) time.Time {
+	year, month, _ := r.StartAt.Date()
+	hour, min, sec := r.StartAt.Clock()
+	firstOfMonth := time.Date(year, month+time.Month(n), 1, hour, min, sec, r.StartAt.Nanosecond(), r.StartAt.Location())
+	day := r.DayOfMonth
+	if last := firstOfMonth.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRecurrenceOccurrence(t *testing.T) {
+	start := time.Date(2024, time.January, 15, 9, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		recurrence Recurrence
+		expected   []time.Time
+	}{
+		{
+			name:       "Daily",
+			recurrence: Recurrence{Frequency: FrequencyDaily, StartAt: start, Count: 3},
+			expected: []time.Time{
+				start,
+				start.AddDate(0, 0, 1),
+				start.AddDate(0, 0, 2),
+			},
+		},
+		{
+			name:       "WeeklyUntilEndDate",
+			recurrence: Recurrence{Frequency: FrequencyWeekly, StartAt: start, EndAt: start.AddDate(0, 0, 20)},
+			expected: []time.Time{
+				start,
+				start.AddDate(0, 0, 7),
+				start.AddDate(0, 0, 14),
+			},
+		},
+		{
+			name:       "MonthlyLaterThisMonth",
+			recurrence: Recurrence{Frequency: FrequencyMonthly, DayOfMonth: 20, StartAt: start, Count: 2},
+			expected: []time.Time{
+				time.Date(2024, time.January, 20, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.February, 20, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "MonthlyStartsNextMonth",
+			recurrence: Recurrence{Frequency: FrequencyMonthly, DayOfMonth: 1, StartAt: start, Count: 2},
+			expected: []time.Time{
+				time.Date(2024, time.February, 1, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.March, 1, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "MonthlyClampedToShortMonths",
+			recurrence: Recurrence{Frequency: FrequencyMonthly, DayOfMonth: 31, StartAt: start, Count: 4},
+			expected: []time.Time{
+				time.Date(2024, time.January, 31, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.February, 29, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.March, 31, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.April, 30, 9, 30, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.recurrence.Validate())
+
+			var got []time.Time
+			for n := 0; ; n++ {
+				at, ok := tc.recurrence.Occurrence(n)
+				if !ok {
+					break
+				}
+				got = append(got, at)
+			}
+			require.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestRecurrenceValidate(t *testing.T) {
+	start := time.Now()
+
+	invalid := []Recurrence{
+		{Frequency: "yearly", StartAt: start},
+		{Frequency: FrequencyMonthly, StartAt: start},
+		{Frequency: FrequencyMonthly, DayOfMonth: 32, StartAt: start},
+		{Frequency: FrequencyWeekly, DayOfMonth: 3, StartAt: start},
+		{Frequency: FrequencyDaily, StartAt: start, Count: -1},
+		{Frequency: FrequencyDaily, StartAt: start, EndAt: start.Add(-time.Hour)},
+	}
+	for _, recurrence := range invalid {
+		require.Error(t, recurrence.Validate(), "%+v", recurrence)
+	}
+}
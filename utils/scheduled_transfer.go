@@ -0,0 +1,8 @@
+package utils
+
+const (
+	ScheduledTransferStatusPending   = "pending"
+	ScheduledTransferStatusSucceeded = "succeeded"
+	ScheduledTransferStatusFailed    = "failed"
+	ScheduledTransferStatusCancelled = "cancelled"
+)
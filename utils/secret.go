@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// RandomBytes returns n bytes from a cryptographically secure source
+func RandomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("cannot read random bytes: %w", err)
+	}
+	return b, nil
+}
+
+// GenerateSecureToken returns a url safe token built from n random bytes
+func GenerateSecureToken(n int) (string, error) {
+	b, err := RandomBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the sha256 hash of a high entropy token, suitable for storing and looking it up
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// EncryptSecret encrypts a secret that needs to be read back, such as a totp secret, with a 32 byte key
+func EncryptSecret(key string, plaintext string) (string, error) {
+	aead, err := chacha20poly1305.NewX([]byte(key))
+	if err != nil {
+		return "", fmt.Errorf("invalid encryption key: %w", err)
+	}
+	nonce, err := RandomBytes(aead.NonceSize())
+	if err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret decrypts a secret encrypted by EncryptSecret
+func DecryptSecret(key string, ciphertext string) (string, error) {
+	aead, err := chacha20poly1305.NewX([]byte(key))
+	if err != nil {
+		return "", fmt.Errorf("invalid encryption key: %w", err)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted secret: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted secret: too short")
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,7 @@
+package utils
+
+const (
+	StandingOrderStatusActive    = "active"
+	StandingOrderStatusCompleted = "completed"
+	StandingOrderStatusCancelled = "cancelled"
+)
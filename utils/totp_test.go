@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"encoding/base32"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// test vectors from RFC 6238 appendix B, truncated to 6 digits
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tc := range testCases {
+		code, err := TOTPCode(secret, time.Unix(tc.unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, tc.code, code)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := TOTPCode(secret, now)
+	require.NoError(t, err)
+
+	step, ok := ValidateTOTP(secret, code, now)
+	require.True(t, ok)
+	require.Equal(t, TOTPStep(now), step)
+
+	// codes from the previous period are still accepted
+	_, ok = ValidateTOTP(secret, code, now.Add(totpPeriod*time.Second))
+	require.True(t, ok)
+
+	_, ok = ValidateTOTP(secret, code, now.Add(3*totpPeriod*time.Second))
+	require.False(t, ok)
+
+	_, ok = ValidateTOTP(secret, "000000x", now)
+	require.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Simple Bank", "alice", "SECRET")
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/Simple%20Bank:alice?"))
+	require.Contains(t, uri, "secret=SECRET")
+	require.Contains(t, uri, "issuer=Simple+Bank")
+}
+
+func TestEncryptSecret(t *testing.T) {
+	key := RandomString(32)
+	secret := RandomString(20)
+
+	encrypted, err := EncryptSecret(key, secret)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, encrypted)
+
+	decrypted, err := DecryptSecret(key, encrypted)
+	require.NoError(t, err)
+	require.Equal(t, secret, decrypted)
+
+	_, err = DecryptSecret(RandomString(32), encrypted)
+	require.Error(t, err)
+}
@@ -0,0 +1,14 @@
+package utils
+
+const (
+	TransferBatchStatusPending            = "pending"
+	TransferBatchStatusSucceeded          = "succeeded"
+	TransferBatchStatusPartiallySucceeded = "partially_succeeded"
+	TransferBatchStatusFailed             = "failed"
+)
+
+const (
+	TransferBatchItemStatusPending   = "pending"
+	TransferBatchItemStatusSucceeded = "succeeded"
+	TransferBatchItemStatusFailed    = "failed"
+)
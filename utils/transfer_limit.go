@@ -0,0 +1,27 @@
+package utils
+
+import "time"
+
+// DayStart returns the start of the calendar day of t in UTC, when daily transfer limits reset
+func DayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// MonthStart returns the start of the calendar month of t in UTC, when monthly transfer limits reset
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// RemainingAllowance returns how much can still be sent under limit after used was sent.
+// A zero limit means no limit, it returns false then.
+func RemainingAllowance(limit, used int64) (int64, bool) {
+	if limit == 0 {
+		return 0, false
+	}
+	if used >= limit {
+		return 0, true
+	}
+	return limit - used, true
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLimitPeriodStart(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 23, 30, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+
+	require.Equal(t, time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC), DayStart(now))
+	require.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), MonthStart(now))
+}
+
+func TestRemainingAllowance(t *testing.T) {
+	testCases := []struct {
+		name      string
+		limit     int64
+		used      int64
+		remaining int64
+		limited   bool
+	}{
+		{name: "NoLimit", limit: 0, used: 100, remaining: 0, limited: false},
+		{name: "Unused", limit: 100, used: 0, remaining: 100, limited: true},
+		{name: "PartlyUsed", limit: 100, used: 40, remaining: 60, limited: true},
+		{name: "Exhausted", limit: 100, used: 100, remaining: 0, limited: true},
+		// limits lowered below what was already sent leave nothing
+		{name: "Exceeded", limit: 100, used: 150, remaining: 0, limited: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			remaining, limited := RemainingAllowance(tc.limit, tc.used)
+			require.Equal(t, tc.remaining, remaining)
+			require.Equal(t, tc.limited, limited)
+		})
+	}
+}
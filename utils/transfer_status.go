@@ -0,0 +1,8 @@
+package utils
+
+const (
+	TransferStatusPending   = "pending"
+	TransferStatusCompleted = "completed"
+	TransferStatusRejected  = "rejected"
+	TransferStatusExpired   = "expired"
+)